@@ -0,0 +1,202 @@
+package blockchain
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "sync"
+    "time"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+const maxBadBlocks = 64
+
+var (
+    ErrKnownBadBlock = errors.New("block was rejected before")
+    ErrBadAncestor   = errors.New("parent block was rejected before")
+)
+
+// badBlockRules maps validation errors to the rule a block broke. Errors not
+// listed here (storage failures, missing parents, ...) do not make a block bad.
+// Neither do errors in a PoS block's own commit certificate, which is not
+// covered by the block hash: the same block may still arrive certified. Nor
+// does a body that does not match the header's transaction root or last
+// commit hash: anyone can pair an honest header with another body, so such
+// a failure says nothing about the block the hash names.
+var badBlockRules = []struct {
+    err  error
+    rule string
+}{
+    {ErrInvalidNumber, "block-number"},
+    {ErrFinalizedFork, "finality"},
+    {ErrInvalidStateRoot, "state-root"},
+    {ErrInvalidChainID, "tx-chain-id"},
+    {hybrid.ErrInvalidDifficulty, "pow-difficulty"},
+    {hybrid.ErrInvalidPoW, "pow-seal"},
+    {hybrid.ErrInvalidCheckpoint, "checkpoint-flag"},
+    {hybrid.ErrBlockTimeTooEarly, "block-time"},
+    {hybrid.ErrInvalidSlot, "block-time"},
+    {hybrid.ErrExtraDataTooLong, "extra-data"},
+    {hybrid.ErrInsufficientStake, "validator-stake"},
+    {hybrid.ErrInvalidLastCommit, "last-commit"},
+    {hybrid.ErrUnknownValidator, "validator-set"},
+    {hybrid.ErrWrongProposer, "proposer"},
+    {hybrid.ErrInvalidRandao, "randao"},
+    {hybrid.ErrUnauthorizedSigner, "pos-seal"},
+    {keys.ErrMissingSeal, "pos-seal"},
+    {keys.ErrInvalidSeal, "pos-seal"},
+    {keys.ErrMissingSignature, "tx-signature"},
+    {keys.ErrInvalidSignature, "tx-signature"},
+    {state.ErrNonceTooLow, "tx-nonce"},
+    {state.ErrNonceTooHigh, "tx-nonce"},
+    {state.ErrIntrinsicGas, "tx-gas"},
+    {state.ErrInsufficientFunds, "tx-funds"},
+    {state.ErrInsufficientStake, "tx-execution"},
+    {state.ErrNotStaker, "tx-execution"},
+    {state.ErrMissingRecipient, "tx-execution"},
+    {state.ErrUnknownTxType, "tx-execution"},
+    {state.ErrNegativeValue, "tx-execution"},
+    {state.ErrBlockGasLimit, "block-gas"},
+    {hybrid.ErrInvalidEvidence, "tx-evidence"},
+    {hybrid.ErrDuplicateEvidence, "tx-evidence"},
+    {hybrid.ErrNotJailed, "tx-unjail"},
+    {hybrid.ErrStillJailed, "tx-unjail"},
+}
+
+// BadBlock is a block that failed validation, kept for diagnosing consensus splits
+type BadBlock struct {
+    Hash       types.Hash   `json:"hash"`
+    Block      *types.Block `json:"block"`
+    Error      string       `json:"error"`
+    Rule       string       `json:"rule"`
+    Peer       string       `json:"peer,omitempty"` // Empty for locally produced or imported blocks
+    LocalHead  uint64       `json:"localHead"`
+    LocalHash  types.Hash   `json:"localHeadHash"`
+    RejectedAt time.Time    `json:"rejectedAt"`
+}
+
+// badBlockRegistry keeps the most recent bad blocks, oldest first, and
+// mirrors them to the database so they survive restarts
+type badBlockRegistry struct {
+    db     rawdb.Database
+    blocks []*BadBlock
+    mu     sync.RWMutex
+}
+
+func newBadBlockRegistry(db rawdb.Database) *badBlockRegistry {
+    registry := &badBlockRegistry{db: db}
+    if data := rawdb.ReadBadBlocks(db); len(data) > 0 {
+        if err := json.Unmarshal(data, &registry.blocks); err != nil {
+            fmt.Printf("⚠️  Ignoring corrupt bad block registry: %v\n", err)
+            registry.blocks = nil
+        }
+    }
+    return registry
+}
+
+// badBlockRule returns the rule err reports as broken, or "" if err does
+// not say anything about the block itself
+func badBlockRule(err error) string {
+    for _, entry := range badBlockRules {
+        if errors.Is(err, entry.err) {
+            return entry.rule
+        }
+    }
+    return ""
+}
+
+// add records entry unless its hash is already known
+func (r *badBlockRegistry) add(entry *BadBlock) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, known := range r.blocks {
+        if known.Hash == entry.Hash {
+            return
+        }
+    }
+    r.blocks = append(r.blocks, entry)
+    if len(r.blocks) > maxBadBlocks {
+        r.blocks = r.blocks[len(r.blocks)-maxBadBlocks:]
+    }
+
+    data, err := json.Marshal(r.blocks)
+    if err == nil {
+        batch := r.db.NewBatch()
+        rawdb.WriteBadBlocks(batch, data)
+        err = batch.Write()
+    }
+    if err != nil {
+        fmt.Printf("⚠️  Failed to store bad block registry: %v\n", err)
+    }
+}
+
+func (r *badBlockRegistry) get(blockHash types.Hash) *BadBlock {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, entry := range r.blocks {
+        if entry.Hash == blockHash {
+            return entry
+        }
+    }
+    return nil
+}
+
+// list returns the registered bad blocks, newest first
+func (r *badBlockRegistry) list() []*BadBlock {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    blocks := make([]*BadBlock, 0, len(r.blocks))
+    for i := len(r.blocks) - 1; i >= 0; i-- {
+        blocks = append(blocks, r.blocks[i])
+    }
+    return blocks
+}
+
+// reportBadBlock records block in the registry if err is a validation failure
+func (bc *Blockchain) reportBadBlock(block *types.Block, blockHash types.Hash, peer string, err error) {
+    rule := badBlockRule(err)
+    if rule == "" {
+        return
+    }
+    bc.badBlocks.add(&BadBlock{
+        Hash:       blockHash,
+        Block:      block,
+        Error:      err.Error(),
+        Rule:       rule,
+        Peer:       peer,
+        LocalHead:  bc.current.Header.Number.Uint64(),
+        LocalHash:  bc.CalculateHash(bc.current.Header),
+        RejectedAt: time.Now().UTC(),
+    })
+    fmt.Printf("🚫 Bad block #%s (%x) broke rule %s: %v\n", block.Header.Number, blockHash[:4], rule, err)
+
+    // Orphans waiting for it can never be inserted
+    if dropped := bc.buffer.dropDescendants(blockHash); dropped > 0 {
+        fmt.Printf("🧹 Dropped %d buffered descendants of bad block %x\n", dropped, blockHash[:4])
+    }
+}
+
+// BadBlocks returns the recently rejected blocks, newest first
+func (bc *Blockchain) BadBlocks() []*BadBlock {
+    return bc.badBlocks.list()
+}
+
+// GetBadBlock returns the bad block with the given hash, or nil
+func (bc *Blockchain) GetBadBlock(blockHash types.Hash) *BadBlock {
+    return bc.badBlocks.get(blockHash)
+}
+
+// DumpBadBlocks writes the bad block registry to w as indented JSON
+func (bc *Blockchain) DumpBadBlocks(w io.Writer) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(bc.BadBlocks())
+}
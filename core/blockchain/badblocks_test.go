@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// A peer relaying an honest header with another body must not get the
+// honest block refused
+func TestTamperedBodyDoesNotBanBlock(t *testing.T) {
+    tc := newTestChain(t, nil, nil)
+    tc.extend(t, 1, types.Address{0xa}, false)
+
+    head, statedb := tc.CurrentState()
+    block := tc.makeBlock(t, head, []*types.Transaction{newTransfer(t, tc.ChainID(), statedb.GetNonce(testKey.Address), types.Address{0xb})}, types.Address{0xa})
+    if len(block.LastCommit) == 0 {
+        t.Fatal("block #2 carries no last commit")
+    }
+
+    // A transaction the sender cannot pay for
+    unfunded := newTransfer(t, tc.ChainID(), statedb.GetNonce(testKey.Address), types.Address{0xb})
+    unfunded.Value = new(big.Int).Mul(selsi(1000), big.NewInt(2))
+    if err := testKey.SignTransaction(unfunded); err != nil {
+        t.Fatal(err)
+    }
+    for _, test := range []struct {
+        name string
+        body types.Block
+        want error
+    }{
+        {"transactions", types.Block{Header: block.Header, Transactions: []*types.Transaction{unfunded}, Votes: block.Votes, LastCommit: block.LastCommit}, ErrInvalidTxRoot},
+        {"last commit", types.Block{Header: block.Header, Transactions: block.Transactions, Votes: block.Votes, LastCommit: block.LastCommit[:1]}, ErrInvalidCommitHash},
+    } {
+        if err := tc.AddBlock(&test.body); !errors.Is(err, test.want) {
+            t.Fatalf("tampered %s: got %v, want %v", test.name, err, test.want)
+        }
+        if bad := tc.GetBadBlock(tc.CalculateHash(block.Header)); bad != nil {
+            t.Fatalf("tampered %s recorded the block as bad: %s", test.name, bad.Error)
+        }
+    }
+    if err := tc.AddBlock(block); err != nil {
+        t.Fatalf("honest block refused: %v", err)
+    }
+}
+
+// Orphans building on a block that turns out bad are dropped, and later
+// ones are refused instead of buffered until they expire
+func TestBadBlockDescendantsNotBuffered(t *testing.T) {
+    src := newTestChain(t, nil, nil)
+    blocks := src.extend(t, 3, types.Address{0xa}, false)
+    dst := newTestChain(t, nil, src.clock)
+
+    // Blocks #2 and #3 wait for #1
+    for _, block := range blocks[1:] {
+        if err := dst.InsertBlock(block, "peer-1"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if orphans, _ := dst.BufferedBlocks(); orphans != 2 {
+        t.Fatalf("%d orphans buffered, want 2", orphans)
+    }
+
+    dst.reportBadBlock(blocks[0], dst.CalculateHash(blocks[0].Header), "peer-1", ErrInvalidStateRoot)
+    if orphans, _ := dst.BufferedBlocks(); orphans != 0 {
+        t.Fatalf("%d descendants of the bad block left in the buffer", orphans)
+    }
+    if err := dst.InsertBlock(blocks[1], "peer-2"); !errors.Is(err, ErrBadAncestor) {
+        t.Fatalf("child of a bad block: got %v, want %v", err, ErrBadAncestor)
+    }
+    if orphans, _ := dst.BufferedBlocks(); orphans != 0 {
+        t.Fatalf("child of the bad block buffered")
+    }
+}
@@ -0,0 +1,321 @@
+package blockchain
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+const (
+    allowedFutureBlockTime = 5 * time.Second   // Clock drift accepted without buffering
+    maxFutureBlockTime     = 2 * time.Minute   // Blocks further ahead are rejected outright
+    maxBufferedBlocks      = 1024              // Orphan and future blocks held in total
+    maxBufferedPerPeer     = 128               // ...and per delivering peer
+    bufferedBlockExpiry    = 10 * time.Minute  // Orphans whose parent never shows up are dropped
+    bufferCheckInterval    = time.Second
+)
+
+var (
+    ErrFutureBlock     = errors.New("block timestamp is in the future")
+    ErrBufferFull      = errors.New("block buffer is full")
+    ErrPeerBufferLimit = errors.New("peer exceeded its buffered block limit")
+)
+
+// bufferedBlock is a block waiting for its parent or its timestamp
+type bufferedBlock struct {
+    block    *types.Block
+    hash     types.Hash
+    peer     string
+    received time.Time
+}
+
+// blockBuffer holds blocks that cannot be inserted yet: orphans keyed by
+// their missing parent hash and future blocks ordered by timestamp
+type blockBuffer struct {
+    orphans map[types.Hash][]*bufferedBlock
+    future  []*bufferedBlock
+    known   map[types.Hash]*bufferedBlock
+    perPeer map[string]int
+    mu      sync.Mutex
+}
+
+func newBlockBuffer() *blockBuffer {
+    return &blockBuffer{
+        orphans: make(map[types.Hash][]*bufferedBlock),
+        known:   make(map[types.Hash]*bufferedBlock),
+        perPeer: make(map[string]int),
+    }
+}
+
+// reserve checks the limits and accounts for a new entry; the caller holds mu
+func (b *blockBuffer) reserve(entry *bufferedBlock) error {
+    if len(b.known) >= maxBufferedBlocks {
+        return ErrBufferFull
+    }
+    if entry.peer != "" && b.perPeer[entry.peer] >= maxBufferedPerPeer {
+        return fmt.Errorf("%w: %s", ErrPeerBufferLimit, entry.peer)
+    }
+    b.known[entry.hash] = entry
+    if entry.peer != "" {
+        b.perPeer[entry.peer]++
+    }
+    return nil
+}
+
+// release undoes reserve; the caller holds mu
+func (b *blockBuffer) release(entry *bufferedBlock) {
+    delete(b.known, entry.hash)
+    if entry.peer != "" {
+        if b.perPeer[entry.peer]--; b.perPeer[entry.peer] <= 0 {
+            delete(b.perPeer, entry.peer)
+        }
+    }
+}
+
+// addOrphan buffers entry until its parent is inserted
+func (b *blockBuffer) addOrphan(entry *bufferedBlock) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if _, exists := b.known[entry.hash]; exists {
+        return nil
+    }
+    if err := b.reserve(entry); err != nil {
+        return err
+    }
+    parent := entry.block.Header.ParentHash
+    b.orphans[parent] = append(b.orphans[parent], entry)
+    return nil
+}
+
+// addFuture buffers entry until its timestamp is reached
+func (b *blockBuffer) addFuture(entry *bufferedBlock) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if _, exists := b.known[entry.hash]; exists {
+        return nil
+    }
+    if err := b.reserve(entry); err != nil {
+        return err
+    }
+    index := sort.Search(len(b.future), func(i int) bool {
+        return b.future[i].block.Header.Time > entry.block.Header.Time
+    })
+    b.future = append(b.future, nil)
+    copy(b.future[index+1:], b.future[index:])
+    b.future[index] = entry
+    return nil
+}
+
+// takeOrphans removes and returns the blocks waiting for parent
+func (b *blockBuffer) takeOrphans(parent types.Hash) []*bufferedBlock {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    children := b.orphans[parent]
+    delete(b.orphans, parent)
+    for _, entry := range children {
+        b.release(entry)
+    }
+    return children
+}
+
+// dropDescendants removes the orphans building on parent, directly or
+// through other orphans
+func (b *blockBuffer) dropDescendants(parent types.Hash) int {
+    dropped := 0
+    for queue := []types.Hash{parent}; len(queue) > 0; queue = queue[1:] {
+        for _, entry := range b.takeOrphans(queue[0]) {
+            queue = append(queue, entry.hash)
+            dropped++
+        }
+    }
+    return dropped
+}
+
+// takeDue removes and returns the future blocks that may be inserted at now
+func (b *blockBuffer) takeDue(now time.Time) []*bufferedBlock {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    limit := uint64(now.Add(allowedFutureBlockTime).Unix())
+    count := sort.Search(len(b.future), func(i int) bool {
+        return b.future[i].block.Header.Time > limit
+    })
+    due := append([]*bufferedBlock{}, b.future[:count]...)
+    b.future = b.future[count:]
+    for _, entry := range due {
+        b.release(entry)
+    }
+    return due
+}
+
+// expire drops orphans buffered for longer than bufferedBlockExpiry
+func (b *blockBuffer) expire(now time.Time) int {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    expired := 0
+    for parent, children := range b.orphans {
+        kept := children[:0]
+        for _, entry := range children {
+            if now.Sub(entry.received) > bufferedBlockExpiry {
+                b.release(entry)
+                expired++
+            } else {
+                kept = append(kept, entry)
+            }
+        }
+        if len(kept) == 0 {
+            delete(b.orphans, parent)
+        } else {
+            b.orphans[parent] = kept
+        }
+    }
+    return expired
+}
+
+// dropPeer removes every block delivered by peer
+func (b *blockBuffer) dropPeer(peer string) int {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    dropped := 0
+    for parent, children := range b.orphans {
+        kept := children[:0]
+        for _, entry := range children {
+            if entry.peer == peer {
+                b.release(entry)
+                dropped++
+            } else {
+                kept = append(kept, entry)
+            }
+        }
+        if len(kept) == 0 {
+            delete(b.orphans, parent)
+        } else {
+            b.orphans[parent] = kept
+        }
+    }
+    kept := b.future[:0]
+    for _, entry := range b.future {
+        if entry.peer == peer {
+            b.release(entry)
+            dropped++
+        } else {
+            kept = append(kept, entry)
+        }
+    }
+    b.future = kept
+    return dropped
+}
+
+// counts returns the number of buffered orphan and future blocks
+func (b *blockBuffer) counts() (int, int) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    return len(b.known) - len(b.future), len(b.future)
+}
+
+// InsertBlock is the entry point for blocks received from peer. Blocks whose
+// parent is unknown or whose timestamp is slightly ahead of the local clock
+// are buffered and inserted automatically once possible; buffering is not
+// an error.
+func (bc *Blockchain) InsertBlock(block *types.Block, peer string) error {
+    blockHash := bc.CalculateHash(block.Header)
+    err := bc.addBlock(block, peer)
+    switch {
+    case err == nil:
+        return nil
+
+    case errors.Is(err, ErrFutureBlock):
+        ahead := time.Duration(int64(block.Header.Time)-bc.consensus.Clock().Now().Unix()) * time.Second
+        if ahead > maxFutureBlockTime {
+            return err
+        }
+        if err := bc.buffer.addFuture(&bufferedBlock{block: block, hash: blockHash, peer: peer, received: time.Now()}); err != nil {
+            return err
+        }
+        fmt.Printf("⏳ Future block #%s (%x) buffered for %v\n", block.Header.Number, blockHash[:4], ahead)
+        return nil
+
+    case errors.Is(err, ErrUnknownParent):
+        if err := bc.buffer.addOrphan(&bufferedBlock{block: block, hash: blockHash, peer: peer, received: time.Now()}); err != nil {
+            return err
+        }
+        fmt.Printf("🧩 Orphan block #%s (%x) buffered until parent %x arrives\n",
+            block.Header.Number, blockHash[:4], block.Header.ParentHash[:4])
+        return nil
+
+    default:
+        return err
+    }
+}
+
+// DropPeerBlocks discards the buffered blocks delivered by a disconnected peer
+func (bc *Blockchain) DropPeerBlocks(peer string) {
+    if dropped := bc.buffer.dropPeer(peer); dropped > 0 {
+        fmt.Printf("🧹 Dropped %d buffered blocks from %s\n", dropped, peer)
+    }
+}
+
+// BufferedBlocks returns the number of buffered orphan and future blocks
+func (bc *Blockchain) BufferedBlocks() (orphans int, future int) {
+    return bc.buffer.counts()
+}
+
+// insertBuffered retries buffered blocks that were waiting for parent,
+// following the chain of descendants they unlock
+func (bc *Blockchain) insertBuffered(parent types.Hash) {
+    queue := []types.Hash{parent}
+    for len(queue) > 0 {
+        children := bc.buffer.takeOrphans(queue[0])
+        queue = queue[1:]
+
+        for _, entry := range children {
+            err := bc.insertBlock(entry.block, entry.hash)
+            switch {
+            case err == nil:
+                queue = append(queue, entry.hash)
+            case errors.Is(err, ErrFutureBlock):
+                if err := bc.buffer.addFuture(entry); err != nil {
+                    fmt.Printf("⚠️  Dropped buffered block #%s: %v\n", entry.block.Header.Number, err)
+                }
+            default:
+                bc.reportBadBlock(entry.block, entry.hash, entry.peer, err)
+                fmt.Printf("❌ Buffered block #%s (%x) from %s rejected: %v\n",
+                    entry.block.Header.Number, entry.hash[:4], entry.peer, err)
+            }
+        }
+    }
+}
+
+// bufferLoop inserts future blocks when their time comes and expires
+// orphans that waited too long
+func (bc *Blockchain) bufferLoop() {
+    defer bc.wg.Done()
+
+    ticker := time.NewTicker(bufferCheckInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case now := <-ticker.C:
+            for _, entry := range bc.buffer.takeDue(bc.consensus.Clock().Now()) {
+                if err := bc.InsertBlock(entry.block, entry.peer); err != nil {
+                    fmt.Printf("❌ Future block #%s (%x) rejected: %v\n", entry.block.Header.Number, entry.hash[:4], err)
+                }
+            }
+            if expired := bc.buffer.expire(now); expired > 0 {
+                fmt.Printf("🧹 Expired %d orphan blocks\n", expired)
+            }
+        case <-bc.quit:
+            return
+        }
+    }
+}
@@ -1,116 +1,537 @@
 package blockchain
 
 import (
+    "errors"
     "fmt"
-    "math/big"
-    "time"
+    "path/filepath"
+    "sync"
     "github.com/selsichain/selsichain-core/core/types"
     "github.com/selsichain/selsichain-core/core/state"
-    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/crypto/hash"
 )
 
+var (
+    ErrInvalidTxRoot     = errors.New("transaction root mismatch")
+    ErrInvalidCommitHash = errors.New("last commit hash mismatch")
+    ErrInvalidStateRoot  = errors.New("state root mismatch")
+    ErrUnknownParent     = errors.New("unknown parent block")
+    ErrInvalidNumber     = errors.New("block number does not follow parent")
+    ErrMissingHead       = errors.New("database has no head block")
+    ErrInvalidChainID    = errors.New("transaction signed for another chain")
+)
+
+// Blockchain is safe for concurrent use. Block insertion and rewinds are
+// serialised by chainmu; the head, its state and the finality heads are
+// replaced under mu, so readers always see a matching head and state.
 type Blockchain struct {
+    db        rawdb.Database
     genesis   *types.Block
     current   *types.Block
-    blocks    map[string]*types.Block
+    finalized *types.Block
+    safe      *types.Block
     state     *state.StateDB
-    consensus *hybrid.HybridEngine
+    chainID   uint64
+    digest    types.Hash // Consensus config digest, for the fork ID
+    heights   []uint64   // Heights the rules change at, for the fork ID
+    consensus consensus.Engine
+    finality  consensus.Finality // nil if the engine has no finality
     config    *Config
+    buffer    *blockBuffer
+    badBlocks *badBlockRegistry
+    
+    chainmu sync.Mutex   // Serialises block insertion and rewinds
+    mu      sync.RWMutex // Protects current, state, finalized and safe
+    quit    chan struct{}
+    wg      sync.WaitGroup
+    
+    headFeed      Feed[NewHeadEvent]
+    sideFeed      Feed[ChainSideEvent]
+    reorgFeed     Feed[ChainReorgEvent]
+    pendingTxFeed Feed[NewPendingTxEvent]
+    finalizedFeed Feed[FinalizedEvent]
 }
 
 type Config struct {
-    DataDir string
+    DataDir  string
+    Genesis  *Genesis       // nil uses the stored genesis, or the default one for a new database
+    Database rawdb.Database // nil opens DataDir/chaindata (in-memory if DataDir is empty)
 }
 
-func NewBlockchain(config *Config, consensus *hybrid.HybridEngine) (*Blockchain, error) {
+// OpenDatabase opens the chain database inside dataDir
+func OpenDatabase(dataDir string) (rawdb.Database, error) {
+    if dataDir == "" {
+        return rawdb.NewMemoryDatabase(), nil
+    }
+    return rawdb.OpenFileDatabase(filepath.Join(dataDir, "chaindata"))
+}
+
+func NewBlockchain(config *Config, engine consensus.Engine) (*Blockchain, error) {
+    db := config.Database
+    if db == nil {
+        var err error
+        if db, err = OpenDatabase(config.DataDir); err != nil {
+            return nil, err
+        }
+    }
+
     bc := &Blockchain{
-        blocks:    make(map[string]*types.Block),
-        state:     state.NewStateDB(),
-        consensus: consensus,
+        db:        db,
+        consensus: engine,
         config:    config,
+        buffer:    newBlockBuffer(),
+        badBlocks: newBadBlockRegistry(db),
+        quit:      make(chan struct{}),
     }
-    
+    bc.finality, _ = engine.(consensus.Finality)
+
     if err := bc.initGenesis(); err != nil {
+        db.Close()
         return nil, err
     }
-    
+    if err := bc.loadHead(); err != nil {
+        db.Close()
+        return nil, err
+    }
+    bc.loadFinality()
+
+    bc.wg.Add(1)
+    go bc.bufferLoop()
+
     return bc, nil
 }
 
+// initGenesis makes sure the database holds the configured genesis block
 func (bc *Blockchain) initGenesis() error {
-    bc.genesis = &types.Block{
-        Header: &types.Header{
-            ParentHash: types.Hash{},
-            Number:     big.NewInt(0),
-            Time:       uint64(time.Now().Unix()),
-            Difficulty: big.NewInt(1000),
-        },
-        Transactions: []*types.Transaction{},
+    genesis, genesisHash, err := SetupGenesis(bc.db, bc.config.Genesis)
+    if err != nil {
+        return err
+    }
+
+    bc.genesis, err = rawdb.ReadBlock(bc.db, genesisHash)
+    if err != nil {
+        return fmt.Errorf("failed to read genesis block: %w", err)
+    }
+    bc.chainID = genesis.ChainID
+    bc.digest = genesis.Config.Digest()
+    bc.heights = genesis.Config.ForkHeights()
+
+    fmt.Printf("✅ Genesis block %x (chain ID %d) with %d validator accounts\n",
+        genesisHash[:8], genesis.ChainID, len(genesis.Validators))
+    for i, validator := range genesis.Validators {
+        fmt.Printf("   Validator %d: %x - Stake: %s SELSI\n", i+1, validator.Address[:4], validator.Stake)
+    }
+
+    return nil
+}
+
+// loadHead restores the current head block and its state from the database
+func (bc *Blockchain) loadHead() error {
+    headHash, ok := rawdb.ReadHeadBlockHash(bc.db)
+    if !ok {
+        return ErrMissingHead
+    }
+    head, err := rawdb.ReadBlock(bc.db, headHash)
+    if err != nil {
+        fmt.Printf("⚠️  Failed to read head block %x: %v\n", headHash[:4], err)
+        return bc.repairHead()
+    }
+    statedb, err := rawdb.ReadState(bc.db, headHash)
+    if err != nil {
+        fmt.Printf("⚠️  Failed to read head state %x: %v\n", headHash[:4], err)
+        return bc.repairHead()
+    }
+
+    bc.setCurrent(head, statedb)
+
+    if head.Header.Number.Sign() > 0 {
+        fmt.Printf("📦 Loaded chain head #%s (%x)\n", head.Header.Number, headHash[:4])
     }
-    
-    bc.blocks[bc.CalculateHash(bc.genesis.Header)] = bc.genesis
-    bc.current = bc.genesis
-    
-    // Initialize genesis accounts with balances and stakes
-    genesisAddr1 := types.Address{1}
-    genesisAddr2 := types.Address{2} 
-    genesisAddr3 := types.Address{3}
-    
-    // Set initial balances
-    initialBalance := new(big.Int).Mul(big.NewInt(1000000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-    bc.state.SetBalance(genesisAddr1, initialBalance)
-    bc.state.SetBalance(genesisAddr2, initialBalance)
-    bc.state.SetBalance(genesisAddr3, initialBalance)
-    
-    // Set initial stakes for PoS validators
-    stake1 := new(big.Int).Mul(big.NewInt(5000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-    stake2 := new(big.Int).Mul(big.NewInt(3000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-    stake3 := new(big.Int).Mul(big.NewInt(7000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-    
-    bc.state.SetStake(genesisAddr1, stake1)
-    bc.state.SetStake(genesisAddr2, stake2) 
-    bc.state.SetStake(genesisAddr3, stake3)
-    
-    fmt.Println("✅ Genesis block created with 3 validator accounts")
-    fmt.Printf("   Validator 1: %x - Stake: %s SELSI\n", genesisAddr1[:4], stake1)
-    fmt.Printf("   Validator 2: %x - Stake: %s SELSI\n", genesisAddr2[:4], stake2)
-    fmt.Printf("   Validator 3: %x - Stake: %s SELSI\n", genesisAddr3[:4], stake3)
-    
     return nil
 }
 
-func (bc *Blockchain) CalculateHash(header *types.Header) string {
-    return fmt.Sprintf("block-%d-%d", header.Number, header.Time)
+func (bc *Blockchain) CalculateHash(header *types.Header) types.Hash {
+    return hash.CalculateBlockHash(header)
 }
 
+// AddBlock verifies block, executes it on top of its parent state and
+// stores it. The block becomes the new head if it extends the current head
+// or makes its branch longer than the canonical chain; otherwise it is kept
+// as a side block. Buffered blocks waiting for it are inserted afterwards.
 func (bc *Blockchain) AddBlock(block *types.Block) error {
-    hash := bc.CalculateHash(block.Header)
-    bc.blocks[hash] = block
-    bc.current = block
-    
-    // Apply rewards from consensus
-    rewards := bc.consensus.CalculateRewards(block, bc.state)
-    for addr, reward := range rewards {
-        bc.state.AddBalance(addr, reward)
-        fmt.Printf("💰 Rewarded %x: +%s SELSI\n", addr[:4], reward)
+    return bc.addBlock(block, "")
+}
+
+// addBlock implements AddBlock for a block delivered by peer ("" if local)
+func (bc *Blockchain) addBlock(block *types.Block, peer string) error {
+    bc.chainmu.Lock()
+    defer bc.chainmu.Unlock()
+
+    blockHash := bc.CalculateHash(block.Header)
+    if bad := bc.badBlocks.get(blockHash); bad != nil {
+        return fmt.Errorf("%w: %s", ErrKnownBadBlock, bad.Error)
     }
-    
-    fmt.Printf("✅ Block #%s added to chain\n", block.Header.Number)
+    if err := bc.insertBlock(block, blockHash); err != nil {
+        bc.reportBadBlock(block, blockHash, peer, err)
+        return err
+    }
+    bc.insertBuffered(blockHash)
+    return nil
+}
+
+// insertBlock implements AddBlock for a single block; the caller holds chainmu
+func (bc *Blockchain) insertBlock(block *types.Block, blockHash types.Hash) error {
+    if rawdb.HasBlock(bc.db, blockHash) {
+        return nil // Already known
+    }
+    now := bc.consensus.Clock().Now()
+    if limit := uint64(now.Add(allowedFutureBlockTime).Unix()); block.Header.Time > limit {
+        return fmt.Errorf("%w: block #%s at %d, local time %d", ErrFutureBlock, block.Header.Number, block.Header.Time, now.Unix())
+    }
+
+    parent := bc.GetBlockByHash(block.Header.ParentHash)
+    if parent == nil {
+        // Not worth buffering: the parent will never be inserted
+        if bad := bc.badBlocks.get(block.Header.ParentHash); bad != nil {
+            return fmt.Errorf("%w: block #%s parent %x: %s", ErrBadAncestor, block.Header.Number, block.Header.ParentHash[:4], bad.Error)
+        }
+        return fmt.Errorf("%w: block #%s parent %x", ErrUnknownParent, block.Header.Number, block.Header.ParentHash[:4])
+    }
+    if block.Header.Number.Uint64() != parent.Header.Number.Uint64()+1 {
+        return fmt.Errorf("%w: block #%s parent #%s", ErrInvalidNumber, block.Header.Number, parent.Header.Number)
+    }
+    // The body must be the one the header commits to before anything in it
+    // is held against the block: a relayed header with another body is not
+    // the block its proposer made
+    if txHash := hash.CalculateTxRoot(block.Transactions); txHash != block.Header.TxHash {
+        return fmt.Errorf("%w: have %x, want %x", ErrInvalidTxRoot, block.Header.TxHash[:4], txHash[:4])
+    }
+    if commitHash := hash.CalculateCommitHash(block.LastCommit); commitHash != block.Header.LastCommitHash {
+        return fmt.Errorf("%w: have %x, want %x", ErrInvalidCommitHash, block.Header.LastCommitHash[:4], commitHash[:4])
+    }
+    if err := bc.checkFinality(block); err != nil {
+        return err
+    }
+    if err := bc.consensus.VerifyHeader(bc, block.Header, parent.Header); err != nil {
+        return err
+    }
+
+    for i, tx := range block.Transactions {
+        if tx.ChainID != bc.chainID {
+            return fmt.Errorf("%w: tx %d signed for chain %d, chain ID %d", ErrInvalidChainID, i, tx.ChainID, bc.chainID)
+        }
+    }
+    statedb, err := bc.stateAt(block.Header.ParentHash)
+    if err != nil {
+        return err
+    }
+
+    if err := bc.consensus.VerifyBody(bc, block, statedb); err != nil {
+        return err
+    }
+    rewards, err := bc.consensus.Finalize(bc, block, statedb)
+    if err != nil {
+        return err
+    }
+
+    if root := statedb.Root(); root != block.Header.Root {
+        return fmt.Errorf("%w: have %x, want %x", ErrInvalidStateRoot, block.Header.Root[:4], root[:4])
+    }
+
+    batch := bc.db.NewBatch()
+    if err := rawdb.WriteBlock(batch, blockHash, block); err != nil {
+        return err
+    }
+    number := block.Header.Number.Uint64()
+    rawdb.WriteBlockHashes(batch, number, append(rawdb.ReadBlockHashes(bc.db, number), blockHash))
+    if err := rawdb.WriteState(batch, blockHash, statedb); err != nil {
+        return err
+    }
+    if err := rawdb.WriteRewards(batch, blockHash, rewards); err != nil {
+        return err
+    }
+
+    currentHash := bc.CalculateHash(bc.current.Header)
+    switch {
+    case block.Header.ParentHash == currentHash:
+        // Extends the canonical chain
+        bc.writeCanonical(batch, blockHash, block)
+        rawdb.WriteHeadBlockHash(batch, blockHash)
+        if err := batch.Write(); err != nil {
+            return fmt.Errorf("failed to store block #%s: %w", block.Header.Number, err)
+        }
+        bc.setCurrent(block, statedb)
+        
+        fmt.Printf("✅ Block #%s added to chain\n", block.Header.Number)
+        bc.headFeed.Send(NewHeadEvent{Block: block, Hash: blockHash})
+        bc.updateFinality()
+
+    case block.Header.Number.Cmp(bc.current.Header.Number) > 0:
+        // Side branch overtook the canonical chain
+        reorg, err := bc.reorg(batch, block, blockHash)
+        if err != nil {
+            return err
+        }
+        rawdb.WriteHeadBlockHash(batch, blockHash)
+        if err := batch.Write(); err != nil {
+            return fmt.Errorf("failed to store block #%s: %w", block.Header.Number, err)
+        }
+        bc.setCurrent(block, statedb)
+        
+        fmt.Printf("🔀 Chain reorg at #%s: dropped %d blocks, added %d blocks\n",
+            reorg.CommonAncestor.Header.Number, len(reorg.Dropped), len(reorg.Added))
+        bc.reorgFeed.Send(*reorg)
+        bc.headFeed.Send(NewHeadEvent{Block: block, Hash: blockHash})
+        bc.updateFinality()
+
+    default:
+        // Valid block on a shorter branch
+        if err := batch.Write(); err != nil {
+            return fmt.Errorf("failed to store block #%s: %w", block.Header.Number, err)
+        }
+        
+        fmt.Printf("🔱 Side block #%s (%x) stored\n", block.Header.Number, blockHash[:4])
+        bc.sideFeed.Send(ChainSideEvent{Block: block, Hash: blockHash})
+    }
+
     return nil
 }
 
+// setCurrent installs a new head and its post-state. The state must not be
+// modified afterwards; readers only ever get copies of it.
+func (bc *Blockchain) setCurrent(block *types.Block, statedb *state.StateDB) {
+    bc.mu.Lock()
+    defer bc.mu.Unlock()
+
+    bc.current = block
+    bc.state = statedb
+}
+
+// stateAt returns a writable copy of the post-state of the given block
+func (bc *Blockchain) stateAt(blockHash types.Hash) (*state.StateDB, error) {
+    if blockHash == bc.CalculateHash(bc.current.Header) {
+        return bc.state.Copy(), nil
+    }
+    statedb, err := rawdb.ReadState(bc.db, blockHash)
+    if err != nil {
+        return nil, fmt.Errorf("missing state for block %x: %w", blockHash[:4], err)
+    }
+    return statedb, nil
+}
+
+// StateAt returns a copy of the post-state of the stored block with the given hash
+func (bc *Blockchain) StateAt(blockHash types.Hash) (*state.StateDB, error) {
+    bc.mu.RLock()
+    if blockHash == bc.CalculateHash(bc.current.Header) {
+        defer bc.mu.RUnlock()
+        return bc.state.Copy(), nil
+    }
+    bc.mu.RUnlock()
+
+    statedb, err := rawdb.ReadState(bc.db, blockHash)
+    if err != nil {
+        return nil, fmt.Errorf("missing state for block %x: %w", blockHash[:4], err)
+    }
+    return statedb, nil
+}
+
+// writeCanonical marks block as canonical at its height and indexes its transactions
+func (bc *Blockchain) writeCanonical(batch rawdb.Batch, blockHash types.Hash, block *types.Block) {
+    rawdb.WriteCanonicalHash(batch, blockHash, block.Header.Number.Uint64())
+    for _, tx := range block.Transactions {
+        rawdb.WriteTxLookup(batch, hash.CalculateTransactionHash(tx), blockHash)
+    }
+}
+
+// reorg rewrites the canonical indexes so that the branch ending in newHead
+// (which is not stored yet) becomes canonical
+func (bc *Blockchain) reorg(batch rawdb.Batch, newHead *types.Block, newHeadHash types.Hash) (*ChainReorgEvent, error) {
+    // Walk the new branch back to the canonical chain
+    added := []*types.Block{newHead}
+    addedHashes := []types.Hash{newHeadHash}
+    ancestorHash := newHead.Header.ParentHash
+    for {
+        ancestor := bc.GetBlockByHash(ancestorHash)
+        if ancestor == nil {
+            return nil, fmt.Errorf("%w: %x", ErrUnknownParent, ancestorHash[:4])
+        }
+        number := ancestor.Header.Number.Uint64()
+        if canonical, ok := rawdb.ReadCanonicalHash(bc.db, number); ok && canonical == ancestorHash {
+            break
+        }
+        added = append([]*types.Block{ancestor}, added...)
+        addedHashes = append([]types.Hash{ancestorHash}, addedHashes...)
+        ancestorHash = ancestor.Header.ParentHash
+    }
+    commonAncestor := bc.GetBlockByHash(ancestorHash)
+    if commonAncestor.Header.Number.Cmp(bc.finalized.Header.Number) < 0 {
+        return nil, fmt.Errorf("%w: common ancestor #%s, finalized #%s",
+            ErrReorgBelowFinalized, commonAncestor.Header.Number, bc.finalized.Header.Number)
+    }
+
+    // Unindex the old branch
+    var dropped []*types.Block
+    for number := commonAncestor.Header.Number.Uint64() + 1; number <= bc.current.Header.Number.Uint64(); number++ {
+        old := bc.GetBlockByNumber(number)
+        if old == nil {
+            break
+        }
+        for _, tx := range old.Transactions {
+            rawdb.DeleteTxLookup(batch, hash.CalculateTransactionHash(tx))
+        }
+        rawdb.DeleteCanonicalHash(batch, number)
+        dropped = append(dropped, old)
+    }
+
+    // Index the new branch
+    for i, block := range added {
+        bc.writeCanonical(batch, addedHashes[i], block)
+    }
+
+    return &ChainReorgEvent{
+        CommonAncestor: commonAncestor,
+        Dropped:        dropped,
+        Added:          added,
+    }, nil
+}
+
+// PostPendingTransactions announces transactions waiting for inclusion to subscribers
+func (bc *Blockchain) PostPendingTransactions(txs []*types.Transaction) {
+    if len(txs) > 0 {
+        bc.pendingTxFeed.Send(NewPendingTxEvent{Txs: txs})
+    }
+}
+
+// SubscribeNewHead subscribes to canonical head changes
+func (bc *Blockchain) SubscribeNewHead(buffer int) *Subscription[NewHeadEvent] {
+    return bc.headFeed.Subscribe(buffer)
+}
+
+// SubscribeChainSide subscribes to blocks stored on side branches
+func (bc *Blockchain) SubscribeChainSide(buffer int) *Subscription[ChainSideEvent] {
+    return bc.sideFeed.Subscribe(buffer)
+}
+
+// SubscribeChainReorg subscribes to canonical branch switches
+func (bc *Blockchain) SubscribeChainReorg(buffer int) *Subscription[ChainReorgEvent] {
+    return bc.reorgFeed.Subscribe(buffer)
+}
+
+// SubscribeNewPendingTx subscribes to transactions announced as pending
+func (bc *Blockchain) SubscribeNewPendingTx(buffer int) *Subscription[NewPendingTxEvent] {
+    return bc.pendingTxFeed.Subscribe(buffer)
+}
+
+// SubscribeFinalized subscribes to blocks becoming irreversible
+func (bc *Blockchain) SubscribeFinalized(buffer int) *Subscription[FinalizedEvent] {
+    return bc.finalizedFeed.Subscribe(buffer)
+}
+
+// GetCurrentBlock returns the canonical head
 func (bc *Blockchain) GetCurrentBlock() *types.Block {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+
     return bc.current
 }
 
+// CurrentState returns the canonical head together with a copy of its
+// post-state, taken atomically
+func (bc *Blockchain) CurrentState() (*types.Block, *state.StateDB) {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+
+    return bc.current, bc.state.Copy()
+}
+
+// ChainID returns the ID transactions must be signed for
+func (bc *Blockchain) ChainID() uint64 {
+    return bc.chainID
+}
+
+// GetGenesisBlock returns the genesis block
+func (bc *Blockchain) GetGenesisBlock() *types.Block {
+    return bc.genesis
+}
+
+// GetBlockByHash returns the stored block with the given hash, or nil
+func (bc *Blockchain) GetBlockByHash(blockHash types.Hash) *types.Block {
+    block, err := rawdb.ReadBlock(bc.db, blockHash)
+    if err != nil {
+        return nil
+    }
+    return block
+}
+
+// GetBlockRewards returns how the reward of the stored block with the given
+// hash was paid out, or nil
+func (bc *Blockchain) GetBlockRewards(blockHash types.Hash) *types.RewardBreakdown {
+    rewards, err := rawdb.ReadRewards(bc.db, blockHash)
+    if err != nil {
+        return nil
+    }
+    return rewards
+}
+
+// GetHeader returns the header of the stored block with the given hash, or nil
+func (bc *Blockchain) GetHeader(blockHash types.Hash) *types.Header {
+    if block := bc.GetBlockByHash(blockHash); block != nil {
+        return block.Header
+    }
+    return nil
+}
+
+// GetTransaction returns a canonical transaction with the hash and number of
+// its block and its index in the block
+func (bc *Blockchain) GetTransaction(txHash types.Hash) (*types.Transaction, types.Hash, uint64, int, bool) {
+    blockHash, ok := rawdb.ReadTxLookup(bc.db, txHash)
+    if !ok {
+        return nil, types.Hash{}, 0, 0, false
+    }
+    block := bc.GetBlockByHash(blockHash)
+    if block == nil {
+        return nil, types.Hash{}, 0, 0, false
+    }
+    for i, tx := range block.Transactions {
+        if hash.CalculateTransactionHash(tx) == txHash {
+            return tx, blockHash, block.Header.Number.Uint64(), i, true
+        }
+    }
+    return nil, types.Hash{}, 0, 0, false
+}
+
+// GetBlockByNumber returns the canonical block at number, or nil
+func (bc *Blockchain) GetBlockByNumber(number uint64) *types.Block {
+    blockHash, ok := rawdb.ReadCanonicalHash(bc.db, number)
+    if !ok {
+        return nil
+    }
+    return bc.GetBlockByHash(blockHash)
+}
+
 func (bc *Blockchain) Close() {
+    close(bc.quit)
+    bc.wg.Wait()
+    
+    // Let an insertion in progress finish before closing the database
+    bc.chainmu.Lock()
+    defer bc.chainmu.Unlock()
+    
+    if err := bc.db.Close(); err != nil {
+        fmt.Printf("⚠️  Failed to close chain database: %v\n", err)
+    }
     fmt.Println("📦 Blockchain closed")
 }
 
+// GetBlockCount returns the number of blocks in the canonical chain
 func (bc *Blockchain) GetBlockCount() int {
-    return len(bc.blocks)
+    return int(bc.GetCurrentBlock().Header.Number.Uint64()) + 1
 }
 
+// GetStateDB returns a copy of the head state; use CurrentState to get it
+// together with the matching head block
 func (bc *Blockchain) GetStateDB() *state.StateDB {
-    return bc.state
+    _, statedb := bc.CurrentState()
+    return statedb
 }
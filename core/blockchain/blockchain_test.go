@@ -0,0 +1,330 @@
+package blockchain
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "math/big"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// testKey is funded in testGenesis, so tests can send transfers from it
+var testKey = func() *keys.KeyPair {
+    seed := sha256.Sum256([]byte("selsichain-test-sender"))
+    key, err := new(keys.KeyManager).ImportPrivateKey(hex.EncodeToString(seed[:]))
+    if err != nil {
+        panic(err)
+    }
+    return key
+}()
+
+// testChain is a chain run by the hybrid engine in simulated time
+type testChain struct {
+    *Blockchain
+    engine *hybrid.HybridEngine
+    config *hybrid.Config
+    clock  *consensus.SimulatedClock
+}
+
+// testGenesis returns DefaultGenesis with trivial proof of work, checkpoints
+// far apart and testKey funded
+func testGenesis() *Genesis {
+    genesis := DefaultGenesis()
+    genesis.Config.MiningDifficulty = big.NewInt(1)
+    genesis.Config.PowBlockInterval = 100
+    genesis.Alloc[testKey.Address] = GenesisAccount{Balance: selsi(1000)}
+    return genesis
+}
+
+// newTestChain creates an in-memory chain from genesis (testGenesis if nil)
+// whose engine proposes with the dev validator keys and tells the time with
+// clock (a new simulated clock if nil)
+func newTestChain(t *testing.T, genesis *Genesis, clock *consensus.SimulatedClock) *testChain {
+    t.Helper()
+    if genesis == nil {
+        genesis = testGenesis()
+    }
+    if clock == nil {
+        clock = consensus.NewSimulatedClock(time.Now())
+    }
+    engine := hybrid.NewHybridEngine(genesis.Config)
+    engine.SetClock(clock)
+    engine.Authorize(DevValidatorKeys()...)
+
+    bc, err := NewBlockchain(&Config{Genesis: genesis}, engine)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(bc.Close)
+    return &testChain{Blockchain: bc, engine: engine, config: genesis.Config, clock: clock}
+}
+
+// makeBlock builds the block after parent one slot later. PoS blocks get
+// precommits from every dev validator, so the chain accepts them as final.
+func (tc *testChain) makeBlock(t *testing.T, parent *types.Block, txs []*types.Transaction, miner types.Address) *types.Block {
+    t.Helper()
+    statedb, err := tc.StateAt(tc.CalculateHash(parent.Header))
+    if err != nil {
+        t.Fatal(err)
+    }
+    tc.clock.Advance(tc.config.BlockTime)
+    block, err := tc.engine.CreateBlock(tc, parent, txs, miner, statedb, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !block.Header.Checkpoint {
+        certify(block)
+    }
+    return block
+}
+
+// extend adds n blocks on top of the head, each carrying a transfer from
+// testKey if transfers is set
+func (tc *testChain) extend(t *testing.T, n int, miner types.Address, transfers bool) []*types.Block {
+    t.Helper()
+    var blocks []*types.Block
+    for i := 0; i < n; i++ {
+        head, statedb := tc.CurrentState()
+        var txs []*types.Transaction
+        if transfers {
+            txs = append(txs, newTransfer(t, tc.ChainID(), statedb.GetNonce(testKey.Address), miner))
+        }
+        block := tc.makeBlock(t, head, txs, miner)
+        if err := tc.AddBlock(block); err != nil {
+            t.Fatal(err)
+        }
+        blocks = append(blocks, block)
+    }
+    return blocks
+}
+
+// certify attaches precommits for block from every dev validator
+func certify(block *types.Block) {
+    blockHash := hash.CalculateBlockHash(block.Header)
+    block.Votes = nil
+    for _, key := range DevValidatorKeys() {
+        vote := &types.Vote{
+            Validator: key.Address,
+            Number:    block.Header.Number.Uint64(),
+            Round:     block.Header.Round,
+            Type:      types.VotePrecommit,
+            BlockHash: blockHash,
+            Decision:  true,
+            Timestamp: time.Now().Unix(),
+        }
+        key.SignVote(vote)
+        block.Votes = append(block.Votes, vote)
+    }
+}
+
+// newTransfer returns a transfer of 1 wei from testKey to to
+func newTransfer(t *testing.T, chainID uint64, nonce uint64, to types.Address) *types.Transaction {
+    t.Helper()
+    tx := &types.Transaction{
+        Nonce:    nonce,
+        GasPrice: big.NewInt(1000000000),
+        Gas:      21000,
+        To:       &to,
+        Value:    big.NewInt(1),
+        Type:     types.TxRegular,
+        ChainID:  chainID,
+    }
+    if err := testKey.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+    return tx
+}
+
+// readChain hammers the read API of bc until stop is closed, checking that
+// every head is served together with its own state
+func readChain(t *testing.T, bc *Blockchain, stop <-chan struct{}) {
+    for {
+        select {
+        case <-stop:
+            return
+        default:
+        }
+        head, statedb := bc.CurrentState()
+        if root := statedb.Root(); root != head.Header.Root {
+            t.Errorf("head #%s served with state %x, want %x", head.Header.Number, root[:4], head.Header.Root[:4])
+            return
+        }
+        bc.GetStateDB().GetBalance(testKey.Address)
+        bc.GetCurrentBlock()
+        bc.GetBlockCount()
+        bc.GetFinalizedBlock()
+        bc.GetSafeBlock()
+        bc.BadBlocks()
+        for number := uint64(0); number <= head.Header.Number.Uint64(); number++ {
+            block := bc.GetBlockByNumber(number)
+            if block == nil {
+                continue // Unindexed by a concurrent reorg
+            }
+            for _, tx := range block.Transactions {
+                bc.GetTransaction(hash.CalculateTransactionHash(tx))
+            }
+        }
+    }
+}
+
+func TestConcurrentInsertAndRead(t *testing.T) {
+    src := newTestChain(t, nil, nil)
+    blocks := src.extend(t, 30, types.Address{0xa}, true)
+
+    dst := newTestChain(t, nil, src.clock)
+
+    stop := make(chan struct{})
+    var readers sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        readers.Add(1)
+        go func() {
+            defer readers.Done()
+            readChain(t, dst.Blockchain, stop)
+        }()
+    }
+
+    // Peers deliver the blocks newest first, so most of them are buffered
+    // as orphans and inserted by whichever peer delivers their parent
+    var writers sync.WaitGroup
+    for _, peer := range []string{"peer-1", "peer-2", "peer-3"} {
+        writers.Add(1)
+        go func(peer string) {
+            defer writers.Done()
+            for i := len(blocks) - 1; i >= 0; i-- {
+                if err := dst.InsertBlock(blocks[i], peer); err != nil {
+                    t.Errorf("%s: block #%s: %v", peer, blocks[i].Header.Number, err)
+                }
+            }
+        }(peer)
+    }
+    writers.Add(1)
+    go func() {
+        defer writers.Done()
+        for _, block := range blocks {
+            if err := dst.AddBlock(block); err != nil {
+                t.Errorf("block #%s: %v", block.Header.Number, err)
+            }
+        }
+    }()
+    writers.Wait()
+    close(stop)
+    readers.Wait()
+
+    head := dst.GetCurrentBlock()
+    if want := blocks[len(blocks)-1]; dst.CalculateHash(head.Header) != dst.CalculateHash(want.Header) {
+        t.Fatalf("head #%s, want #%s", head.Header.Number, want.Header.Number)
+    }
+    if orphans, future := dst.BufferedBlocks(); orphans != 0 || future != 0 {
+        t.Fatalf("%d orphans and %d future blocks left in the buffer", orphans, future)
+    }
+    for _, block := range blocks {
+        for _, tx := range block.Transactions {
+            if _, _, number, _, ok := dst.GetTransaction(hash.CalculateTransactionHash(tx)); !ok || number != block.Header.Number.Uint64() {
+                t.Fatalf("transaction of block #%s not indexed", block.Header.Number)
+            }
+        }
+    }
+}
+
+func TestConcurrentReorg(t *testing.T) {
+    // Two branches from genesis, built on their own chains; b outgrows a
+    // by one block
+    clock := consensus.NewSimulatedClock(time.Now())
+    branchA := newTestChain(t, nil, clock).extend(t, 10, types.Address{0xa}, true)
+    branchB := newTestChain(t, nil, clock).extend(t, 11, types.Address{0xb}, true)
+
+    bc := newTestChain(t, nil, clock)
+
+    stop := make(chan struct{})
+    var readers sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        readers.Add(1)
+        go func() {
+            defer readers.Done()
+            readChain(t, bc.Blockchain, stop)
+        }()
+    }
+
+    var writers sync.WaitGroup
+    for _, branch := range [][]*types.Block{branchA, branchB} {
+        writers.Add(1)
+        go func(branch []*types.Block) {
+            defer writers.Done()
+            for _, block := range branch {
+                if err := bc.AddBlock(block); err != nil {
+                    t.Errorf("block #%s: %v", block.Header.Number, err)
+                }
+            }
+        }(branch)
+    }
+    writers.Wait()
+    close(stop)
+    readers.Wait()
+
+    tip := branchB[len(branchB)-1]
+    if head := bc.GetCurrentBlock(); bc.CalculateHash(head.Header) != bc.CalculateHash(tip.Header) {
+        t.Fatalf("head #%s is not the tip of the longer branch", head.Header.Number)
+    }
+    for _, block := range branchB {
+        canonical := bc.GetBlockByNumber(block.Header.Number.Uint64())
+        if canonical == nil || bc.CalculateHash(canonical.Header) != bc.CalculateHash(block.Header) {
+            t.Fatalf("block #%s of the longer branch is not canonical", block.Header.Number)
+        }
+    }
+    for _, block := range branchA {
+        for _, tx := range block.Transactions {
+            if _, _, _, _, ok := bc.GetTransaction(hash.CalculateTransactionHash(tx)); ok {
+                t.Fatalf("transaction of dropped block #%s still indexed", block.Header.Number)
+            }
+        }
+    }
+    if head, statedb := bc.CurrentState(); statedb.Root() != head.Header.Root {
+        t.Fatal("head state does not match the head after the reorg")
+    }
+}
+
+func TestSetHeadDeletesSideBlocks(t *testing.T) {
+    tc := newTestChain(t, nil, nil)
+    blocks := tc.extend(t, 2, types.Address{0xa}, false)
+
+    // Two blocks on top of #2, the second stored as a side block
+    canonical := tc.makeBlock(t, blocks[1], nil, types.Address{0xa})
+    side := tc.makeBlock(t, blocks[1], nil, types.Address{0xb})
+    for _, block := range []*types.Block{canonical, side} {
+        if err := tc.AddBlock(block); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    if err := tc.SetHead(1); err != nil {
+        t.Fatal(err)
+    }
+    sideHash := tc.CalculateHash(side.Header)
+    if rawdb.HasBlock(tc.db, sideHash) {
+        t.Fatal("side block on a deleted parent kept")
+    }
+    if err := tc.AddBlock(side); !errors.Is(err, ErrUnknownParent) {
+        t.Fatalf("side block without parent: got %v, want %v", err, ErrUnknownParent)
+    }
+
+    // Once its parent is back the side block is validated again and
+    // extends the chain
+    if err := tc.AddBlock(blocks[1]); err != nil {
+        t.Fatal(err)
+    }
+    if err := tc.AddBlock(side); err != nil {
+        t.Fatal(err)
+    }
+    if head := tc.GetCurrentBlock(); tc.CalculateHash(head.Header) != sideHash {
+        t.Fatalf("head #%s is not the re-added block", head.Header.Number)
+    }
+}
@@ -0,0 +1,113 @@
+package blockchain
+
+import (
+    "sync"
+    "sync/atomic"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// NewHeadEvent is posted when a block becomes the canonical head
+type NewHeadEvent struct {
+    Block *types.Block
+    Hash  types.Hash
+}
+
+// ChainSideEvent is posted when a valid block is stored on a non-canonical branch
+type ChainSideEvent struct {
+    Block *types.Block
+    Hash  types.Hash
+}
+
+// ChainReorgEvent is posted when the canonical chain switches branch.
+// Dropped and Added are ordered from the common ancestor upwards.
+type ChainReorgEvent struct {
+    CommonAncestor *types.Block
+    Dropped        []*types.Block
+    Added          []*types.Block
+}
+
+// NewPendingTxEvent is posted when transactions enter the node waiting for inclusion
+type NewPendingTxEvent struct {
+    Txs []*types.Transaction
+}
+
+// FinalizedEvent is posted when a block becomes irreversible
+type FinalizedEvent struct {
+    Block *types.Block
+    Hash  types.Hash
+}
+
+// Feed delivers events of one type to any number of subscribers. Sending
+// never blocks: a subscriber whose buffer is full misses the event, which
+// is counted in its Dropped total.
+type Feed[T any] struct {
+    subs map[*Subscription[T]]struct{}
+    mu   sync.Mutex
+}
+
+// Subscription receives events from a Feed until it is unsubscribed
+type Subscription[T any] struct {
+    feed    *Feed[T]
+    ch      chan T
+    once    sync.Once
+    dropped atomic.Uint64
+}
+
+// Subscribe registers a new subscriber with room for buffer pending events
+func (f *Feed[T]) Subscribe(buffer int) *Subscription[T] {
+    if buffer < 1 {
+        buffer = 1
+    }
+    sub := &Subscription[T]{
+        feed: f,
+        ch:   make(chan T, buffer),
+    }
+
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if f.subs == nil {
+        f.subs = make(map[*Subscription[T]]struct{})
+    }
+    f.subs[sub] = struct{}{}
+    return sub
+}
+
+// Send delivers event to every subscriber with buffer space and returns how
+// many received it
+func (f *Feed[T]) Send(event T) int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    delivered := 0
+    for sub := range f.subs {
+        select {
+        case sub.ch <- event:
+            delivered++
+        default:
+            sub.dropped.Add(1)
+        }
+    }
+    return delivered
+}
+
+// Chan returns the channel events are delivered on. It is closed on Unsubscribe.
+func (s *Subscription[T]) Chan() <-chan T {
+    return s.ch
+}
+
+// Dropped returns how many events were lost because the buffer was full
+func (s *Subscription[T]) Dropped() uint64 {
+    return s.dropped.Load()
+}
+
+// Unsubscribe stops delivery and closes the channel. It is safe to call more than once.
+func (s *Subscription[T]) Unsubscribe() {
+    s.once.Do(func() {
+        s.feed.mu.Lock()
+        defer s.feed.mu.Unlock()
+
+        delete(s.feed.subs, s)
+        close(s.ch)
+    })
+}
@@ -0,0 +1,193 @@
+package blockchain
+
+import (
+    "bufio"
+    "compress/gzip"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash/crc32"
+    "io"
+    "time"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// Export file layout (gzip compressed):
+//
+//   magic "SELSIEXP" | version byte | genesis hash [32]
+//   repeated block records: [length uint32][crc32 uint32][JSON block]
+//   trailer: [0xFFFFFFFF][block count uint64]
+const (
+    exportMagic   = "SELSIEXP"
+    exportVersion = 1
+    trailerMarker = 0xFFFFFFFF
+    maxRecordSize = 32 * 1024 * 1024
+
+    progressInterval = 8 * time.Second
+)
+
+var (
+    ErrBadExportFile    = errors.New("not a SelsiChain export file")
+    ErrExportChecksum   = errors.New("export record checksum mismatch")
+    ErrExportTruncated  = errors.New("export file is truncated")
+    ErrExportWrongChain = errors.New("export file belongs to a different genesis")
+    ErrInvalidRange     = errors.New("invalid block range")
+)
+
+// Export writes the canonical blocks first..last (inclusive) to w
+func (bc *Blockchain) Export(w io.Writer, first, last uint64) error {
+    head := bc.GetCurrentBlock().Header.Number.Uint64()
+    if last > head {
+        last = head
+    }
+    if first > last {
+        return fmt.Errorf("%w: %d..%d (head #%d)", ErrInvalidRange, first, last, head)
+    }
+
+    zw := gzip.NewWriter(w)
+    genesisHash := bc.CalculateHash(bc.genesis.Header)
+    header := append([]byte(exportMagic), exportVersion)
+    if _, err := zw.Write(append(header, genesisHash[:]...)); err != nil {
+        return err
+    }
+
+    fmt.Printf("📤 Exporting blocks #%d..#%d\n", first, last)
+    var (
+        count    uint64
+        reported = time.Now()
+        record   [8]byte
+    )
+    for number := first; number <= last; number++ {
+        block := bc.GetBlockByNumber(number)
+        if block == nil {
+            return fmt.Errorf("missing canonical block #%d", number)
+        }
+        data, err := json.Marshal(block)
+        if err != nil {
+            return err
+        }
+        binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+        binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+        if _, err := zw.Write(record[:]); err != nil {
+            return err
+        }
+        if _, err := zw.Write(data); err != nil {
+            return err
+        }
+        count++
+
+        if time.Since(reported) > progressInterval {
+            fmt.Printf("📤 Exported %d blocks (#%d)\n", count, number)
+            reported = time.Now()
+        }
+    }
+
+    binary.BigEndian.PutUint32(record[0:4], trailerMarker)
+    if _, err := zw.Write(record[0:4]); err != nil {
+        return err
+    }
+    if _, err := zw.Write(binary.BigEndian.AppendUint64(nil, count)); err != nil {
+        return err
+    }
+    if err := zw.Close(); err != nil {
+        return err
+    }
+
+    fmt.Printf("✅ Exported %d blocks\n", count)
+    return nil
+}
+
+// ImportResult summarises an import run
+type ImportResult struct {
+    Imported int // Blocks added to the chain
+    Skipped  int // Blocks that were already known, e.g. from an interrupted run
+}
+
+// Import replays an export file through AddBlock, so every block gets the
+// same validation as one received from the network. Blocks already stored
+// are skipped, which lets an interrupted import simply be run again.
+func (bc *Blockchain) Import(r io.Reader) (*ImportResult, error) {
+    zr, err := gzip.NewReader(bufio.NewReader(r))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrBadExportFile, err)
+    }
+    defer zr.Close()
+
+    header := make([]byte, len(exportMagic)+1+len(types.Hash{}))
+    if _, err := io.ReadFull(zr, header); err != nil || string(header[:len(exportMagic)]) != exportMagic {
+        return nil, ErrBadExportFile
+    }
+    if version := header[len(exportMagic)]; version != exportVersion {
+        return nil, fmt.Errorf("%w: unsupported version %d", ErrBadExportFile, version)
+    }
+    if genesisHash := bc.CalculateHash(bc.genesis.Header); types.Hash(header[len(exportMagic)+1:]) != genesisHash {
+        return nil, ErrExportWrongChain
+    }
+
+    var (
+        result   = &ImportResult{}
+        reported = time.Now()
+        record   [8]byte
+    )
+    for {
+        if _, err := io.ReadFull(zr, record[0:4]); err != nil {
+            return result, ErrExportTruncated
+        }
+        length := binary.BigEndian.Uint32(record[0:4])
+        if length == trailerMarker {
+            break
+        }
+        if length > maxRecordSize {
+            return result, fmt.Errorf("%w: oversized record (%d bytes)", ErrBadExportFile, length)
+        }
+        if _, err := io.ReadFull(zr, record[4:8]); err != nil {
+            return result, ErrExportTruncated
+        }
+        data := make([]byte, length)
+        if _, err := io.ReadFull(zr, data); err != nil {
+            return result, ErrExportTruncated
+        }
+        if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(record[4:8]) {
+            return result, fmt.Errorf("%w after %d blocks", ErrExportChecksum, result.Imported+result.Skipped)
+        }
+
+        block := new(types.Block)
+        if err := json.Unmarshal(data, block); err != nil {
+            return result, fmt.Errorf("%w: %v", ErrBadExportFile, err)
+        }
+        if block.Header == nil || block.Header.Number == nil {
+            return result, fmt.Errorf("%w: block without header", ErrBadExportFile)
+        }
+
+        if bc.GetBlockByHash(bc.CalculateHash(block.Header)) != nil {
+            result.Skipped++
+        } else {
+            if err := bc.AddBlock(block); err != nil {
+                return result, fmt.Errorf("block #%s: %w", block.Header.Number, err)
+            }
+            result.Imported++
+        }
+
+        if time.Since(reported) > progressInterval {
+            fmt.Printf("📥 Imported %d blocks, skipped %d known (#%s)\n",
+                result.Imported, result.Skipped, block.Header.Number)
+            reported = time.Now()
+        }
+    }
+
+    var count [8]byte
+    if _, err := io.ReadFull(zr, count[:]); err != nil {
+        return result, ErrExportTruncated
+    }
+    if total := binary.BigEndian.Uint64(count[:]); total != uint64(result.Imported+result.Skipped) {
+        return result, fmt.Errorf("%w: trailer announces %d blocks, read %d", ErrExportTruncated, total, result.Imported+result.Skipped)
+    }
+    // Reading to the end verifies the gzip checksum of the whole stream
+    if _, err := io.Copy(io.Discard, zr); err != nil {
+        return result, fmt.Errorf("%w: %v", ErrExportChecksum, err)
+    }
+
+    fmt.Printf("✅ Import done: %d blocks imported, %d already known\n", result.Imported, result.Skipped)
+    return result, nil
+}
@@ -0,0 +1,146 @@
+package blockchain
+
+import (
+    "errors"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+var (
+    ErrReorgBelowFinalized = errors.New("reorg below finalized block refused")
+    ErrFinalizedFork       = errors.New("block conflicts with finalized chain")
+)
+
+// Finality rules:
+//
+//   - Genesis and every PoW checkpoint (every PowBlockInterval blocks, as
+//     in force at each height) are finality candidates.
+//   - A checkpoint is safe once the PoS block following it gathered a 2/3
+//     stake supermajority.
+//   - A checkpoint is finalized once every PoS block of the epoch following
+//     it (up to the next checkpoint) gathered a supermajority.
+//
+// Both heads only move forward, and AddBlock refuses any block that would
+// replace a block at or below the finalized head. The engine tells the
+// candidates and the supermajorities through consensus.Finality; under an
+// engine without it both heads stay at genesis.
+
+// loadFinality restores the finalized and safe heads from the database
+func (bc *Blockchain) loadFinality() {
+    finalized, safe := bc.genesis, bc.genesis
+    if finalizedHash, ok := rawdb.ReadFinalizedBlockHash(bc.db); ok {
+        if block := bc.GetBlockByHash(finalizedHash); block != nil {
+            finalized = block
+        }
+    }
+    if safeHash, ok := rawdb.ReadSafeBlockHash(bc.db); ok {
+        if block := bc.GetBlockByHash(safeHash); block != nil {
+            safe = block
+        }
+    }
+    bc.setFinality(finalized, safe)
+
+    // Heads are derived data; catch up in case the node stopped before storing them
+    bc.updateFinality()
+}
+
+// updateFinality advances the finalized and safe heads after a head change.
+// The caller holds chainmu (or is still constructing the chain).
+func (bc *Blockchain) updateFinality() {
+    if bc.finality == nil {
+        return
+    }
+    head := bc.current.Header.Number.Uint64()
+    finalized, safe := bc.finalized, bc.safe
+    batch := bc.db.NewBatch()
+    var newlyFinalized *types.Block
+
+    // Latest checkpoint whose whole following epoch is on chain, i.e. the
+    // one before the latest checkpoint at or below head+1
+    if next := bc.finality.LastCheckpoint(head + 1); next > 0 {
+        checkpoint := bc.finality.LastCheckpoint(next - 1)
+        if checkpoint > finalized.Header.Number.Uint64() && bc.epochApproved(checkpoint, next-1) {
+            if block := bc.GetBlockByNumber(checkpoint); block != nil {
+                finalized = block
+                newlyFinalized = block
+                rawdb.WriteFinalizedBlockHash(batch, bc.CalculateHash(block.Header))
+            }
+        }
+    }
+
+    // Latest checkpoint with at least one approved successor
+    if head >= 1 {
+        checkpoint := bc.finality.LastCheckpoint(head - 1)
+        if checkpoint > safe.Header.Number.Uint64() && bc.epochApproved(checkpoint, checkpoint+1) {
+            if block := bc.GetBlockByNumber(checkpoint); block != nil {
+                safe = block
+                rawdb.WriteSafeBlockHash(batch, bc.CalculateHash(block.Header))
+            }
+        }
+    }
+    if safe.Header.Number.Cmp(finalized.Header.Number) < 0 {
+        safe = finalized
+        rawdb.WriteSafeBlockHash(batch, bc.CalculateHash(safe.Header))
+    }
+
+    if err := batch.Write(); err != nil {
+        fmt.Printf("⚠️  Failed to store finality heads: %v\n", err)
+    }
+    bc.setFinality(finalized, safe)
+    if newlyFinalized != nil {
+        fmt.Printf("🔒 Block #%s finalized\n", newlyFinalized.Header.Number)
+        bc.finalizedFeed.Send(FinalizedEvent{Block: newlyFinalized, Hash: bc.CalculateHash(newlyFinalized.Header)})
+    }
+}
+
+// setFinality installs new finalized and safe heads
+func (bc *Blockchain) setFinality(finalized, safe *types.Block) {
+    bc.mu.Lock()
+    defer bc.mu.Unlock()
+
+    bc.finalized = finalized
+    bc.safe = safe
+}
+
+// epochApproved checks that every canonical PoS block in (checkpoint, last]
+// carries a supermajority of votes
+func (bc *Blockchain) epochApproved(checkpoint, last uint64) bool {
+    for number := checkpoint + 1; number <= last; number++ {
+        block := bc.GetBlockByNumber(number)
+        if block == nil {
+            return false
+        }
+        if bc.finality.IsCheckpoint(block.Header.Number) {
+            continue
+        }
+        if !bc.finality.HasSupermajority(bc, block) {
+            return false
+        }
+    }
+    return true
+}
+
+// checkFinality rejects blocks that would rewrite finalized history
+func (bc *Blockchain) checkFinality(block *types.Block) error {
+    if block.Header.Number.Cmp(bc.finalized.Header.Number) <= 0 {
+        return fmt.Errorf("%w: block #%s, finalized #%s", ErrFinalizedFork, block.Header.Number, bc.finalized.Header.Number)
+    }
+    return nil
+}
+
+// GetFinalizedBlock returns the latest finalized block
+func (bc *Blockchain) GetFinalizedBlock() *types.Block {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+
+    return bc.finalized
+}
+
+// GetSafeBlock returns the latest safe block
+func (bc *Blockchain) GetSafeBlock() *types.Block {
+    bc.mu.RLock()
+    defer bc.mu.RUnlock()
+
+    return bc.safe
+}
@@ -0,0 +1,17 @@
+package blockchain
+
+import (
+    "github.com/selsichain/selsichain-core/core/forks"
+)
+
+// ForkID returns the fork identifier of the chain at its current head, as
+// announced to peers at handshake
+func (bc *Blockchain) ForkID() forks.ID {
+    return forks.NewID(bc.CalculateHash(bc.genesis.Header), bc.digest, bc.heights, bc.GetCurrentBlock().Header.Number.Uint64())
+}
+
+// CheckForkID checks the fork identifier a peer announced against the chain
+// (see forks.CheckID)
+func (bc *Blockchain) CheckForkID(remote forks.ID) error {
+    return forks.CheckID(bc.CalculateHash(bc.genesis.Header), bc.digest, bc.heights, bc.GetCurrentBlock().Header.Number.Uint64(), remote)
+}
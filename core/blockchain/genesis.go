@@ -0,0 +1,365 @@
+package blockchain
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "os"
+    "time"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+var (
+    ErrGenesisMismatch = errors.New("database contains incompatible genesis")
+    ErrNoGenesis       = errors.New("genesis has no consensus config")
+    ErrCorruptGenesis  = errors.New("corrupt stored genesis")
+)
+
+// Genesis specifies the initial state of a chain
+type Genesis struct {
+    ChainID    uint64                           `json:"chainId"`
+    Timestamp  uint64                           `json:"timestamp"`
+    ExtraData  string                           `json:"extraData,omitempty"`
+    Difficulty *big.Int                         `json:"difficulty"`
+    Alloc      map[types.Address]GenesisAccount `json:"alloc"`
+    Validators []GenesisValidator               `json:"validators"`
+    Config     *hybrid.Config                   `json:"config"`
+}
+
+// GenesisAccount is an account funded in the genesis state
+type GenesisAccount struct {
+    Balance *big.Int `json:"balance"`
+    Nonce   uint64   `json:"nonce,omitempty"`
+}
+
+// GenesisValidator is a validator staked in the genesis state
+type GenesisValidator struct {
+    Address types.Address `json:"address"`
+    Stake   *big.Int      `json:"stake"`
+}
+
+// selsi converts whole SELSI to wei
+func selsi(amount int64) *big.Int {
+    return new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+}
+
+// DevValidatorKeys returns the keys of the demo validators staked in
+// DefaultGenesis and TestnetGenesis. They are derived from public seeds, so
+// anyone can propose with them: never use them on a real network.
+func DevValidatorKeys() []*keys.KeyPair {
+    var devKeys []*keys.KeyPair
+    for i := 1; i <= 3; i++ {
+        seed := sha256.Sum256([]byte(fmt.Sprintf("selsichain-dev-validator-%d", i)))
+        key, err := new(keys.KeyManager).ImportPrivateKey(hex.EncodeToString(seed[:]))
+        if err != nil {
+            panic(err) // Fixed seeds are valid keys
+        }
+        devKeys = append(devKeys, key)
+    }
+    return devKeys
+}
+
+// DevTreasuryKey returns the key of the ecosystem treasury of DefaultGenesis
+// and TestnetGenesis. Like DevValidatorKeys it comes from a public seed.
+func DevTreasuryKey() *keys.KeyPair {
+    seed := sha256.Sum256([]byte("selsichain-dev-treasury"))
+    key, err := new(keys.KeyManager).ImportPrivateKey(hex.EncodeToString(seed[:]))
+    if err != nil {
+        panic(err) // Fixed seeds are valid keys
+    }
+    return key
+}
+
+// DefaultGenesis returns the SelsiChain main network genesis
+func DefaultGenesis() *Genesis {
+    devKeys := DevValidatorKeys()
+    return &Genesis{
+        ChainID:    769,
+        Timestamp:  1735689600, // 2025-01-01 00:00:00 UTC
+        ExtraData:  "SelsiChain Genesis",
+        Difficulty: big.NewInt(1000),
+        Alloc: map[types.Address]GenesisAccount{
+            {1}: {Balance: selsi(1000000)},
+            {2}: {Balance: selsi(1000000)},
+            {3}: {Balance: selsi(1000000)},
+        },
+        Validators: []GenesisValidator{
+            {Address: devKeys[0].Address, Stake: selsi(5000)},
+            {Address: devKeys[1].Address, Stake: selsi(3000)},
+            {Address: devKeys[2].Address, Stake: selsi(7000)},
+        },
+        Config: &hybrid.Config{
+            PowBlockInterval: 5,
+            MiningDifficulty: big.NewInt(1000000),
+            MinimumStake:     selsi(1000),
+            Slashing: hybrid.SlashingConfig{
+                DoubleSignPercent:    5,
+                DoubleSignJailBlocks: 100000,
+                WhistleblowerPercent: 10,
+                EvidenceMaxAge:       10000,
+                SignedBlocksWindow:   100,
+                MinSignedPercent:     50,
+                DowntimeSlashPercent: 1,
+                DowntimeJailBlocks:   600,
+            },
+            BlockTime:        12 * time.Second,
+            Emission: hybrid.EmissionConfig{
+                InitialReward:     selsi(100),
+                Curve:             hybrid.EmissionHalving,
+                ReductionInterval: 10512000, // ~4 years
+            },
+            RewardDistribution: hybrid.RewardConfig{
+                MinerPercent:     45,
+                StakerPercent:    45,
+                EcosystemPercent: 7,
+                BurnPercent:      3,
+
+                ProposerBonusPercent: 10,
+            },
+            Treasury: DevTreasuryKey().Address,
+        },
+    }
+}
+
+// TestnetGenesis returns the SelsiChain test network genesis
+func TestnetGenesis() *Genesis {
+    genesis := DefaultGenesis()
+    genesis.ChainID = 1337
+    genesis.ExtraData = "SelsiChain Testnet Genesis"
+    genesis.Config.PowBlockInterval = 3
+    genesis.Config.BlockTime = 10 * time.Second
+    return genesis
+}
+
+// LoadGenesis reads a genesis specification from a JSON file
+func LoadGenesis(path string) (*Genesis, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read genesis file: %w", err)
+    }
+    genesis := new(Genesis)
+    if err := json.Unmarshal(data, genesis); err != nil {
+        return nil, fmt.Errorf("invalid genesis file: %w", err)
+    }
+    if err := genesis.Validate(); err != nil {
+        return nil, err
+    }
+    return genesis, nil
+}
+
+// Validate checks the specification for values the chain cannot run with
+func (g *Genesis) Validate() error {
+    if g.Config == nil {
+        return ErrNoGenesis
+    }
+    if g.Config.PowBlockInterval == 0 {
+        return fmt.Errorf("invalid genesis: powBlockInterval must be positive")
+    }
+    if g.Config.MiningDifficulty == nil || g.Config.MiningDifficulty.Sign() <= 0 {
+        return fmt.Errorf("invalid genesis: miningDifficulty must be positive")
+    }
+    if _, err := hybrid.NewPowHasher(g.Config.PowAlgorithm); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    if g.Config.BlockTime < time.Second || g.Config.BlockTime%time.Second != 0 {
+        return fmt.Errorf("invalid genesis: blockTime must be a whole number of seconds")
+    }
+    if g.Config.MinimumStake == nil {
+        return fmt.Errorf("invalid genesis: minimumStake is required")
+    }
+    if err := hybrid.ValidateEmission(g.Config.Emission); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    if err := g.Config.Upgrades.Validate(); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    if err := hybrid.ValidateRewards(g.Config.RewardDistribution); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    if err := hybrid.ValidateSlashing(g.Config.Slashing); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    if err := hybrid.ValidateOverrides(g.Config); err != nil {
+        return fmt.Errorf("invalid genesis: %w", err)
+    }
+    for _, validator := range g.Validators {
+        if validator.Stake == nil || validator.Stake.Cmp(g.Config.MinimumStake) < 0 {
+            return fmt.Errorf("invalid genesis: validator %x stake below minimumStake", validator.Address[:4])
+        }
+    }
+    for addr, account := range g.Alloc {
+        if account.Balance == nil || account.Balance.Sign() < 0 {
+            return fmt.Errorf("invalid genesis: account %x has invalid balance", addr[:4])
+        }
+    }
+    return nil
+}
+
+// ToBlock builds the genesis block and its state. The result only depends on
+// the specification, so every node derives the same genesis hash.
+func (g *Genesis) ToBlock() (*types.Block, *state.StateDB) {
+    statedb := state.NewStateDB()
+    for addr, account := range g.Alloc {
+        statedb.SetBalance(addr, account.Balance)
+        if account.Nonce > 0 {
+            statedb.SetNonce(addr, account.Nonce)
+        }
+    }
+    for _, validator := range g.Validators {
+        statedb.SetStake(validator.Address, validator.Stake)
+    }
+
+    difficulty := g.Difficulty
+    if difficulty == nil {
+        difficulty = big.NewInt(0)
+    }
+    block := &types.Block{
+        Header: &types.Header{
+            Number:     big.NewInt(0),
+            Time:       g.Timestamp,
+            Extra:      []byte(g.ExtraData),
+            Difficulty: new(big.Int).Set(difficulty),
+        },
+        Transactions: []*types.Transaction{},
+    }
+    block.Header.TxHash = hash.CalculateTxRoot(block.Transactions)
+    block.Header.Root = statedb.Root()
+    return block, statedb
+}
+
+// Hash returns the hash of the genesis block described by the specification
+func (g *Genesis) Hash() types.Hash {
+    block, _ := g.ToBlock()
+    return hash.CalculateBlockHash(block.Header)
+}
+
+// Commit writes the genesis block, its state and the specification to db
+func (g *Genesis) Commit(db rawdb.Database) (*types.Block, error) {
+    spec, err := json.Marshal(g)
+    if err != nil {
+        return nil, err
+    }
+    block, statedb := g.ToBlock()
+    blockHash := hash.CalculateBlockHash(block.Header)
+
+    batch := db.NewBatch()
+    if err := rawdb.WriteBlock(batch, blockHash, block); err != nil {
+        return nil, err
+    }
+    if err := rawdb.WriteState(batch, blockHash, statedb); err != nil {
+        return nil, err
+    }
+    rawdb.WriteCanonicalHash(batch, blockHash, 0)
+    rawdb.WriteHeadBlockHash(batch, blockHash)
+    rawdb.WriteGenesisSpec(batch, spec)
+    rawdb.WriteGenesisHash(batch, blockHash)
+    if err := batch.Write(); err != nil {
+        return nil, err
+    }
+    return block, nil
+}
+
+// SetupGenesis makes sure db is initialised with a genesis and returns the
+// one in effect:
+//
+//   - empty db, genesis nil:  DefaultGenesis is committed
+//   - empty db, genesis set:  genesis is committed
+//   - initialised db, genesis nil: the stored genesis is returned
+//   - initialised db, genesis set: genesis must hash to the stored genesis hash
+//     and carry the stored consensus config; its upgrade schedule and config overrides replace the stored ones if
+//     they leave the heights the chain already passed unchanged
+func SetupGenesis(db rawdb.Database, genesis *Genesis) (*Genesis, types.Hash, error) {
+    storedHash, initialised := rawdb.ReadGenesisHash(db)
+
+    if !initialised {
+        if genesis == nil {
+            fmt.Println("📜 Writing default SelsiChain genesis block")
+            genesis = DefaultGenesis()
+        }
+        if err := genesis.Validate(); err != nil {
+            return nil, types.Hash{}, err
+        }
+        block, err := genesis.Commit(db)
+        if err != nil {
+            return nil, types.Hash{}, fmt.Errorf("failed to write genesis: %w", err)
+        }
+        return genesis, hash.CalculateBlockHash(block.Header), nil
+    }
+
+    if genesis != nil {
+        if configuredHash := genesis.Hash(); configuredHash != storedHash {
+            return nil, types.Hash{}, fmt.Errorf("%w (have %x, new %x)", ErrGenesisMismatch, storedHash[:8], configuredHash[:8])
+        }
+        stored, err := readStoredGenesis(db)
+        if err != nil {
+            return nil, types.Hash{}, err
+        }
+        // The genesis hash does not cover the consensus config
+        if stored.Config.Digest() != genesis.Config.Digest() {
+            return nil, types.Hash{}, fmt.Errorf("%w: consensus config differs from the stored one", ErrGenesisMismatch)
+        }
+        if err := updateSchedule(db, stored, genesis); err != nil {
+            return nil, types.Hash{}, err
+        }
+        return genesis, storedHash, nil
+    }
+
+    stored, err := readStoredGenesis(db)
+    if err != nil {
+        return nil, types.Hash{}, err
+    }
+    if err := stored.Validate(); err != nil {
+        return nil, types.Hash{}, err
+    }
+    return stored, storedHash, nil
+}
+
+// updateSchedule stores the specification of genesis if it reschedules
+// upgrades or config overrides, which is only allowed above the current head
+func updateSchedule(db rawdb.Database, stored, genesis *Genesis) error {
+    if stored.Config.Upgrades.Equal(genesis.Config.Upgrades) && hybrid.OverridesEqual(stored.Config.Overrides, genesis.Config.Overrides) {
+        return nil
+    }
+
+    head := uint64(0)
+    if headHash, ok := rawdb.ReadHeadBlockHash(db); ok {
+        head, _ = rawdb.ReadBlockNumber(db, headHash)
+    }
+    if err := stored.Config.Upgrades.CheckCompatible(genesis.Config.Upgrades, head); err != nil {
+        return err
+    }
+    if err := hybrid.CheckOverridesCompatible(stored.Config.Overrides, genesis.Config.Overrides, head); err != nil {
+        return err
+    }
+    spec, err := json.Marshal(genesis)
+    if err != nil {
+        return err
+    }
+    batch := db.NewBatch()
+    rawdb.WriteGenesisSpec(batch, spec)
+    if err := batch.Write(); err != nil {
+        return err
+    }
+    fmt.Printf("📜 Upgrade schedule updated: %v, %d config overrides\n", genesis.Config.Upgrades, len(genesis.Config.Overrides))
+    return nil
+}
+
+// readStoredGenesis returns the genesis specification stored in db
+func readStoredGenesis(db rawdb.Database) (*Genesis, error) {
+    stored := new(Genesis)
+    if err := json.Unmarshal(rawdb.ReadGenesisSpec(db), stored); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrCorruptGenesis, err)
+    }
+    if stored.Config == nil {
+        return nil, fmt.Errorf("%w: %w", ErrCorruptGenesis, ErrNoGenesis)
+    }
+    return stored, nil
+}
@@ -0,0 +1,127 @@
+package blockchain
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+)
+
+// The genesis.json shipped with the node must describe the same network as
+// DefaultGenesis, consensus config included
+func TestGenesisFileMatchesDefault(t *testing.T) {
+    file, err := LoadGenesis("../../genesis.json")
+    if err != nil {
+        t.Fatal(err)
+    }
+    have, err := json.MarshalIndent(file, "", "  ")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want, err := json.MarshalIndent(DefaultGenesis(), "", "  ")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(have, want) {
+        t.Fatalf("genesis.json differs from DefaultGenesis:\n%s\nwant:\n%s", have, want)
+    }
+}
+
+func TestSetupGenesisCorruptSpec(t *testing.T) {
+    var syntaxErr *json.SyntaxError
+    for _, test := range []struct {
+        spec string
+        want error
+    }{
+        {"{", nil},           // Unreadable: the JSON error is wrapped
+        {"{}", ErrNoGenesis}, // No consensus config
+    } {
+        for _, genesis := range []*Genesis{nil, DefaultGenesis()} {
+            db := rawdb.NewMemoryDatabase()
+            if _, _, err := SetupGenesis(db, nil); err != nil {
+                t.Fatal(err)
+            }
+            batch := db.NewBatch()
+            rawdb.WriteGenesisSpec(batch, []byte(test.spec))
+            if err := batch.Write(); err != nil {
+                t.Fatal(err)
+            }
+
+            _, _, err := SetupGenesis(db, genesis)
+            if !errors.Is(err, ErrCorruptGenesis) {
+                t.Fatalf("spec %q: got %v, want %v", test.spec, err, ErrCorruptGenesis)
+            }
+            if test.want == nil && !errors.As(err, &syntaxErr) || test.want != nil && !errors.Is(err, test.want) {
+                t.Fatalf("spec %q: %v does not wrap the cause", test.spec, err)
+            }
+        }
+    }
+}
+
+// Overrides above the head may be rescheduled on restart and are stored
+func TestSetupGenesisReschedulesOverrides(t *testing.T) {
+    db := rawdb.NewMemoryDatabase()
+    if _, _, err := SetupGenesis(db, nil); err != nil {
+        t.Fatal(err)
+    }
+    genesis := DefaultGenesis()
+    genesis.Config.Overrides = []hybrid.ConfigOverride{{Number: 10, PowBlockInterval: 2}}
+    if _, _, err := SetupGenesis(db, genesis); err != nil {
+        t.Fatal(err)
+    }
+    stored, _, err := SetupGenesis(db, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !hybrid.OverridesEqual(stored.Config.Overrides, genesis.Config.Overrides) {
+        t.Fatalf("stored overrides %+v, want %+v", stored.Config.Overrides, genesis.Config.Overrides)
+    }
+}
+
+// The genesis hash does not cover the consensus config, so a changed config
+// is caught by its digest
+func TestSetupGenesisRejectsConfigChange(t *testing.T) {
+    db := rawdb.NewMemoryDatabase()
+    if _, _, err := SetupGenesis(db, nil); err != nil {
+        t.Fatal(err)
+    }
+    genesis := DefaultGenesis()
+    genesis.Config.BlockTime *= 2
+    if genesis.Hash() != DefaultGenesis().Hash() {
+        t.Fatal("block time changes the genesis hash")
+    }
+    if _, _, err := SetupGenesis(db, genesis); !errors.Is(err, ErrGenesisMismatch) {
+        t.Fatalf("got %v, want %v", err, ErrGenesisMismatch)
+    }
+}
+
+func TestForkIDCoversConfig(t *testing.T) {
+    base := newTestChain(t, nil, nil)
+
+    other := testGenesis()
+    other.Config.PowBlockInterval = 50
+    differs := newTestChain(t, other, nil)
+    if base.CalculateHash(base.genesis.Header) != differs.CalculateHash(differs.genesis.Header) {
+        t.Fatal("genesis hashes differ")
+    }
+    if base.ForkID() == differs.ForkID() {
+        t.Fatalf("fork ID %s does not cover the config", base.ForkID())
+    }
+    if err := base.CheckForkID(differs.ForkID()); err == nil {
+        t.Fatal("peer with another config accepted")
+    }
+
+    // An override is announced as the next fork, like an upgrade
+    scheduled := testGenesis()
+    scheduled.Config.Overrides = []hybrid.ConfigOverride{{Number: 10, RewardDistribution: &scheduled.Config.RewardDistribution}}
+    ahead := newTestChain(t, scheduled, nil)
+    if id := ahead.ForkID(); id.Hash != base.ForkID().Hash || id.Next != 10 {
+        t.Fatalf("fork ID %s, want %x/10", id, base.ForkID().Hash)
+    }
+    if err := base.CheckForkID(ahead.ForkID()); err != nil {
+        t.Fatalf("peer scheduling an override rejected: %v", err)
+    }
+}
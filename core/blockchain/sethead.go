@@ -0,0 +1,147 @@
+package blockchain
+
+import (
+    "errors"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+// Blocks removed per database batch while rewinding. Every batch also moves
+// the stored head down to the highest block it keeps, so an interrupted
+// rewind leaves a consistent (partially rewound) chain behind.
+const rewindBatchSize = 1024
+
+var ErrSetHeadAhead = errors.New("cannot set head above the current head")
+
+// SetHead rewinds the canonical chain to the block at number. Canonical
+// blocks above it are deleted together with their state and transaction
+// indexes, so they can be received and validated again. So are side blocks
+// above it, some of which build on the deleted blocks. The finalized and
+// safe heads are lowered if necessary.
+func (bc *Blockchain) SetHead(number uint64) error {
+    bc.chainmu.Lock()
+    defer bc.chainmu.Unlock()
+
+    head := bc.current.Header.Number.Uint64()
+    if number > head {
+        return fmt.Errorf("%w: #%d, head #%d", ErrSetHeadAhead, number, head)
+    }
+    if number == head {
+        return nil
+    }
+
+    dropped, err := bc.rewind(head, number)
+    if err != nil {
+        return err
+    }
+    fmt.Printf("⏪ Chain rewound from #%d to #%d\n", head, number)
+    bc.reorgFeed.Send(ChainReorgEvent{CommonAncestor: bc.current, Dropped: dropped})
+    return nil
+}
+
+// rewind deletes the blocks in (number, top], canonical or not, from the top
+// down and makes the block at number the head
+func (bc *Blockchain) rewind(top, number uint64) ([]*types.Block, error) {
+    targetHash, ok := rawdb.ReadCanonicalHash(bc.db, number)
+    if !ok {
+        return nil, fmt.Errorf("no canonical block #%d", number)
+    }
+    target, err := rawdb.ReadBlock(bc.db, targetHash)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read block #%d: %w", number, err)
+    }
+    statedb, err := rawdb.ReadState(bc.db, targetHash)
+    if err != nil {
+        return nil, fmt.Errorf("missing state for block #%d: %w", number, err)
+    }
+
+    var dropped []*types.Block
+    for top > number {
+        bottom := number
+        if top-number > rewindBatchSize {
+            bottom = top - rewindBatchSize
+        }
+        bottomHash, ok := rawdb.ReadCanonicalHash(bc.db, bottom)
+        if !ok {
+            return nil, fmt.Errorf("no canonical block #%d", bottom)
+        }
+
+        batch := bc.db.NewBatch()
+        for n := top; n > bottom; n-- {
+            for _, blockHash := range rawdb.ReadBlockHashes(bc.db, n) {
+                deleteBlock(batch, blockHash)
+            }
+            rawdb.DeleteBlockHashes(batch, n)
+
+            blockHash, ok := rawdb.ReadCanonicalHash(bc.db, n)
+            if !ok {
+                continue
+            }
+            if block := bc.GetBlockByHash(blockHash); block != nil {
+                for _, tx := range block.Transactions {
+                    rawdb.DeleteTxLookup(batch, hash.CalculateTransactionHash(tx))
+                }
+                dropped = append([]*types.Block{block}, dropped...)
+            }
+            rawdb.DeleteCanonicalHash(batch, n)
+            deleteBlock(batch, blockHash)
+        }
+        rawdb.WriteHeadBlockHash(batch, bottomHash)
+        if bc.finalized != nil && bc.finalized.Header.Number.Uint64() > bottom {
+            rawdb.WriteFinalizedBlockHash(batch, bottomHash)
+        }
+        if bc.safe != nil && bc.safe.Header.Number.Uint64() > bottom {
+            rawdb.WriteSafeBlockHash(batch, bottomHash)
+        }
+        if err := batch.Write(); err != nil {
+            return nil, fmt.Errorf("rewind interrupted at #%d: %w", top, err)
+        }
+        top = bottom
+    }
+
+    bc.setCurrent(target, statedb)
+    finalized, safe := bc.finalized, bc.safe
+    if finalized != nil && finalized.Header.Number.Uint64() > number {
+        fmt.Printf("⚠️  Finalized head lowered from #%s to #%d\n", finalized.Header.Number, number)
+        finalized = target
+    }
+    if safe != nil && safe.Header.Number.Uint64() > number {
+        safe = target
+    }
+    bc.setFinality(finalized, safe)
+    return dropped, nil
+}
+
+// deleteBlock removes a block together with its state and rewards
+func deleteBlock(batch rawdb.Batch, blockHash types.Hash) {
+    rawdb.DeleteBlock(batch, blockHash)
+    rawdb.DeleteState(batch, blockHash)
+    rawdb.DeleteRewards(batch, blockHash)
+}
+
+// repairHead recovers from a damaged head: it rewinds to the highest block
+// that, like all its canonical ancestors, is stored with its state
+func (bc *Blockchain) repairHead() error {
+    var intact, top uint64
+    damaged := false
+    for number := uint64(1); ; number++ {
+        blockHash, ok := rawdb.ReadCanonicalHash(bc.db, number)
+        if !ok {
+            break
+        }
+        top = number
+        if !damaged && rawdb.HasBlock(bc.db, blockHash) && rawdb.HasState(bc.db, blockHash) {
+            intact = number
+        } else {
+            damaged = true
+        }
+    }
+
+    if _, err := bc.rewind(top, intact); err != nil {
+        return fmt.Errorf("chain repair failed: %w", err)
+    }
+    fmt.Printf("🩹 Repaired damaged chain head: rewound to #%d\n", intact)
+    return nil
+}
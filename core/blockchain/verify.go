@@ -0,0 +1,198 @@
+package blockchain
+
+import (
+    "fmt"
+    "math/big"
+    "time"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/rawdb"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+// VerifyOptions selects what VerifyDatabase checks
+type VerifyOptions struct {
+    First, Last uint64 // Canonical range to walk; Last is capped at the head
+    ExecEvery   uint64 // Re-execute every Nth block (1 = all, 0 = none)
+}
+
+// VerifyIssue is one discrepancy found in the database
+type VerifyIssue struct {
+    Number uint64
+    Hash   types.Hash
+    Check  string
+    Detail string
+}
+
+// VerifyReport summarises a database verification run
+type VerifyReport struct {
+    First, Last uint64
+    Blocks      int
+    Txs         int
+    Executed    int
+    Issues      []VerifyIssue
+}
+
+func (r *VerifyReport) addIssue(number uint64, blockHash types.Hash, check string, format string, args ...interface{}) {
+    r.Issues = append(r.Issues, VerifyIssue{
+        Number: number,
+        Hash:   blockHash,
+        Check:  check,
+        Detail: fmt.Sprintf(format, args...),
+    })
+}
+
+// dbChain serves the engine headers and states straight from a database
+type dbChain struct {
+    db rawdb.Database
+}
+
+// GetHeader returns the header of the stored block with the given hash
+func (c dbChain) GetHeader(blockHash types.Hash) *types.Header {
+    block, err := rawdb.ReadBlock(c.db, blockHash)
+    if err != nil {
+        return nil
+    }
+    return block.Header
+}
+
+// StateAt returns the post-state of the stored block with the given hash
+func (c dbChain) StateAt(blockHash types.Hash) (*state.StateDB, error) {
+    return rawdb.ReadState(c.db, blockHash)
+}
+
+// VerifyDatabase walks the canonical chain in db without modifying it. For
+// every block it recomputes the block hash, transaction root and state root,
+// checks the number<->hash and transaction lookup indexes, the link to the
+// parent and the supply (genesis holdings + emitted - burned), and
+// re-executes the selected blocks on their parent state.
+// Lookup entries pointing at non-canonical blocks are not detected, since
+// that needs a full key scan.
+func VerifyDatabase(db rawdb.Database, engine consensus.Engine, opts VerifyOptions) (*VerifyReport, error) {
+    genesisHash, ok := rawdb.ReadGenesisHash(db)
+    if !ok {
+        return nil, ErrMissingHead
+    }
+    headHash, ok := rawdb.ReadHeadBlockHash(db)
+    if !ok {
+        return nil, ErrMissingHead
+    }
+
+    report := &VerifyReport{First: opts.First}
+    head, headKnown := rawdb.ReadBlockNumber(db, headHash)
+    if !headKnown {
+        report.addIssue(0, headHash, "head", "head block %x is not stored", headHash[:4])
+        // Walk as far as the canonical index goes
+        for head = 0; ; head++ {
+            if _, ok := rawdb.ReadCanonicalHash(db, head+1); !ok {
+                break
+            }
+        }
+    } else if canonical, ok := rawdb.ReadCanonicalHash(db, head); !ok || canonical != headHash {
+        report.addIssue(head, headHash, "head", "head block is not canonical at #%d", head)
+    }
+    if _, ok := rawdb.ReadCanonicalHash(db, head+1); ok {
+        report.addIssue(head+1, types.Hash{}, "head", "canonical index continues past the head")
+    }
+    if canonical, ok := rawdb.ReadCanonicalHash(db, 0); !ok || canonical != genesisHash {
+        report.addIssue(0, genesisHash, "genesis", "canonical block #0 does not match the genesis hash")
+    }
+
+    report.Last = opts.Last
+    if report.Last > head {
+        report.Last = head
+    }
+    if report.First > report.Last {
+        return nil, fmt.Errorf("%w: %d..%d (head #%d)", ErrInvalidRange, opts.First, opts.Last, head)
+    }
+
+    genesisState, err := rawdb.ReadState(db, genesisHash)
+    if err != nil {
+        return nil, fmt.Errorf("genesis state unreadable: %w", err)
+    }
+    genesisHoldings := genesisState.Holdings()
+
+    fmt.Printf("🔎 Verifying canonical blocks #%d..#%d\n", report.First, report.Last)
+    reported := time.Now()
+    for number := report.First; number <= report.Last; number++ {
+        verifyBlock(db, engine, report, number, genesisHoldings, opts.ExecEvery > 0 && (number%opts.ExecEvery == 0 || number == report.Last))
+
+        if time.Since(reported) > progressInterval {
+            fmt.Printf("🔎 Verified %d blocks (#%d), %d issues so far\n", report.Blocks, number, len(report.Issues))
+            reported = time.Now()
+        }
+    }
+    return report, nil
+}
+
+// verifyBlock runs every check on the canonical block at number
+func verifyBlock(db rawdb.Database, engine consensus.Engine, report *VerifyReport, number uint64, genesisHoldings *big.Int, execute bool) {
+    blockHash, ok := rawdb.ReadCanonicalHash(db, number)
+    if !ok {
+        report.addIssue(number, types.Hash{}, "canonical-index", "no canonical hash")
+        return
+    }
+    block, err := rawdb.ReadBlock(db, blockHash)
+    if err != nil {
+        report.addIssue(number, blockHash, "block", "unreadable: %v", err)
+        return
+    }
+    report.Blocks++
+    report.Txs += len(block.Transactions)
+
+    if computed := hash.CalculateBlockHash(block.Header); computed != blockHash {
+        report.addIssue(number, blockHash, "block-hash", "header hashes to %x", computed[:4])
+    }
+    if block.Header.Number == nil || block.Header.Number.Uint64() != number {
+        report.addIssue(number, blockHash, "block-number", "header number is %v", block.Header.Number)
+    }
+    if stored, ok := rawdb.ReadBlockNumber(db, blockHash); !ok || stored != number {
+        report.addIssue(number, blockHash, "number-index", "hash maps to #%d (found: %v)", stored, ok)
+    }
+    if number > 0 {
+        if parentHash, ok := rawdb.ReadCanonicalHash(db, number-1); ok && parentHash != block.Header.ParentHash {
+            report.addIssue(number, blockHash, "parent-link", "parent %x is not canonical #%d (%x)",
+                block.Header.ParentHash[:4], number-1, parentHash[:4])
+        }
+    }
+    if txHash := hash.CalculateTxRoot(block.Transactions); txHash != block.Header.TxHash {
+        report.addIssue(number, blockHash, "tx-root", "have %x, computed %x", block.Header.TxHash[:4], txHash[:4])
+    }
+    for i, tx := range block.Transactions {
+        txHash := hash.CalculateTransactionHash(tx)
+        if indexed, ok := rawdb.ReadTxLookup(db, txHash); !ok {
+            report.addIssue(number, blockHash, "tx-lookup", "tx %d (%x) is not indexed", i, txHash[:4])
+        } else if indexed != blockHash {
+            report.addIssue(number, blockHash, "tx-lookup", "tx %d (%x) indexed under block %x", i, txHash[:4], indexed[:4])
+        }
+    }
+
+    statedb, err := rawdb.ReadState(db, blockHash)
+    if err != nil {
+        report.addIssue(number, blockHash, "state", "unreadable: %v", err)
+    } else if root := statedb.Root(); root != block.Header.Root {
+        report.addIssue(number, blockHash, "state-root", "stored state hashes to %x, header has %x", root[:4], block.Header.Root[:4])
+    } else {
+        supply := new(big.Int).Add(genesisHoldings, statedb.Emitted())
+        supply.Sub(supply, statedb.Burned())
+        if holdings := statedb.Holdings(); holdings.Cmp(supply) != 0 {
+            report.addIssue(number, blockHash, "supply", "accounts hold %s wei, genesis + emitted - burned is %s", holdings, supply)
+        }
+    }
+
+    if !execute || number == 0 {
+        return
+    }
+    parentState, err := rawdb.ReadState(db, block.Header.ParentHash)
+    if err != nil {
+        report.addIssue(number, blockHash, "re-execution", "parent state unreadable: %v", err)
+        return
+    }
+    report.Executed++
+    if _, err := engine.Finalize(dbChain{db}, block, parentState); err != nil {
+        report.addIssue(number, blockHash, "re-execution", "execution failed: %v", err)
+    } else if root := parentState.Root(); root != block.Header.Root {
+        report.addIssue(number, blockHash, "re-execution", "post-state hashes to %x, header has %x", root[:4], block.Header.Root[:4])
+    }
+}
@@ -0,0 +1,85 @@
+package consensus
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// Clock tells an engine the time. Block timestamps, slot waits and the
+// agreement timeouts all read it, so tests can run in simulated time.
+type Clock interface {
+    Now() time.Time
+    AfterFunc(d time.Duration, f func())
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now returns the current wall-clock time
+func (SystemClock) Now() time.Time {
+    return time.Now()
+}
+
+// AfterFunc calls f in its own goroutine once d has passed
+func (SystemClock) AfterFunc(d time.Duration, f func()) {
+    time.AfterFunc(d, f)
+}
+
+// SimulatedClock is a Clock that only moves when Advance is called
+type SimulatedClock struct {
+    now    time.Time
+    timers []simulatedTimer // Ordered by deadline
+    mu     sync.Mutex
+}
+
+type simulatedTimer struct {
+    at time.Time
+    f  func()
+}
+
+// NewSimulatedClock creates a simulated clock standing at start
+func NewSimulatedClock(start time.Time) *SimulatedClock {
+    return &SimulatedClock{now: start}
+}
+
+// Now returns the simulated time
+func (c *SimulatedClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.now
+}
+
+// AfterFunc calls f in its own goroutine once the clock advanced by d
+func (c *SimulatedClock) AfterFunc(d time.Duration, f func()) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if d <= 0 {
+        go f()
+        return
+    }
+    timer := simulatedTimer{at: c.now.Add(d), f: f}
+    index := sort.Search(len(c.timers), func(i int) bool {
+        return c.timers[i].at.After(timer.at)
+    })
+    c.timers = append(c.timers, simulatedTimer{})
+    copy(c.timers[index+1:], c.timers[index:])
+    c.timers[index] = timer
+}
+
+// Advance moves the clock forward by d and fires the timers that came due
+func (c *SimulatedClock) Advance(d time.Duration) {
+    c.mu.Lock()
+    c.now = c.now.Add(d)
+    count := sort.Search(len(c.timers), func(i int) bool {
+        return c.timers[i].at.After(c.now)
+    })
+    due := append([]simulatedTimer{}, c.timers[:count]...)
+    c.timers = c.timers[count:]
+    c.mu.Unlock()
+
+    for _, timer := range due {
+        go timer.f()
+    }
+}
@@ -0,0 +1,70 @@
+// Package consensus defines the interface between the blockchain and the
+// engines that decide who may produce blocks and what a valid block is
+package consensus
+
+import (
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// ChainHeaderReader gives an engine access to the headers of the chain a
+// block builds on, including non-canonical branches
+type ChainHeaderReader interface {
+    GetHeader(hash types.Hash) *types.Header
+}
+
+// ChainReader also gives access to the post-state of stored blocks
+type ChainReader interface {
+    ChainHeaderReader
+    StateAt(blockHash types.Hash) (*state.StateDB, error)
+}
+
+// Engine is a consensus engine. The blockchain verifies and executes blocks
+// through it; block producers build blocks with Prepare, Finalize and Seal.
+type Engine interface {
+    // Author returns the address that produced the block with header
+    Author(header *types.Header) (types.Address, error)
+
+    // VerifyHeader checks the consensus fields of header against its parent
+    VerifyHeader(chain ChainHeaderReader, header *types.Header, parent *types.Header) error
+
+    // VerifyBody checks the consensus rules of a block that need more than
+    // its header, e.g. votes. statedb is the post-state of the parent and
+    // must not be modified.
+    VerifyBody(chain ChainReader, block *types.Block, statedb *state.StateDB) error
+
+    // Prepare fills the consensus fields of a new header whose ParentHash,
+    // Number and Coinbase are set
+    Prepare(chain ChainReader, header *types.Header) error
+
+    // Finalize runs the state transition of block on the post-state of its
+    // parent and pays out the block reward
+    Finalize(chain ChainReader, block *types.Block, statedb *state.StateDB) (*types.RewardBreakdown, error)
+
+    // Seal returns block with the proof that makes it valid, e.g. a PoW
+    // nonce or a signature. Closing stop aborts sealing.
+    Seal(chain ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error)
+
+    // CalcDifficulty returns the difficulty a child of parent must carry
+    CalcDifficulty(chain ChainHeaderReader, parent *types.Header) *big.Int
+
+    // Clock returns the clock the engine tells the time with
+    Clock() Clock
+}
+
+// Finality is implemented by engines under which blocks become
+// irreversible. Without it the chain keeps its finalized and safe heads at
+// genesis.
+type Finality interface {
+    // LastCheckpoint returns the number of the latest finality candidate at
+    // or below number; genesis is always one
+    LastCheckpoint(number uint64) uint64
+
+    // IsCheckpoint reports whether the block at number is a finality candidate
+    IsCheckpoint(number *big.Int) bool
+
+    // HasSupermajority reports whether a non-checkpoint block was approved
+    // by the validators
+    HasSupermajority(chain ChainReader, block *types.Block) bool
+}
@@ -0,0 +1,157 @@
+// Package dev implements a consensus engine for local development and tests.
+// Blocks need no proof of work and no votes, so they are sealed as soon as
+// they are asked for.
+package dev
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/forks"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+var (
+    ErrUnknownAncestor   = errors.New("unknown ancestor")
+    ErrInvalidTimestamp  = errors.New("block time before parent")
+    ErrInvalidDifficulty = errors.New("dev blocks have difficulty 1")
+    ErrUnexpectedSeal    = errors.New("dev blocks carry no seal or votes")
+)
+
+// Config configures the dev engine
+type Config struct {
+    BlockReward *big.Int       // Minted for the coinbase of every block (nil mints nothing)
+    Upgrades    forks.Schedule // Protocol upgrades followed when executing transactions
+}
+
+// Engine is the dev consensus engine. The coinbase of a block authors it and
+// receives its fees and BlockReward. Every block is final as soon as it is
+// on the chain, so nothing is ever reorganised away.
+type Engine struct {
+    config    Config
+    processor *state.Processor
+    clock     consensus.Clock
+}
+
+// NewEngine creates a dev engine. A nil config mints no block reward.
+func NewEngine(config *Config) *Engine {
+    e := &Engine{
+        clock: consensus.SystemClock{},
+    }
+    if config != nil {
+        e.config = *config
+    }
+    e.processor = state.NewProcessor(e.config.Upgrades)
+    return e
+}
+
+// Author returns the coinbase of the block
+func (e *Engine) Author(header *types.Header) (types.Address, error) {
+    return header.Coinbase, nil
+}
+
+// VerifyHeader checks that header is not stamped before its parent, has
+// difficulty 1 and carries none of the hybrid consensus fields. Several
+// blocks may share a timestamp, since they are sealed on demand.
+func (e *Engine) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parent *types.Header) error {
+    if header.Time < parent.Time {
+        return fmt.Errorf("%w: %d, parent %d", ErrInvalidTimestamp, header.Time, parent.Time)
+    }
+    if header.Difficulty == nil || header.Difficulty.Cmp(big.NewInt(1)) != 0 {
+        return fmt.Errorf("%w: block #%s has %v", ErrInvalidDifficulty, header.Number, header.Difficulty)
+    }
+    if header.Checkpoint || header.Round != 0 || len(header.Seal) > 0 || len(header.RandaoReveal) > 0 {
+        return fmt.Errorf("%w: block #%s", ErrUnexpectedSeal, header.Number)
+    }
+    return nil
+}
+
+// VerifyBody checks that block carries no votes
+func (e *Engine) VerifyBody(chain consensus.ChainReader, block *types.Block, statedb *state.StateDB) error {
+    if len(block.Votes) > 0 || len(block.LastCommit) > 0 || block.Header.LastCommitHash != (types.Hash{}) {
+        return fmt.Errorf("%w: block #%s has votes", ErrUnexpectedSeal, block.Header.Number)
+    }
+    return nil
+}
+
+// Prepare stamps header with the current time, or its parent's if the clock
+// is behind it
+func (e *Engine) Prepare(chain consensus.ChainReader, header *types.Header) error {
+    parent := chain.GetHeader(header.ParentHash)
+    if parent == nil {
+        return fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, header.ParentHash[:4], header.Number)
+    }
+    header.Time = uint64(e.clock.Now().Unix())
+    if header.Time < parent.Time {
+        header.Time = parent.Time
+    }
+    header.Difficulty = e.CalcDifficulty(chain, parent)
+    header.MixDigest = parent.MixDigest
+    return nil
+}
+
+// Finalize executes the transactions of block on statedb, mints BlockReward
+// and pays it to the coinbase together with the fees
+func (e *Engine) Finalize(chain consensus.ChainReader, block *types.Block, statedb *state.StateDB) (*types.RewardBreakdown, error) {
+    result, err := e.processor.Process(block, statedb)
+    if err != nil {
+        return nil, err
+    }
+
+    emission := big.NewInt(0)
+    if e.config.BlockReward != nil {
+        emission.Set(e.config.BlockReward)
+    }
+    statedb.AddEmitted(emission)
+    reward := new(big.Int).Add(emission, result.Fees)
+    statedb.AddBalance(block.Header.Coinbase, reward)
+    return &types.RewardBreakdown{
+        Number:   block.Header.Number.Uint64(),
+        Emission: emission,
+        Fees:     result.Fees,
+        Burned:   big.NewInt(0),
+        Payouts: []types.RewardPayout{{
+            Role:    types.RewardMiner,
+            Address: block.Header.Coinbase,
+            Amount:  reward,
+        }},
+    }, nil
+}
+
+// Seal returns block unchanged: dev blocks need no proof
+func (e *Engine) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+    return block, nil
+}
+
+// CalcDifficulty returns 1, the difficulty of every dev block
+func (e *Engine) CalcDifficulty(chain consensus.ChainHeaderReader, parent *types.Header) *big.Int {
+    return big.NewInt(1)
+}
+
+// SetClock replaces the wall clock the engine stamps blocks with
+func (e *Engine) SetClock(clock consensus.Clock) {
+    e.clock = clock
+}
+
+// Clock returns the clock the engine tells the time with
+func (e *Engine) Clock() consensus.Clock {
+    return e.clock
+}
+
+// LastCheckpoint returns number: every block is a finality candidate
+func (e *Engine) LastCheckpoint(number uint64) uint64 {
+    return number
+}
+
+// IsCheckpoint reports true for every block, so each one is finalized as
+// soon as it becomes the head
+func (e *Engine) IsCheckpoint(number *big.Int) bool {
+    return true
+}
+
+// HasSupermajority reports true; dev blocks need no votes
+func (e *Engine) HasSupermajority(chain consensus.ChainReader, block *types.Block) bool {
+    return true
+}
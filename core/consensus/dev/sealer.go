@@ -0,0 +1,102 @@
+package dev
+
+import (
+    "fmt"
+    "math/big"
+    "sync"
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+// Sealer produces the blocks of a chain run by the dev engine: on demand
+// with Commit, and after Start on every batch of transactions announced as
+// pending.
+type Sealer struct {
+    engine   *Engine
+    chain    *blockchain.Blockchain
+    coinbase types.Address
+
+    mu   sync.Mutex // Serialises block production
+    quit chan struct{}
+    wg   sync.WaitGroup
+}
+
+// NewSealer creates a sealer that credits the blocks of chain to coinbase
+func NewSealer(engine *Engine, chain *blockchain.Blockchain, coinbase types.Address) *Sealer {
+    return &Sealer{
+        engine:   engine,
+        chain:    chain,
+        coinbase: coinbase,
+        quit:     make(chan struct{}),
+    }
+}
+
+// Commit seals a block with txs on top of the head and adds it to the
+// chain. An empty txs seals an empty block.
+func (s *Sealer) Commit(txs []*types.Transaction) (*types.Block, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    parent, statedb := s.chain.CurrentState()
+    header := &types.Header{
+        ParentHash: hash.CalculateBlockHash(parent.Header),
+        Number:     new(big.Int).Add(parent.Header.Number, big.NewInt(1)),
+        Coinbase:   s.coinbase,
+    }
+    if err := s.engine.Prepare(s.chain, header); err != nil {
+        return nil, err
+    }
+    block := &types.Block{
+        Header:       header,
+        Transactions: txs,
+        Votes:        []*types.Vote{},
+    }
+    if _, err := s.engine.Finalize(s.chain, block, statedb); err != nil {
+        return nil, err
+    }
+    block.Header.TxHash = hash.CalculateTxRoot(block.Transactions)
+    block.Header.Root = statedb.Root()
+
+    block, err := s.engine.Seal(s.chain, block, s.quit)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.chain.AddBlock(block); err != nil {
+        return nil, err
+    }
+    fmt.Printf("🧪 Dev block #%s sealed with %d transactions\n", block.Header.Number, len(block.Transactions))
+    return block, nil
+}
+
+// Start seals a block with every batch of transactions posted through
+// Blockchain.PostPendingTransactions until Stop is called
+func (s *Sealer) Start() {
+    sub := s.chain.SubscribeNewPendingTx(64)
+    s.wg.Add(1)
+    go func() {
+        defer s.wg.Done()
+        defer sub.Unsubscribe()
+
+        for {
+            select {
+            case event, ok := <-sub.Chan():
+                if !ok {
+                    return
+                }
+                if _, err := s.Commit(event.Txs); err != nil {
+                    fmt.Printf("❌ Failed to seal dev block: %v\n", err)
+                }
+            case <-s.quit:
+                return
+            }
+        }
+    }()
+}
+
+// Stop ends sealing on pending transactions and waits for the block in
+// progress
+func (s *Sealer) Stop() {
+    close(s.quit)
+    s.wg.Wait()
+}
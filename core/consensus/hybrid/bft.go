@@ -0,0 +1,593 @@
+package hybrid
+
+import (
+    "bytes"
+    "fmt"
+    "math/big"
+    "sort"
+    "time"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// Agreement rules for PoS blocks (Tendermint style). A height runs in rounds
+// 0, 1, 2, ... and "2/3" and "1/3" below mean more than that share of the
+// epoch's stake:
+//
+//   - Propose: the proposer of the round (drawn like the block proposer, see
+//     randao.go) broadcasts a signed Proposal: the block it is locked on or
+//     last saw 2/3 prevotes for (POLRound), or a new block.
+//   - Prevote: a validator prevotes the proposed block if it is valid, it
+//     is not locked on another block and its LastCommit holds every
+//     precommit of the validator's own certificate for the parent (see
+//     omitsPrecommits), or if the proposal shows 2/3 prevotes for it in a
+//     round after the lock. Otherwise, or when the proposal times out, it
+//     prevotes nil.
+//   - Precommit: on 2/3 prevotes for the block a validator locks on it and
+//     precommits it; on 2/3 prevotes for nil, or when the prevote step times
+//     out, it precommits nil.
+//   - Commit: 2/3 precommits for a block in one round commit it, and those
+//     precommits become its commit certificate (Block.Votes). When the
+//     precommit step times out the next round starts.
+//
+// Timeouts grow with the round, and a validator jumps to any later round
+// that 1/3 of the stake is already in.
+
+const (
+    defaultProposeTimeout = 3 * time.Second
+    defaultVoteTimeout    = time.Second
+    defaultTimeoutDelta   = 500 * time.Millisecond
+    maxPendingMessages    = 1024 // Messages kept for heights not started yet
+)
+
+type roundStep uint8
+
+const (
+    stepPropose roundStep = iota
+    stepPrevote
+    stepPrecommit
+)
+
+// BFTConfig sets the agreement timeouts; zero values use the defaults
+type BFTConfig struct {
+    ProposeTimeout time.Duration // Wait for the proposal of a round (default 3s)
+    VoteTimeout    time.Duration // Wait for more votes once 2/3 voted (default 1s)
+    TimeoutDelta   time.Duration // Added to every timeout per round (default 500ms)
+}
+
+// BlockBuilder builds the new block this validator proposes in a round
+type BlockBuilder func(round uint32) (*types.Block, error)
+
+// BFT runs the agreement protocol as one validator. Messages for heights it
+// has not reached yet are kept, so Agree can be called height after height.
+type BFT struct {
+    engine    *HybridEngine
+    chain     ChainReader
+    signer    *keys.KeyPair
+    transport Transport
+    config    BFTConfig
+    pending   []*ConsensusMessage
+}
+
+// NewBFT creates an agreement instance voting with signer
+func NewBFT(engine *HybridEngine, chain ChainReader, signer *keys.KeyPair, transport Transport, config BFTConfig) *BFT {
+    if config.ProposeTimeout == 0 {
+        config.ProposeTimeout = defaultProposeTimeout
+    }
+    if config.VoteTimeout == 0 {
+        config.VoteTimeout = defaultVoteTimeout
+    }
+    if config.TimeoutDelta == 0 {
+        config.TimeoutDelta = defaultTimeoutDelta
+    }
+    return &BFT{
+        engine:    engine,
+        chain:     chain,
+        signer:    signer,
+        transport: transport,
+        config:    config,
+    }
+}
+
+// Address returns the validator this instance votes as
+func (b *BFT) Address() types.Address {
+    return b.signer.Address
+}
+
+// Agree runs the protocol for the PoS block on top of parent until a block
+// is committed, and returns it with its commit certificate. build is called
+// when this validator has to propose a new block. Closing stop aborts with
+// ErrAgreementAborted, e.g. once the block arrived from elsewhere.
+func (b *BFT) Agree(parent *types.Block, build BlockBuilder, stop <-chan struct{}) (*types.Block, error) {
+    number := new(big.Int).Add(parent.Header.Number, big.NewInt(1))
+    if !b.engine.isPosBlock(number) {
+        return nil, fmt.Errorf("%w: block #%s is a PoW checkpoint", ErrInvalidProposal, number)
+    }
+    validators, err := b.engine.Validators(b.chain, parent.Header)
+    if err != nil {
+        return nil, err
+    }
+    if validators.Len() == 0 {
+        return nil, ErrNoValidators
+    }
+
+    a := &agreement{
+        bft:          b,
+        parent:       parent.Header,
+        parentHash:   hash.CalculateBlockHash(parent.Header),
+        parentCommit: parent.Votes,
+        number:       number.Uint64(),
+        validators:   validators,
+        build:        build,
+        lockedRound:  -1,
+        validRound:   -1,
+        proposers:    make(map[uint32]types.Address),
+        proposals:    make(map[uint32]*types.Proposal),
+        blocks:       make(map[types.Hash]*types.Block),
+        verified:     make(map[types.Hash]error),
+        prevotes:     make(map[uint32]map[types.Address]*types.Vote),
+        precommits:   make(map[uint32]map[types.Address]*types.Vote),
+        participants: make(map[uint32]map[types.Address]bool),
+        timeouts:     make(chan timeoutEvent, 16),
+        done:         make(chan struct{}),
+    }
+    defer close(a.done)
+
+    fmt.Printf("🤝 Validator %x joins agreement on block #%d\n", b.signer.Address[:4], a.number)
+    a.startRound(0)
+
+    // Messages that arrived while the previous height was running
+    pending := b.pending
+    b.pending = nil
+    for _, msg := range pending {
+        a.handle(msg)
+    }
+    a.advance()
+
+    for a.committed == nil {
+        select {
+        case msg, ok := <-b.transport.Messages():
+            if !ok {
+                return nil, fmt.Errorf("%w: transport closed", ErrAgreementAborted)
+            }
+            a.handle(msg)
+        case event := <-a.timeouts:
+            a.onTimeout(event)
+        case <-stop:
+            return nil, fmt.Errorf("%w: block #%d in round %d", ErrAgreementAborted, a.number, a.round)
+        }
+        a.advance()
+    }
+    return a.committed, nil
+}
+
+// keep stores a message for a later height, dropping the oldest when full
+func (b *BFT) keep(msg *ConsensusMessage) {
+    if len(b.pending) >= maxPendingMessages {
+        b.pending = b.pending[1:]
+    }
+    b.pending = append(b.pending, msg)
+}
+
+type timeoutEvent struct {
+    round uint32
+    step  roundStep
+}
+
+// agreement is the protocol state of one height
+type agreement struct {
+    bft          *BFT
+    parent       *types.Header
+    parentHash   types.Hash
+    parentCommit []*types.Vote // This validator's certificate of the parent
+    number     uint64
+    validators *ValidatorSet
+    build      BlockBuilder
+
+    round       uint32
+    step        roundStep
+    lockedRound int32
+    lockedBlock *types.Block
+    validRound  int32
+    validBlock  *types.Block
+
+    proposers    map[uint32]types.Address
+    proposals    map[uint32]*types.Proposal
+    blocks       map[types.Hash]*types.Block // Every proposed block by hash
+    verified     map[types.Hash]error
+    prevotes     map[uint32]map[types.Address]*types.Vote
+    precommits   map[uint32]map[types.Address]*types.Vote
+    participants map[uint32]map[types.Address]bool
+
+    // Rules that fire only once per round
+    prevoteWait   bool
+    precommitWait bool
+    polkaSeen     bool
+
+    timeouts  chan timeoutEvent
+    done      chan struct{}
+    committed *types.Block
+}
+
+// startRound enters round, proposing if it is this validator's turn
+func (a *agreement) startRound(round uint32) {
+    a.round = round
+    a.step = stepPropose
+    a.prevoteWait, a.precommitWait, a.polkaSeen = false, false, false
+
+    proposer := a.proposer(round)
+    fmt.Printf("🔄 Block #%d round %d, proposer %x\n", a.number, round, proposer[:4])
+    if proposer == a.bft.signer.Address {
+        a.propose()
+    }
+    a.schedule(stepPropose, a.bft.config.ProposeTimeout)
+}
+
+// propose broadcasts the valid block of an earlier round, or a new block
+func (a *agreement) propose() {
+    block, polRound := a.validBlock, a.validRound
+    if block == nil {
+        built, err := a.build(a.round)
+        if err != nil {
+            fmt.Printf("❌ Failed to build proposal for block #%d round %d: %v\n", a.number, a.round, err)
+            return
+        }
+        block, polRound = built, -1
+    }
+
+    proposal := &types.Proposal{
+        Number:   a.number,
+        Round:    a.round,
+        POLRound: polRound,
+        Block:    block,
+    }
+    if err := a.bft.signer.SignProposal(proposal); err != nil {
+        fmt.Printf("❌ Failed to sign proposal: %v\n", err)
+        return
+    }
+    fmt.Printf("📣 Validator %x proposes block #%d in round %d\n", a.bft.signer.Address[:4], a.number, a.round)
+    a.addProposal(proposal)
+    a.bft.transport.Broadcast(&ConsensusMessage{Proposal: proposal})
+}
+
+// vote signs and broadcasts this validator's vote for blockHash (zero: nil)
+func (a *agreement) vote(voteType types.VoteType, blockHash types.Hash) {
+    if !a.validators.Contains(a.bft.signer.Address) {
+        return // Not a validator in this epoch: follow without voting
+    }
+    vote := &types.Vote{
+        Validator: a.bft.signer.Address,
+        Number:    a.number,
+        Round:     a.round,
+        Type:      voteType,
+        BlockHash: blockHash,
+        Decision:  blockHash != types.Hash{},
+        Timestamp: a.bft.engine.clock.Now().Unix(),
+    }
+    if err := a.bft.signer.SignVote(vote); err != nil {
+        fmt.Printf("❌ Failed to sign vote: %v\n", err)
+        return
+    }
+    a.addVote(vote)
+    a.bft.transport.Broadcast(&ConsensusMessage{Vote: vote})
+}
+
+// schedule fires a timeout for step of the current round
+func (a *agreement) schedule(step roundStep, base time.Duration) {
+    event := timeoutEvent{round: a.round, step: step}
+    a.bft.engine.clock.AfterFunc(base+time.Duration(a.round)*a.bft.config.TimeoutDelta, func() {
+        select {
+        case a.timeouts <- event:
+        case <-a.done:
+        }
+    })
+}
+
+// onTimeout moves on when a step of the current round took too long
+func (a *agreement) onTimeout(event timeoutEvent) {
+    if event.round != a.round {
+        return
+    }
+    switch event.step {
+    case stepPropose:
+        if a.step == stepPropose {
+            fmt.Printf("⏰ No valid proposal for block #%d in round %d\n", a.number, a.round)
+            a.vote(types.VotePrevote, types.Hash{})
+            a.step = stepPrevote
+        }
+    case stepPrevote:
+        if a.step == stepPrevote {
+            a.vote(types.VotePrecommit, types.Hash{})
+            a.step = stepPrecommit
+        }
+    case stepPrecommit:
+        a.startRound(a.round + 1)
+    }
+}
+
+// handle files a message of this height and keeps those of later heights
+func (a *agreement) handle(msg *ConsensusMessage) {
+    number, ok := msg.number()
+    switch {
+    case !ok || number < a.number:
+        return
+    case number > a.number:
+        a.bft.keep(msg)
+    case msg.Proposal != nil:
+        a.addProposal(msg.Proposal)
+    default:
+        a.addVote(msg.Vote)
+    }
+}
+
+// addProposal records the first correctly signed proposal of a round. A new
+// block must be built for the proposal's height and round; a block proposed
+// again with a POLRound must come from that round or an earlier one.
+func (a *agreement) addProposal(proposal *types.Proposal) {
+    if _, exists := a.proposals[proposal.Round]; exists {
+        return
+    }
+    block := proposal.Block
+    if block == nil || block.Header == nil || block.Header.Number == nil || block.Header.ParentHash != a.parentHash {
+        return
+    }
+    if !block.Header.Number.IsUint64() || block.Header.Number.Uint64() != proposal.Number {
+        return
+    }
+    if proposal.POLRound < -1 || int64(proposal.POLRound) >= int64(proposal.Round) {
+        return
+    }
+    if proposal.POLRound < 0 && block.Header.Round != proposal.Round {
+        return
+    }
+    if proposal.POLRound >= 0 && int64(block.Header.Round) > int64(proposal.POLRound) {
+        return
+    }
+    signer, err := keys.ProposalSigner(proposal)
+    if err != nil || signer != a.proposer(proposal.Round) {
+        return
+    }
+    a.proposals[proposal.Round] = proposal
+    a.blocks[hash.CalculateBlockHash(block.Header)] = block
+    a.participate(proposal.Round, signer)
+}
+
+// addVote records the first correctly signed vote of a validator per round
+// and step
+func (a *agreement) addVote(vote *types.Vote) {
+    var votes map[uint32]map[types.Address]*types.Vote
+    switch vote.Type {
+    case types.VotePrevote:
+        votes = a.prevotes
+    case types.VotePrecommit:
+        votes = a.precommits
+    default:
+        return
+    }
+    if vote.Decision != (vote.BlockHash != types.Hash{}) || !a.validators.Contains(vote.Validator) {
+        return
+    }
+    if signer, err := keys.VoteSigner(vote); err != nil || signer != vote.Validator {
+        return
+    }
+
+    if votes[vote.Round] == nil {
+        votes[vote.Round] = make(map[types.Address]*types.Vote)
+    }
+    if existing, exists := votes[vote.Round][vote.Validator]; exists {
+        if existing.BlockHash != vote.BlockHash {
+            fmt.Printf("⚠️  Validator %x cast conflicting votes for block #%d in round %d\n", vote.Validator[:4], a.number, vote.Round)
+        }
+        return
+    }
+    votes[vote.Round][vote.Validator] = vote
+    a.participate(vote.Round, vote.Validator)
+}
+
+func (a *agreement) participate(round uint32, validator types.Address) {
+    if a.participants[round] == nil {
+        a.participants[round] = make(map[types.Address]bool)
+    }
+    a.participants[round][validator] = true
+}
+
+// advance applies the protocol rules until none fires
+func (a *agreement) advance() {
+    for a.committed == nil && a.applyRules() {
+    }
+}
+
+// applyRules fires the first applicable rule and reports whether one fired
+func (a *agreement) applyRules() bool {
+    total := a.validators.TotalStake
+
+    // Commit a valid block precommitted by 2/3 in any round
+    for round, votes := range a.precommits {
+        for blockHash, stake := range tally(votes, a.validators) {
+            if blockHash != (types.Hash{}) && hasQuorum(stake, total) && a.isValid(blockHash) {
+                a.commit(round, blockHash)
+                return true
+            }
+        }
+    }
+
+    // Catch up with a later round 1/3 of the stake is already in
+    for round, members := range a.participants {
+        if round > a.round {
+            stake := big.NewInt(0)
+            for validator := range members {
+                stake.Add(stake, a.validators.StakeOf(validator))
+            }
+            if hasOneThird(stake, total) {
+                a.startRound(round)
+                return true
+            }
+        }
+    }
+
+    proposal := a.proposals[a.round]
+    var proposed types.Hash
+    if proposal != nil {
+        proposed = hash.CalculateBlockHash(proposal.Block.Header)
+    }
+    prevotes := tally(a.prevotes[a.round], a.validators)
+
+    if a.step == stepPropose && proposal != nil {
+        if proposal.POLRound < 0 {
+            if a.isValid(proposed) && !a.omitsPrecommits(proposal.Block) && (a.lockedRound < 0 || a.lockedHash() == proposed) {
+                a.vote(types.VotePrevote, proposed)
+            } else {
+                a.vote(types.VotePrevote, types.Hash{})
+            }
+            a.step = stepPrevote
+            return true
+        }
+        polka := tally(a.prevotes[uint32(proposal.POLRound)], a.validators)
+        if hasQuorum(stakeOrZero(polka[proposed]), total) {
+            if a.isValid(proposed) && (a.lockedRound <= proposal.POLRound || a.lockedHash() == proposed) {
+                a.vote(types.VotePrevote, proposed)
+            } else {
+                a.vote(types.VotePrevote, types.Hash{})
+            }
+            a.step = stepPrevote
+            return true
+        }
+    }
+
+    if a.step == stepPrevote && !a.prevoteWait && hasQuorum(sumStake(prevotes), total) {
+        a.prevoteWait = true
+        a.schedule(stepPrevote, a.bft.config.VoteTimeout)
+        return true
+    }
+
+    if proposal != nil && a.step >= stepPrevote && !a.polkaSeen && hasQuorum(stakeOrZero(prevotes[proposed]), total) && a.isValid(proposed) {
+        a.polkaSeen = true
+        if a.step == stepPrevote {
+            a.lockedRound, a.lockedBlock = int32(a.round), proposal.Block
+            a.vote(types.VotePrecommit, proposed)
+            a.step = stepPrecommit
+        }
+        a.validRound, a.validBlock = int32(a.round), proposal.Block
+        return true
+    }
+
+    if a.step == stepPrevote && hasQuorum(stakeOrZero(prevotes[types.Hash{}]), total) {
+        a.vote(types.VotePrecommit, types.Hash{})
+        a.step = stepPrecommit
+        return true
+    }
+
+    if !a.precommitWait && hasQuorum(sumStake(tally(a.precommits[a.round], a.validators)), total) {
+        a.precommitWait = true
+        a.schedule(stepPrecommit, a.bft.config.VoteTimeout)
+        return true
+    }
+    return false
+}
+
+// commit finishes the height with the block precommitted in round
+func (a *agreement) commit(round uint32, blockHash types.Hash) {
+    var certificate []*types.Vote
+    for _, vote := range a.precommits[round] {
+        if vote.BlockHash == blockHash {
+            certificate = append(certificate, vote)
+        }
+    }
+    sort.Slice(certificate, func(i, j int) bool {
+        return bytes.Compare(certificate[i].Validator[:], certificate[j].Validator[:]) < 0
+    })
+
+    block := a.blocks[blockHash]
+    a.committed = &types.Block{
+        Header:       block.Header,
+        Transactions: block.Transactions,
+        Votes:        certificate,
+        LastCommit:   block.LastCommit,
+    }
+    fmt.Printf("✅ Block #%d (%x) committed in round %d with %d precommits\n", a.number, blockHash[:4], round, len(certificate))
+}
+
+// proposer returns the validator that proposes in round
+func (a *agreement) proposer(round uint32) types.Address {
+    if proposer, ok := a.proposers[round]; ok {
+        return proposer
+    }
+    proposer, _ := a.bft.engine.posEngine.selectValidator(proposerSeed(a.parent, new(big.Int).SetUint64(a.number), round), a.validators)
+    a.proposers[round] = proposer
+    return proposer
+}
+
+// isValid fully verifies a proposed block once
+func (a *agreement) isValid(blockHash types.Hash) bool {
+    block := a.blocks[blockHash]
+    if block == nil {
+        return false
+    }
+    err, checked := a.verified[blockHash]
+    if !checked {
+        err = a.bft.engine.VerifyProposal(a.bft.chain, block)
+        a.verified[blockHash] = err
+        if err != nil {
+            fmt.Printf("❌ Proposed block #%d (%x) is invalid: %v\n", a.number, blockHash[:4], err)
+        }
+    }
+    return err == nil
+}
+
+// omitsPrecommits reports whether the LastCommit of block leaves out a
+// precommit of this validator's certificate for the parent. LastCommit
+// decides who is credited as live (see trackLiveness), and importers cannot
+// tell which precommits a proposer dropped, so the voters check it against
+// the precommits they received themselves. A certificate from another round
+// than LastCommit cannot be compared and is not held against the proposal.
+func (a *agreement) omitsPrecommits(block *types.Block) bool {
+    if len(a.parentCommit) == 0 || len(block.LastCommit) == 0 || block.LastCommit[0].Round != a.parentCommit[0].Round {
+        return false
+    }
+    included := make(map[types.Address]bool)
+    for _, vote := range block.LastCommit {
+        included[vote.Validator] = true
+    }
+    for _, vote := range a.parentCommit {
+        if !included[vote.Validator] {
+            fmt.Printf("⚠️  Proposed block #%d leaves out the precommit of %x for its parent\n", a.number, vote.Validator[:4])
+            return true
+        }
+    }
+    return false
+}
+
+func (a *agreement) lockedHash() types.Hash {
+    if a.lockedBlock == nil {
+        return types.Hash{}
+    }
+    return hash.CalculateBlockHash(a.lockedBlock.Header)
+}
+
+// tally sums the stake behind every block hash voted for (zero hash: nil)
+func tally(votes map[types.Address]*types.Vote, validators *ValidatorSet) map[types.Hash]*big.Int {
+    stakes := make(map[types.Hash]*big.Int)
+    for _, vote := range votes {
+        if stakes[vote.BlockHash] == nil {
+            stakes[vote.BlockHash] = big.NewInt(0)
+        }
+        stakes[vote.BlockHash].Add(stakes[vote.BlockHash], validators.StakeOf(vote.Validator))
+    }
+    return stakes
+}
+
+func sumStake(stakes map[types.Hash]*big.Int) *big.Int {
+    total := big.NewInt(0)
+    for _, stake := range stakes {
+        total.Add(total, stake)
+    }
+    return total
+}
+
+func stakeOrZero(stake *big.Int) *big.Int {
+    if stake == nil {
+        return big.NewInt(0)
+    }
+    return stake
+}
@@ -0,0 +1,401 @@
+package hybrid_test
+
+import (
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+var testBFTConfig = hybrid.BFTConfig{
+    ProposeTimeout: 100 * time.Millisecond,
+    VoteTimeout:    30 * time.Millisecond,
+    TimeoutDelta:   20 * time.Millisecond,
+}
+
+// newValidators joins one agreement instance per key to a new local network
+func (tc *testChain) newValidators(signers []*keys.KeyPair) []*hybrid.BFT {
+    network := hybrid.NewLocalNetwork()
+    var validators []*hybrid.BFT
+    for _, signer := range signers {
+        validators = append(validators, hybrid.NewBFT(tc.engine, tc, signer, network.Join(), testBFTConfig))
+    }
+    return validators
+}
+
+// honestBuilder proposes the empty block after parent
+func (tc *testChain) honestBuilder(parent *types.Block, statedb *state.StateDB) hybrid.BlockBuilder {
+    return func(round uint32) (*types.Block, error) {
+        return tc.engine.CreateProposal(tc, parent, round, nil, types.Address{0xa}, statedb)
+    }
+}
+
+// agree runs the agreement on the block after parent, validators[i]
+// proposing with builders[i], until every validator committed or timeout
+// passed. Simulated time runs five times faster than wall time meanwhile.
+func (tc *testChain) agree(parent *types.Block, validators []*hybrid.BFT, builders []hybrid.BlockBuilder, timeout time.Duration) ([]*types.Block, []error) {
+    tc.clock.Advance(tc.config.BlockTime)
+    ticking := make(chan struct{})
+    defer close(ticking)
+    go func() {
+        ticker := time.NewTicker(time.Millisecond)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticking:
+                return
+            case <-ticker.C:
+                tc.clock.Advance(5 * time.Millisecond)
+            }
+        }
+    }()
+
+    blocks := make([]*types.Block, len(validators))
+    errs := make([]error, len(validators))
+    stop := make(chan struct{})
+    done := make(chan struct{}, len(validators))
+    for i, validator := range validators {
+        go func(i int, validator *hybrid.BFT) {
+            blocks[i], errs[i] = validator.Agree(parent, builders[i], stop)
+            done <- struct{}{}
+        }(i, validator)
+    }
+    expired := time.After(timeout)
+    for finished := 0; finished < len(validators); finished++ {
+        select {
+        case <-done:
+        case <-expired:
+            close(stop)
+            for ; finished < len(validators); finished++ {
+                <-done
+            }
+        }
+    }
+    return blocks, errs
+}
+
+// commitAll checks that every validator committed the same block and
+// returns it
+func commitAll(t *testing.T, blocks []*types.Block, errs []error) *types.Block {
+    t.Helper()
+    for i, err := range errs {
+        if err != nil {
+            t.Fatalf("validator %d: %v", i, err)
+        }
+    }
+    committed := hash.CalculateBlockHash(blocks[0].Header)
+    for i, block := range blocks[1:] {
+        if hash.CalculateBlockHash(block.Header) != committed {
+            t.Fatalf("validator %d committed another block", i+1)
+        }
+    }
+    return blocks[0]
+}
+
+func TestProposalOmittingPrecommitsIsRejected(t *testing.T) {
+    tc := newTestChain(t, nil)
+    tc.addBlock(t) // Certified by every dev validator
+    parent, statedb := tc.CurrentState()
+    if len(parent.Votes) != 3 {
+        t.Fatalf("parent has %d precommits, want 3", len(parent.Votes))
+    }
+
+    // The proposer of round 0 leaves out the precommit of the smallest
+    // validator, which the other two still outweigh
+    signers := blockchain.DevValidatorKeys()
+    omitted := signers[1].Address
+    stripped := &types.Block{Header: parent.Header, Transactions: parent.Transactions}
+    for _, vote := range parent.Votes {
+        if vote.Validator != omitted {
+            stripped.Votes = append(stripped.Votes, vote)
+        }
+    }
+    cheater, err := tc.engine.ExpectedProposer(tc, parent.Header, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var builders []hybrid.BlockBuilder
+    for _, signer := range signers {
+        if signer.Address == cheater {
+            builders = append(builders, tc.honestBuilder(stripped, statedb))
+        } else {
+            builders = append(builders, tc.honestBuilder(parent, statedb))
+        }
+    }
+
+    blocks, errs := tc.agree(parent, tc.newValidators(signers), builders, 20*time.Second)
+    block := commitAll(t, blocks, errs)
+    if len(block.LastCommit) != 3 {
+        t.Fatalf("committed block #%s credits %d precommits of its parent, want 3", block.Header.Number, len(block.LastCommit))
+    }
+    if err := tc.AddBlock(block); err != nil {
+        t.Fatal(err)
+    }
+    if info := tc.GetStateDB().GetValidatorInfo(omitted); info.MissedCount != 0 {
+        t.Fatalf("omitted validator recorded with %d missed blocks", info.MissedCount)
+    }
+}
+
+// extendUntil adds certified blocks until ready accepts the head
+func (tc *testChain) extendUntil(t *testing.T, ready func(head *types.Block) bool) *types.Block {
+    t.Helper()
+    for i := 0; i < 100; i++ {
+        if head := tc.GetCurrentBlock(); ready(head) {
+            return head
+        }
+        tc.addBlock(t)
+    }
+    t.Fatal("no suitable head within 100 blocks")
+    return nil
+}
+
+// proposerOf returns the key of the proposer of round on top of parent
+func (tc *testChain) proposerOf(t *testing.T, parent *types.Block, round uint32) *keys.KeyPair {
+    t.Helper()
+    proposer, err := tc.engine.ExpectedProposer(tc, parent.Header, round)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, key := range blockchain.DevValidatorKeys() {
+        if key.Address == proposer {
+            return key
+        }
+    }
+    t.Fatalf("proposer %x is not a dev validator", proposer[:4])
+    return nil
+}
+
+// tick runs simulated time five times faster than wall time until the test
+// ends
+func (tc *testChain) tick(t *testing.T) {
+    ticking := make(chan struct{})
+    t.Cleanup(func() { close(ticking) })
+    go func() {
+        ticker := time.NewTicker(time.Millisecond)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticking:
+                return
+            case <-ticker.C:
+                tc.clock.Advance(5 * time.Millisecond)
+            }
+        }
+    }()
+}
+
+// propose broadcasts signer's proposal of block
+func propose(t *testing.T, transport hybrid.Transport, signer *keys.KeyPair, round uint32, polRound int32, block *types.Block) {
+    t.Helper()
+    proposal := &types.Proposal{
+        Number:   block.Header.Number.Uint64(),
+        Round:    round,
+        POLRound: polRound,
+        Block:    block,
+    }
+    if err := signer.SignProposal(proposal); err != nil {
+        t.Fatal(err)
+    }
+    transport.Broadcast(&hybrid.ConsensusMessage{Proposal: proposal})
+}
+
+// vote broadcasts the votes of signers for block (nil: a nil vote)
+func vote(transport hybrid.Transport, signers []*keys.KeyPair, voteType types.VoteType, number uint64, round uint32, block *types.Block) {
+    var blockHash types.Hash
+    if block != nil {
+        blockHash = hash.CalculateBlockHash(block.Header)
+    }
+    for _, signer := range signers {
+        vote := &types.Vote{
+            Validator: signer.Address,
+            Number:    number,
+            Round:     round,
+            Type:      voteType,
+            BlockHash: blockHash,
+            Decision:  block != nil,
+            Timestamp: time.Now().Unix(),
+        }
+        signer.SignVote(vote)
+        transport.Broadcast(&hybrid.ConsensusMessage{Vote: vote})
+    }
+}
+
+// expectVote waits for the vote of the given type and round that the
+// validator under test broadcasts, and checks it is for block (nil: nil)
+func expectVote(t *testing.T, transport hybrid.Transport, voteType types.VoteType, round uint32, block *types.Block) {
+    t.Helper()
+    var want types.Hash
+    if block != nil {
+        want = hash.CalculateBlockHash(block.Header)
+    }
+    timeout := time.After(10 * time.Second)
+    for {
+        select {
+        case msg := <-transport.Messages():
+            if msg.Vote == nil || msg.Vote.Type != voteType || msg.Vote.Round != round {
+                continue
+            }
+            if msg.Vote.BlockHash != want {
+                t.Fatalf("%v in round %d for %x, want %x", voteType, round, msg.Vote.BlockHash[:4], want[:4])
+            }
+            return
+        case <-timeout:
+            t.Fatalf("no %v in round %d", voteType, round)
+        }
+    }
+}
+
+// newLoneValidator runs the agreement on the block after the head as the
+// smallest dev validator, whose two peers are played by the test through
+// the returned transport. Their stake alone is a quorum.
+func (tc *testChain) newLoneValidator(t *testing.T, config hybrid.BFTConfig) (hybrid.Transport, <-chan *types.Block) {
+    t.Helper()
+    network := hybrid.NewLocalNetwork()
+    validator := hybrid.NewBFT(tc.engine, tc, blockchain.DevValidatorKeys()[1], network.Join(), config)
+    peers := network.Join()
+
+    parent := tc.GetCurrentBlock()
+    stop := make(chan struct{})
+    committed := make(chan *types.Block, 1)
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        block, err := validator.Agree(parent, nil, stop)
+        if err == nil {
+            committed <- block
+        }
+    }()
+    t.Cleanup(func() {
+        close(stop)
+        <-done
+    })
+    return peers, committed
+}
+
+func TestBFTCommits(t *testing.T) {
+    tc := newTestChain(t, nil)
+    validators := tc.newValidators(blockchain.DevValidatorKeys())
+    for height := 1; height <= 3; height++ {
+        parent, statedb := tc.CurrentState()
+        builder := tc.honestBuilder(parent, statedb)
+        blocks, errs := tc.agree(parent, validators, []hybrid.BlockBuilder{builder, builder, builder}, 20*time.Second)
+        block := commitAll(t, blocks, errs)
+        if err := tc.AddBlock(block); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if head := tc.GetCurrentBlock(); head.Header.Number.Uint64() != 3 || !tc.engine.HasSupermajority(tc, head) {
+        t.Fatalf("head #%s is not a committed block #3", head.Header.Number)
+    }
+}
+
+func TestBFTRoundChange(t *testing.T) {
+    tc := newTestChain(t, nil)
+    signers := blockchain.DevValidatorKeys()
+    offline := signers[1]
+
+    // The proposer of round 0 is offline; the other two are a quorum
+    parent := tc.extendUntil(t, func(head *types.Block) bool {
+        return tc.proposerOf(t, head, 0).Address == offline.Address
+    })
+    statedb := tc.GetStateDB()
+    builder := tc.honestBuilder(parent, statedb)
+    online := []*keys.KeyPair{signers[0], signers[2]}
+    blocks, errs := tc.agree(parent, tc.newValidators(online), []hybrid.BlockBuilder{builder, builder}, 20*time.Second)
+    block := commitAll(t, blocks, errs)
+    if block.Header.Round == 0 {
+        t.Fatal("block committed in the round of the offline proposer")
+    }
+    if err := tc.AddBlock(block); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestBFTLockAndProofOfLock(t *testing.T) {
+    tc := newTestChain(t, nil)
+    signers := blockchain.DevValidatorKeys()
+    peers := []*keys.KeyPair{signers[0], signers[2]}
+
+    // The validator under test proposes in none of the rounds played
+    parent := tc.extendUntil(t, func(head *types.Block) bool {
+        for round := uint32(0); round <= 2; round++ {
+            if tc.proposerOf(t, head, round).Address == signers[1].Address {
+                return false
+            }
+        }
+        return true
+    })
+    statedb := tc.GetStateDB()
+    number := parent.Header.Number.Uint64() + 1
+    tc.clock.Advance(tc.config.BlockTime)
+    first, err := tc.engine.CreateProposal(tc, parent, 0, nil, types.Address{0xa}, statedb)
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, err := tc.engine.CreateProposal(tc, parent, 1, nil, types.Address{0xb}, statedb)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    config := testBFTConfig
+    config.ProposeTimeout = time.Hour // Rounds only end on precommit timeouts
+    transport, committed := tc.newLoneValidator(t, config)
+    tc.tick(t)
+
+    // Round 0: 2/3 prevote the first block, so the validator locks on it
+    propose(t, transport, tc.proposerOf(t, parent, 0), 0, -1, first)
+    expectVote(t, transport, types.VotePrevote, 0, first)
+    vote(transport, peers, types.VotePrevote, number, 0, first)
+    expectVote(t, transport, types.VotePrecommit, 0, first)
+
+    // Round 1: locked, it refuses a new block
+    propose(t, transport, tc.proposerOf(t, parent, 1), 1, -1, second)
+    vote(transport, peers, types.VotePrecommit, number, 0, nil)
+    expectVote(t, transport, types.VotePrevote, 1, nil)
+    vote(transport, peers, types.VotePrecommit, number, 1, nil)
+
+    // Round 2: the second block is proposed again with the 2/3 prevotes it
+    // got in round 1, which unlock the validator
+    propose(t, transport, tc.proposerOf(t, parent, 2), 2, 1, second)
+    vote(transport, peers, types.VotePrevote, number, 1, second)
+    expectVote(t, transport, types.VotePrevote, 2, second)
+    vote(transport, peers, types.VotePrevote, number, 2, second)
+    expectVote(t, transport, types.VotePrecommit, 2, second)
+    vote(transport, peers, types.VotePrecommit, number, 2, second)
+
+    select {
+    case block := <-committed:
+        if hash.CalculateBlockHash(block.Header) != hash.CalculateBlockHash(second.Header) {
+            t.Fatalf("committed %x, want the second block", hash.CalculateBlockHash(block.Header))
+        }
+    case <-time.After(10 * time.Second):
+        t.Fatal("second block not committed")
+    }
+}
+
+func TestBFTIgnoresProposalOfAnotherRound(t *testing.T) {
+    tc := newTestChain(t, nil)
+    signers := blockchain.DevValidatorKeys()
+    parent := tc.extendUntil(t, func(head *types.Block) bool {
+        return tc.proposerOf(t, head, 0).Address != signers[1].Address
+    })
+    statedb := tc.GetStateDB()
+    tc.clock.Advance(tc.config.BlockTime)
+    later, err := tc.engine.CreateProposal(tc, parent, 1, nil, types.Address{0xa}, statedb)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    transport, _ := tc.newLoneValidator(t, testBFTConfig)
+
+    // A valid block built for round 1 is offered as the new block of round
+    // 0 by its proposer; the validator prevotes nil once round 0 times out
+    propose(t, transport, tc.proposerOf(t, parent, 0), 0, -1, later)
+    tc.tick(t)
+    expectVote(t, transport, types.VotePrevote, 0, nil)
+}
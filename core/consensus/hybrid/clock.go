@@ -0,0 +1,88 @@
+package hybrid
+
+import (
+    "time"
+    "github.com/selsichain/selsichain-core/core/consensus"
+)
+
+const (
+    defaultBlockTime  = 12 * time.Second
+    allowedClockDrift = 5 * time.Second // How far ahead of the local clock a proposal may be stamped
+)
+
+// Clock tells the engine the time
+type Clock = consensus.Clock
+
+// SystemClock is the wall clock
+type SystemClock = consensus.SystemClock
+
+// SlotClock divides time into slots of BlockTime, starting with slot 0 at
+// the genesis timestamp. Every block is stamped with the start of its slot
+// and takes a later slot than its parent, so at most one block is produced
+// per slot.
+type SlotClock struct {
+    genesisTime uint64
+    slotSeconds uint64
+    clock       Clock
+}
+
+// NewSlotClock creates the slot clock of the chain whose genesis block was
+// stamped genesisTime. A zero blockTime uses the default of 12s.
+func NewSlotClock(genesisTime uint64, blockTime time.Duration, clock Clock) *SlotClock {
+    return &SlotClock{
+        genesisTime: genesisTime,
+        slotSeconds: slotSeconds(blockTime),
+        clock:       clock,
+    }
+}
+
+// SlotAt returns the slot containing the Unix time timestamp
+func (s *SlotClock) SlotAt(timestamp uint64) uint64 {
+    if timestamp < s.genesisTime {
+        return 0
+    }
+    return (timestamp - s.genesisTime) / s.slotSeconds
+}
+
+// SlotStart returns the Unix time at which slot begins
+func (s *SlotClock) SlotStart(slot uint64) uint64 {
+    return s.genesisTime + slot*s.slotSeconds
+}
+
+// CurrentSlot returns the slot the clock is in now
+func (s *SlotClock) CurrentSlot() uint64 {
+    return s.SlotAt(unixTime(s.clock.Now()))
+}
+
+// WaitForSlot blocks until slot has started. It returns false if stop was
+// closed first.
+func (s *SlotClock) WaitForSlot(slot uint64, stop <-chan struct{}) bool {
+    wait := time.Unix(int64(s.SlotStart(slot)), 0).Sub(s.clock.Now())
+    if wait <= 0 {
+        return true
+    }
+    started := make(chan struct{})
+    s.clock.AfterFunc(wait, func() { close(started) })
+    select {
+    case <-started:
+        return true
+    case <-stop:
+        return false
+    }
+}
+
+// slotSeconds returns the slot length in whole seconds
+func slotSeconds(blockTime time.Duration) uint64 {
+    if blockTime < time.Second {
+        blockTime = defaultBlockTime
+    }
+    return uint64(blockTime / time.Second)
+}
+
+// unixTime returns t in Unix seconds, clamped to zero
+func unixTime(t time.Time) uint64 {
+    if t.Unix() < 0 {
+        return 0
+    }
+    return uint64(t.Unix())
+}
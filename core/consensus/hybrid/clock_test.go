@@ -0,0 +1,146 @@
+package hybrid_test
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// advanceTo moves clock forward to the Unix second timestamp
+func advanceTo(t *testing.T, clock *consensus.SimulatedClock, timestamp uint64) {
+    t.Helper()
+    d := time.Unix(int64(timestamp), 0).Sub(clock.Now())
+    if d < 0 {
+        t.Fatalf("clock already %v past %d", -d, timestamp)
+    }
+    clock.Advance(d)
+}
+
+func TestSlotClock(t *testing.T) {
+    const genesisTime = 1735689600
+    clock := consensus.NewSimulatedClock(time.Unix(genesisTime+25, 0))
+    slots := hybrid.NewSlotClock(genesisTime, 12*time.Second, clock)
+
+    if slot := slots.CurrentSlot(); slot != 2 {
+        t.Fatalf("25s after genesis in slot %d, want 2", slot)
+    }
+    if start := slots.SlotStart(3); start != genesisTime+36 {
+        t.Fatalf("slot 3 starts at %d, want %d", start, genesisTime+36)
+    }
+    if slot := slots.SlotAt(genesisTime - 1); slot != 0 {
+        t.Fatalf("time before genesis in slot %d, want 0", slot)
+    }
+    if !slots.WaitForSlot(2, nil) {
+        t.Fatal("waiting for the current slot did not return at once")
+    }
+
+    stop := make(chan struct{})
+    close(stop)
+    if slots.WaitForSlot(3, stop) {
+        t.Fatal("stopped wait reported slot 3 as started")
+    }
+
+    // The waiter may read the clock just before an advance, so keep
+    // advancing until it wakes
+    started := make(chan bool)
+    go func() { started <- slots.WaitForSlot(3, nil) }()
+    timeout := time.After(10 * time.Second)
+    for {
+        select {
+        case ok := <-started:
+            if !ok || slots.CurrentSlot() < 3 {
+                t.Fatalf("wait for slot 3 returned in slot %d", slots.CurrentSlot())
+            }
+            return
+        case <-timeout:
+            t.Fatal("wait for slot 3 never returned")
+        case <-time.After(time.Millisecond):
+            clock.Advance(time.Second)
+        }
+    }
+}
+
+func TestPrepareWaitsForSlotBoundary(t *testing.T) {
+    tc := newTestChain(t, nil)
+    slot := uint64(tc.config.BlockTime / time.Second)
+
+    // Start from the beginning of a slot, so the parent is stamped with the
+    // current time
+    slots := hybrid.NewSlotClock(tc.GetCurrentBlock().Header.Time, tc.config.BlockTime, tc.clock)
+    advanceTo(t, tc.clock, slots.SlotStart(slots.CurrentSlot()+1))
+    parent := tc.addBlock(t)
+
+    // One second before the next slot the block cannot be produced yet
+    advanceTo(t, tc.clock, parent.Header.Time+slot-1)
+    head, statedb := tc.CurrentState()
+    if _, err := tc.engine.CreateBlock(tc, head, nil, types.Address{0xa}, statedb, nil); !errors.Is(err, hybrid.ErrSlotNotReached) {
+        t.Fatalf("block in the parent's slot: got %v, want %v", err, hybrid.ErrSlotNotReached)
+    }
+
+    // On the boundary it is stamped with the start of the slot, and so is
+    // it anywhere later in the same slot
+    for _, offset := range []uint64{0, slot - 1} {
+        advanceTo(t, tc.clock, parent.Header.Time+slot+offset)
+        block, err := tc.engine.CreateBlock(tc, head, nil, types.Address{0xa}, statedb, nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if block.Header.Time != parent.Header.Time+slot {
+            t.Fatalf("block produced %ds into the slot stamped %d, want %d", offset, block.Header.Time, parent.Header.Time+slot)
+        }
+    }
+}
+
+func TestVerifyHeaderRejectsOffSlot(t *testing.T) {
+    tc := newTestChain(t, nil)
+    parent := tc.GetCurrentBlock().Header
+    block := tc.makeBlock(t)
+    slot := uint64(tc.config.BlockTime / time.Second)
+    if err := tc.engine.VerifyHeader(tc, block.Header, parent); err != nil {
+        t.Fatalf("block on the slot grid rejected: %v", err)
+    }
+
+    header := *block.Header
+    for _, test := range []struct {
+        time uint64
+        want error
+    }{
+        {block.Header.Time + slot, nil}, // Empty slots may be skipped
+        {block.Header.Time + 1, hybrid.ErrInvalidSlot},
+        {block.Header.Time + slot - 1, hybrid.ErrInvalidSlot},
+        {parent.Time, hybrid.ErrBlockTimeTooEarly},
+        {parent.Time - slot, hybrid.ErrBlockTimeTooEarly},
+    } {
+        header.Time = test.time
+        if err := tc.engine.VerifyHeader(tc, &header, parent); !errors.Is(err, test.want) {
+            t.Errorf("block %ds after its parent: got %v, want %v", int64(test.time-parent.Time), err, test.want)
+        }
+    }
+}
+
+func TestVerifyProposalRejectsFutureBlock(t *testing.T) {
+    tc := newTestChain(t, nil)
+    block := tc.makeBlock(t)
+
+    // A node whose clock lags the proposer's accepts the proposal within the
+    // allowed drift of 5s, and refuses it beyond
+    for _, test := range []struct {
+        lag  time.Duration
+        want error
+    }{
+        {0, nil},
+        {5 * time.Second, nil},
+        {6 * time.Second, hybrid.ErrSlotNotReached},
+        {tc.config.BlockTime, hybrid.ErrSlotNotReached},
+    } {
+        verifier := hybrid.NewHybridEngine(tc.config)
+        verifier.SetClock(consensus.NewSimulatedClock(time.Unix(int64(block.Header.Time), 0).Add(-test.lag)))
+        if err := verifier.VerifyProposal(tc, block); !errors.Is(err, test.want) {
+            t.Errorf("proposal %v ahead: got %v, want %v", test.lag, err, test.want)
+        }
+    }
+}
@@ -1,32 +1,104 @@
 package hybrid
 
 import (
+    "crypto/sha256"
+    "encoding/json"
     "math/big"
     "time"
+    "github.com/selsichain/selsichain-core/core/forks"
     "github.com/selsichain/selsichain-core/core/types"
 )
 
 type Config struct {
     // PoW Configuration
-    PowBlockInterval   uint64        // Setiap 100 block
-    MiningDifficulty   *big.Int      // Difficulty target
-    PowReward          *big.Int      // Reward untuk miner
+    PowBlockInterval   uint64        `json:"powBlockInterval"`    // Setiap 100 block
+    MiningDifficulty   *big.Int      `json:"miningDifficulty"`    // Initial difficulty, retargeted from chain history
+    PowReward          *big.Int      `json:"powReward,omitempty"` // Reward untuk miner
+    DifficultyWindow   uint64        `json:"difficultyWindow,omitempty"` // Checkpoints averaged when retargeting (default 12)
+    PowAlgorithm       string        `json:"powAlgorithm,omitempty"`     // sha256 (default), scrypt or argon2id
     
     // PoS Configuration  
-    MinimumStake       *big.Int      // Minimum stake required
-    StakingPeriod      time.Duration // Lock period
-    PosReward          *big.Int      // Reward untuk staker
+    MinimumStake       *big.Int      `json:"minimumStake"`        // Minimum stake required
+    StakingPeriod      time.Duration `json:"stakingPeriod"`       // Lock period (nanoseconds)
+    PosReward          *big.Int      `json:"posReward,omitempty"` // Reward untuk staker
+    MaxValidators      uint64        `json:"maxValidators,omitempty"` // Largest active validator set (default 100)
+    Slashing           SlashingConfig `json:"slashing"`
     
     // Hybrid Configuration
-    BlockTime          time.Duration // 12 detik
-    RewardDistribution RewardConfig
+    BlockTime          time.Duration `json:"blockTime"`           // 12 detik (nanoseconds)
+    Emission           EmissionConfig `json:"emission"`
+    RewardDistribution RewardConfig  `json:"rewardDistribution"`
+    Treasury           types.Address `json:"treasury"`            // Receives the ecosystem share; burned if zero
+    
+    // Protocol upgrades and the heights they activate at
+    Upgrades           forks.Schedule `json:"upgrades,omitempty"`
+    // Parameters changed at later heights, in ascending order (see schedule.go)
+    Overrides          []ConfigOverride `json:"overrides,omitempty"`
+}
+
+// ConfigOverride changes consensus parameters from block Number on. Every
+// field that is set replaces the value in force before it; a new
+// PowBlockInterval must start at a checkpoint of the previous interval.
+type ConfigOverride struct {
+    Number             uint64          `json:"number"`
+    PowBlockInterval   uint64          `json:"powBlockInterval,omitempty"`
+    Emission           *EmissionConfig `json:"emission,omitempty"`
+    RewardDistribution *RewardConfig   `json:"rewardDistribution,omitempty"`
+    Slashing           *SlashingConfig `json:"slashing,omitempty"`
+}
+
+// IsActive reports whether the protocol upgrade fork is active in the block
+// at number
+func (c *Config) IsActive(fork string, number *big.Int) bool {
+    return c.Upgrades.IsActive(fork, number)
+}
+
+// Digest returns the hash of the consensus parameters the chain starts with.
+// Upgrades and Overrides are left out: they may be rescheduled above the
+// head and enter the fork ID as heights (see ForkHeights).
+func (c *Config) Digest() types.Hash {
+    base := *c
+    base.Upgrades, base.Overrides = nil, nil
+    blob, err := json.Marshal(&base)
+    if err != nil {
+        panic(err) // Plain values and big.Ints always marshal
+    }
+    return sha256.Sum256(blob)
+}
+
+// EmissionConfig sets how many new SELSI each block mints; zero values use
+// the defaults. The emission plus the block's fees are shared out according
+// to RewardDistribution.
+type EmissionConfig struct {
+    InitialReward     *big.Int `json:"initialReward,omitempty"`     // Minted by the first blocks (default 100 SELSI)
+    Curve             string   `json:"curve,omitempty"`             // halving (default) or decay
+    ReductionInterval uint64   `json:"reductionInterval,omitempty"` // Blocks between reductions (default 10512000, ~4 years)
+    DecayPercent      int      `json:"decayPercent,omitempty"`      // Cut per reduction with the decay curve (default 10%)
+    SupplyCap         *big.Int `json:"supplyCap,omitempty"`         // Most SELSI ever minted on top of genesis (nil: no cap)
 }
 
 type RewardConfig struct {
-    MinerPercent     int // 45%
-    StakerPercent    int // 45%
-    EcosystemPercent int // 7%
-    BurnPercent      int // 3%
+    MinerPercent     int `json:"minerPercent"`     // 45%
+    StakerPercent    int `json:"stakerPercent"`    // 45%
+    EcosystemPercent int `json:"ecosystemPercent"` // 7%
+    BurnPercent      int `json:"burnPercent"`      // 3%
+
+    // Share of the staker share the proposer gets up front; the rest goes to
+    // the validators whose precommits the block carries, by stake
+    ProposerBonusPercent int `json:"proposerBonusPercent,omitempty"` // 10%
+}
+
+// SlashingConfig sets the penalties for misbehaving validators; zero values
+// use the defaults
+type SlashingConfig struct {
+    DoubleSignPercent    int    `json:"doubleSignPercent,omitempty"`    // Stake slashed for equivocation (default 5%)
+    DoubleSignJailBlocks uint64 `json:"doubleSignJailBlocks,omitempty"` // Blocks before an equivocator may unjail (default 100000)
+    WhistleblowerPercent int    `json:"whistleblowerPercent,omitempty"` // Share of the slashed stake paid to the reporter (default 10%)
+    EvidenceMaxAge       uint64 `json:"evidenceMaxAge,omitempty"`       // Blocks after which evidence expires (default 10000)
+    SignedBlocksWindow   uint64 `json:"signedBlocksWindow,omitempty"`   // PoS blocks in the liveness window (default 100)
+    MinSignedPercent     int    `json:"minSignedPercent,omitempty"`     // Share of the window a validator must sign (default 50%)
+    DowntimeSlashPercent int    `json:"downtimeSlashPercent,omitempty"` // Stake slashed when jailed for downtime (default 1%)
+    DowntimeJailBlocks   uint64 `json:"downtimeJailBlocks,omitempty"`   // Blocks before an offline validator may unjail (default 600)
 }
 
 type Validator struct {
@@ -0,0 +1,85 @@
+package hybrid
+
+import (
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+const (
+    defaultDifficultyWindow = 12 // Checkpoint solve times averaged by LWMA
+    maxSolveTimeFactor      = 6  // Solve times are clamped to 6x the target
+    maxDifficultyStep       = 2  // Difficulty at most doubles or halves per checkpoint
+)
+
+// ChainHeaderReader gives the engine access to the headers of the chain a
+// block builds on, including non-canonical branches
+type ChainHeaderReader = consensus.ChainHeaderReader
+
+// CalcDifficulty returns the difficulty a checkpoint built on parent must
+// carry. It is a linearly weighted moving average (LWMA) over the solve
+// times of the last DifficultyWindow checkpoints, where a solve time is the
+// time between two consecutive checkpoints and the target is
+// PowBlockInterval (in force at the new checkpoint) * BlockTime. Recent
+// solve times weigh the most, and the result stays within a factor of two
+// of the latest checkpoint. Until two checkpoints exist the configured
+// MiningDifficulty is used.
+func (h *HybridEngine) CalcDifficulty(chain ChainHeaderReader, parent *types.Header) *big.Int {
+    window := h.config.DifficultyWindow
+    if window == 0 {
+        window = defaultDifficultyWindow
+    }
+    target := int64(h.config.PowBlockIntervalAt(parent.Number.Uint64()+1)) * int64(h.config.BlockTime.Seconds())
+    if target <= 0 {
+        target = 1
+    }
+
+    // Checkpoints ending at or below parent, newest first
+    var checkpoints []*types.Header
+    for header := parent; header != nil && header.Number.Sign() > 0; header = chain.GetHeader(header.ParentHash) {
+        if h.isCheckpointBlock(header.Number) {
+            checkpoints = append(checkpoints, header)
+            if uint64(len(checkpoints)) > window {
+                break
+            }
+        }
+    }
+    if len(checkpoints) < 2 {
+        return new(big.Int).Set(h.config.MiningDifficulty)
+    }
+
+    // Oldest first: solve time i is checkpoints[i].Time - checkpoints[i-1].Time
+    n := int64(len(checkpoints) - 1)
+    sumDifficulty := new(big.Int)
+    var weightedTime int64
+    for i := int64(1); i <= n; i++ {
+        older := checkpoints[n-i+1]
+        newer := checkpoints[n-i]
+
+        solveTime := int64(newer.Time) - int64(older.Time)
+        if solveTime < 1 {
+            solveTime = 1
+        }
+        if solveTime > maxSolveTimeFactor*target {
+            solveTime = maxSolveTimeFactor * target
+        }
+        weightedTime += i * solveTime
+        sumDifficulty.Add(sumDifficulty, newer.Difficulty)
+    }
+
+    // next = sum(D) * T * (n+1) / (2 * sum(i * solveTime_i))
+    next := new(big.Int).Mul(sumDifficulty, big.NewInt(target*(n+1)))
+    next.Div(next, big.NewInt(2*weightedTime))
+
+    latest := checkpoints[0].Difficulty
+    if upper := new(big.Int).Mul(latest, big.NewInt(maxDifficultyStep)); next.Cmp(upper) > 0 {
+        next = upper
+    }
+    if lower := new(big.Int).Div(latest, big.NewInt(maxDifficultyStep)); next.Cmp(lower) < 0 {
+        next = lower
+    }
+    if next.Sign() <= 0 {
+        next.SetInt64(1)
+    }
+    return next
+}
@@ -0,0 +1,104 @@
+package hybrid
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+)
+
+// Emission curves selectable with Config.Emission.Curve
+const (
+    EmissionHalving = "halving" // The reward halves every ReductionInterval blocks
+    EmissionDecay   = "decay"   // The reward shrinks by DecayPercent every ReductionInterval blocks
+)
+
+const (
+    defaultReductionInterval = 10512000 // ~4 years of 12s blocks
+    defaultDecayPercent      = 10
+)
+
+// EmissionCurves lists the supported emission curves
+var EmissionCurves = []string{EmissionHalving, EmissionDecay}
+
+var ErrUnknownEmissionCurve = errors.New("unknown emission curve")
+
+// ValidateEmission checks an emission configuration for values the chain
+// cannot run with
+func ValidateEmission(config EmissionConfig) error {
+    switch config.Curve {
+    case "", EmissionHalving, EmissionDecay:
+    default:
+        return fmt.Errorf("%w: %q (supported: %v)", ErrUnknownEmissionCurve, config.Curve, EmissionCurves)
+    }
+    if config.InitialReward != nil && config.InitialReward.Sign() < 0 {
+        return fmt.Errorf("emission initialReward must not be negative")
+    }
+    if config.DecayPercent < 0 || config.DecayPercent > 100 {
+        return fmt.Errorf("emission decayPercent must be between 0 and 100")
+    }
+    if config.SupplyCap != nil && config.SupplyCap.Sign() < 0 {
+        return fmt.Errorf("emission supplyCap must not be negative")
+    }
+    return nil
+}
+
+// BlockEmission returns the SELSI the emission curve in force at block
+// number schedules for it, before the supply cap is applied. Reductions
+// count from genesis, also for a curve overridden at a later height.
+func (h *HybridEngine) BlockEmission(number uint64) *big.Int {
+    emission := h.config.EmissionAt(number)
+    reward := selsiWei(100)
+    if emission.InitialReward != nil {
+        reward = new(big.Int).Set(emission.InitialReward)
+    }
+    interval := emission.ReductionInterval
+    if interval == 0 {
+        interval = defaultReductionInterval
+    }
+    reductions := number / interval
+
+    if emission.Curve == EmissionDecay {
+        keep := big.NewInt(int64(100 - decayPercent(emission)))
+        // Every step shrinks a positive reward, so this ends well before
+        // reductions gets large
+        for ; reductions > 0 && reward.Sign() > 0; reductions-- {
+            reward.Mul(reward, keep)
+            reward.Div(reward, big.NewInt(100))
+        }
+        return reward
+    }
+    if reductions >= uint64(reward.BitLen()) {
+        return big.NewInt(0)
+    }
+    return reward.Rsh(reward, uint(reductions))
+}
+
+// emission returns what block number mints when emitted SELSI were minted
+// before it: the scheduled emission, cut so the total stays within the
+// supply cap
+func (h *HybridEngine) emission(number uint64, emitted *big.Int) *big.Int {
+    reward := h.BlockEmission(number)
+    if supplyCap := h.config.EmissionAt(number).SupplyCap; supplyCap != nil {
+        left := new(big.Int).Sub(supplyCap, emitted)
+        if left.Sign() < 0 {
+            left.SetInt64(0)
+        }
+        if reward.Cmp(left) > 0 {
+            reward = left
+        }
+    }
+    return reward
+}
+
+// decayPercent returns the reward cut per reduction of the decay curve
+func decayPercent(emission EmissionConfig) int {
+    if emission.DecayPercent == 0 {
+        return defaultDecayPercent
+    }
+    return emission.DecayPercent
+}
+
+// selsiWei converts whole SELSI to wei
+func selsiWei(amount int64) *big.Int {
+    return new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+}
@@ -3,145 +3,521 @@ package hybrid
 import (
     "fmt"
     "math/big"
-    "time"
+    "sync"
+    "github.com/selsichain/selsichain-core/core/forks"
     "github.com/selsichain/selsichain-core/core/types"
     "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/crypto/hash"
 )
 
+// MaxExtraDataSize is the most extra data a header may carry once
+// forks.ExtraDataLimit is active
+const MaxExtraDataSize = 32
+
 type HybridEngine struct {
     config    *Config
     powEngine *POWEngine
     posEngine *POSEngine
+    processor *state.Processor
+    clock     Clock
+
+    snapshots     map[types.Hash]*ValidatorSet // Validator sets by epoch start block
+    snapshotOrder []types.Hash
+    snapshotMu    sync.Mutex
 }
 
 func NewHybridEngine(config *Config) *HybridEngine {
-    return &HybridEngine{
+    h := &HybridEngine{
         config:    config,
         powEngine: NewPOWEngine(config),
         posEngine: NewPOSEngine(config),
+        processor: state.NewProcessor(config.Upgrades),
+        clock:     SystemClock{},
+        snapshots: make(map[types.Hash]*ValidatorSet),
+    }
+    h.processor.RegisterHandler(types.TxEvidence, h.applyEvidence)
+    h.processor.RegisterHandler(types.TxUnjail, h.applyUnjail)
+    return h
+}
+
+// VerifyHeader checks header against its parent: the block must be stamped
+// with the start of a slot after its parent's, and checkpoint headers must
+// carry the retargeted difficulty, a valid proof of work and the parent's
+// RANDAO mix
+func (h *HybridEngine) VerifyHeader(chain ChainHeaderReader, header *types.Header, parent *types.Header) error {
+    if header.Time <= parent.Time {
+        return fmt.Errorf("%w: %d, parent %d", ErrBlockTimeTooEarly, header.Time, parent.Time)
+    }
+    // Genesis opens slot 0, so slots a whole number of BlockTimes apart keep
+    // every block on the genesis slot grid
+    if slot := h.slotSeconds(); (header.Time-parent.Time)%slot != 0 {
+        return fmt.Errorf("%w: %d is %ds into a slot", ErrInvalidSlot, header.Time, (header.Time-parent.Time)%slot)
+    }
+    if h.config.IsActive(forks.ExtraDataLimit, header.Number) && len(header.Extra) > MaxExtraDataSize {
+        return fmt.Errorf("%w: %d bytes, limit %d", ErrExtraDataTooLong, len(header.Extra), MaxExtraDataSize)
+    }
+    if header.Checkpoint != h.isCheckpointBlock(header.Number) {
+        return fmt.Errorf("%w: block #%s", ErrInvalidCheckpoint, header.Number)
     }
+    if header.Checkpoint {
+        if header.Round != 0 {
+            return fmt.Errorf("%w: checkpoint #%s has agreement round %d", ErrInvalidCheckpoint, header.Number, header.Round)
+        }
+        // Checkpoints carry the randomness of their parent forward
+        if header.MixDigest != parent.MixDigest {
+            return fmt.Errorf("%w: checkpoint #%s changed the mix", ErrInvalidRandao, header.Number)
+        }
+        if len(header.RandaoReveal) > 0 {
+            return fmt.Errorf("%w: checkpoint #%s has a reveal", ErrInvalidRandao, header.Number)
+        }
+        if len(header.Seal) > 0 {
+            return fmt.Errorf("%w: checkpoint #%s carries a PoS seal", ErrInvalidCheckpoint, header.Number)
+        }
+        return h.powEngine.VerifyHeader(header, h.CalcDifficulty(chain, parent))
+    }
+    return nil
+}
+
+// SetMiningThreads sets the number of goroutines used to mine checkpoints
+func (h *HybridEngine) SetMiningThreads(n int) {
+    h.powEngine.SetThreads(n)
 }
 
-// VerifyBlock implements hybrid verification
-func (h *HybridEngine) VerifyBlock(block *types.Block, state *state.StateDB) error {
+// VerifyBody implements hybrid verification. PoS blocks are checked against
+// the validator set of their epoch and must carry a commit certificate.
+func (h *HybridEngine) VerifyBody(chain ChainReader, block *types.Block, state *state.StateDB) error {
     fmt.Printf("\n🔍 Verifying Block #%s...\n", block.Header.Number)
     
+    parent := chain.GetHeader(block.Header.ParentHash)
+    if parent == nil {
+        return fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, block.Header.ParentHash[:4], block.Header.Number)
+    }
+    if err := h.verifyLastCommit(chain, block, parent); err != nil {
+        return err
+    }
+    
     if h.isCheckpointBlock(block.Header.Number) {
         fmt.Printf("⛏️  Using PoW Consensus (Checkpoint Block)\n")
-        if state != nil {
-            return h.powEngine.VerifyBlock(block, state)
-        }
-        return nil // Skip verification if no state for demo
+        return h.powEngine.VerifyBlock(block, h.CalcDifficulty(chain, parent))
     } else {
         fmt.Printf("🎯 Using PoS Consensus (Regular Block)\n")
-        if state != nil {
-            return h.posEngine.VerifyBlock(block, state)
+        validators, err := h.Validators(chain, parent)
+        if err != nil {
+            return err
+        }
+        if err := h.posEngine.VerifyBlock(block, parent, validators); err != nil {
+            return err
         }
-        return nil // Skip verification if no state for demo
+        return h.posEngine.verifyCommit(block, validators)
     }
 }
 
-// PrepareBlock prepares block based on consensus type
-func (h *HybridEngine) PrepareBlock(header *types.Header, txs []*types.Transaction) (*types.Block, error) {
-    block := &types.Block{
-        Header:       header,
-        Transactions: txs,
-        Votes:        []*types.Vote{},
+// VerifyProposal fully checks a PoS block offered for agreement, which does
+// not carry a commit certificate yet: its header against the parent and the
+// epoch's validator set, and its transactions and state root by executing it
+func (h *HybridEngine) VerifyProposal(chain ChainReader, block *types.Block) error {
+    if h.isCheckpointBlock(block.Header.Number) {
+        return fmt.Errorf("%w: block #%s is a PoW checkpoint", ErrInvalidProposal, block.Header.Number)
+    }
+    parent := chain.GetHeader(block.Header.ParentHash)
+    if parent == nil {
+        return fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, block.Header.ParentHash[:4], block.Header.Number)
+    }
+    if block.Header.Number.Uint64() != parent.Number.Uint64()+1 {
+        return fmt.Errorf("%w: block #%s on parent #%s", ErrInvalidBlockNumber, block.Header.Number, parent.Number)
+    }
+    if err := h.VerifyHeader(chain, block.Header, parent); err != nil {
+        return err
+    }
+    if now := unixTime(h.clock.Now().Add(allowedClockDrift)); block.Header.Time > now {
+        return fmt.Errorf("%w: proposal stamped %d, local time %d", ErrSlotNotReached, block.Header.Time, unixTime(h.clock.Now()))
+    }
+    if err := h.verifyLastCommit(chain, block, parent); err != nil {
+        return err
+    }
+    validators, err := h.Validators(chain, parent)
+    if err != nil {
+        return err
+    }
+    if err := h.posEngine.VerifyBlock(block, parent, validators); err != nil {
+        return err
+    }
+    
+    statedb, err := chain.StateAt(block.Header.ParentHash)
+    if err != nil {
+        return err
+    }
+    if _, err := h.Finalize(chain, block, statedb); err != nil {
+        return err
+    }
+    if txHash := hash.CalculateTxRoot(block.Transactions); txHash != block.Header.TxHash {
+        return fmt.Errorf("%w: tx root %x, want %x", ErrInvalidProposal, block.Header.TxHash[:4], txHash[:4])
+    }
+    if root := statedb.Root(); root != block.Header.Root {
+        return fmt.Errorf("%w: state root %x, want %x", ErrInvalidProposal, block.Header.Root[:4], root[:4])
+    }
+    return nil
+}
+
+// Author returns the miner of a checkpoint or the validator of a PoS block
+func (h *HybridEngine) Author(header *types.Header) (types.Address, error) {
+    if h.isPosBlock(header.Number) {
+        return header.Validator, nil
+    }
+    return header.Coinbase, nil
+}
+
+// Prepare fills the consensus fields of header based on consensus type: the
+// start of the current slot as its time, its difficulty and, for a PoS
+// block, the proposer of header.Round with its RANDAO reveal. It fails with
+// ErrSlotNotReached while the clock is still in the parent's slot, and with
+// ErrNotProposer unless this node holds the key of the proposer.
+func (h *HybridEngine) Prepare(chain ChainReader, header *types.Header) error {
+    parent := chain.GetHeader(header.ParentHash)
+    if parent == nil {
+        return fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, header.ParentHash[:4], header.Number)
+    }
+    timestamp, err := h.slotTime(parent)
+    if err != nil {
+        return err
     }
+    header.Time = timestamp
+    header.MixDigest = parent.MixDigest
+    block := &types.Block{Header: header}
     
     if h.isCheckpointBlock(header.Number) {
         fmt.Printf("\n⛏️  Preparing PoW Checkpoint Block #%s\n", header.Number)
-        return h.powEngine.PrepareBlock(block)
-    } else {
-        fmt.Printf("\n🎯 Preparing PoS Regular Block #%s\n", header.Number)
-        return h.posEngine.PrepareBlock(block)
+        header.Difficulty = h.CalcDifficulty(chain, parent)
+        header.Round = 0
+        _, err = h.powEngine.PrepareBlock(block)
+        return err
+    }
+    fmt.Printf("\n🎯 Preparing PoS Regular Block #%s\n", header.Number)
+    header.Difficulty = new(big.Int).Set(h.config.MiningDifficulty)
+    validators, err := h.Validators(chain, parent)
+    if err != nil {
+        return err
+    }
+    _, err = h.posEngine.PrepareBlock(block, parent, validators)
+    return err
+}
+
+// Seal mines a checkpoint, or signs a PoS block with the key of its
+// validator. Closing stop aborts mining with ErrMiningAborted.
+func (h *HybridEngine) Seal(chain ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+    if h.isCheckpointBlock(block.Header.Number) {
+        fmt.Printf("⛏️  Mining PoW Checkpoint Block #%s\n", block.Header.Number)
+        return h.powEngine.MineBlock(block, stop)
     }
+    fmt.Printf("🎯 Finalizing PoS Regular Block #%s\n", block.Header.Number)
+    // For PoS, the proposer signs the block it offers for agreement
+    return h.posEngine.Seal(block)
 }
 
-// CreateBlock creates and mines/stakes a new block
-func (h *HybridEngine) CreateBlock(parent *types.Block, txs []*types.Transaction, miner types.Address) (*types.Block, error) {
+// CreateBlock creates and mines/stakes a new block on top of parent.
+// parentState is the post-state of parent; it is not modified. Closing stop
+// aborts mining a checkpoint with ErrMiningAborted. PoS blocks are proposed
+// for round 0 and still need a commit certificate from the agreement
+// protocol before the chain accepts them.
+func (h *HybridEngine) CreateBlock(chain ChainReader, parent *types.Block, txs []*types.Transaction, miner types.Address, parentState *state.StateDB, stop <-chan struct{}) (*types.Block, error) {
+    return h.createBlock(chain, parent, 0, txs, miner, parentState, stop)
+}
+
+// CreateProposal builds and seals the PoS block on top of parent for an
+// agreement round
+func (h *HybridEngine) CreateProposal(chain ChainReader, parent *types.Block, round uint32, txs []*types.Transaction, miner types.Address, parentState *state.StateDB) (*types.Block, error) {
+    if number := new(big.Int).Add(parent.Header.Number, big.NewInt(1)); h.isCheckpointBlock(number) {
+        return nil, fmt.Errorf("%w: block #%s is a PoW checkpoint", ErrInvalidProposal, number)
+    }
+    return h.createBlock(chain, parent, round, txs, miner, parentState, nil)
+}
+
+// createBlock implements CreateBlock for a given agreement round with
+// Prepare, Finalize and Seal. The block is stamped with the start of the
+// current slot, which must come after the parent's.
+func (h *HybridEngine) createBlock(chain ChainReader, parent *types.Block, round uint32, txs []*types.Transaction, miner types.Address, parentState *state.StateDB, stop <-chan struct{}) (*types.Block, error) {
     // Create new header dengan number yang benar
-    newNumber := new(big.Int).Add(parent.Header.Number, big.NewInt(1))
     header := &types.Header{
         ParentHash: h.calculateBlockHash(parent),
-        Number:     newNumber,
-        Time:       uint64(time.Now().Unix()), // Set current time
-        Difficulty: h.config.MiningDifficulty,
+        Number:     new(big.Int).Add(parent.Header.Number, big.NewInt(1)),
         Coinbase:   miner,
+        Round:      round,
     }
     
-    // Prepare block
-    block, err := h.PrepareBlock(header, txs)
-    if err != nil {
+    // Carry the parent's commit certificate, so who signed it is part of
+    // this block's state transition
+    var lastCommit []*types.Vote
+    if h.isPosBlock(parent.Header.Number) {
+        lastCommit = parent.Votes
+        header.LastCommitHash = hash.CalculateCommitHash(lastCommit)
+    }
+    if err := h.Prepare(chain, header); err != nil {
+        return nil, err
+    }
+    block := &types.Block{
+        Header:       header,
+        Transactions: txs,
+        Votes:        []*types.Vote{},
+        LastCommit:   lastCommit,
+    }
+    
+    // Execute on a copy so the header commits to the post-state
+    statedb := parentState.Copy()
+    if _, err := h.Finalize(chain, block, statedb); err != nil {
         return nil, err
     }
+    block.Header.TxHash = hash.CalculateTxRoot(block.Transactions)
+    block.Header.Root = statedb.Root()
     
     // Mine or validate based on consensus type
-    if h.isCheckpointBlock(newNumber) {
-        fmt.Printf("⛏️  Mining PoW Checkpoint Block #%s\n", newNumber)
-        return h.powEngine.MineBlock(block)
-    } else {
-        fmt.Printf("🎯 Finalizing PoS Regular Block #%s\n", newNumber)
-        // For PoS, block is already prepared with votes
-        return block, nil
+    return h.Seal(chain, block, stop)
+}
+
+// Finalize runs the state transition of block on statedb: it executes the
+// transactions, records the liveness of the validators that were to sign a
+// PoS parent, mints the block's emission and pays it out with the fees. It
+// returns how the block reward was paid out.
+func (h *HybridEngine) Finalize(chain ChainReader, block *types.Block, statedb *state.StateDB) (*types.RewardBreakdown, error) {
+    if err := h.verifyOffenders(chain, block); err != nil {
+        return nil, err
+    }
+    result, err := h.processor.Process(block, statedb)
+    if err != nil {
+        return nil, err
     }
+    if len(block.Transactions) > 0 {
+        fmt.Printf("📝 Executed %d transactions (gas used: %d, fees: %s)\n", 
+            len(block.Transactions), result.GasUsed, result.Fees)
+    }
+    
+    parent := chain.GetHeader(block.Header.ParentHash)
+    if parent == nil {
+        return nil, fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, block.Header.ParentHash[:4], block.Header.Number)
+    }
+    var validators *ValidatorSet
+    if h.isPosBlock(parent.Number) {
+        // A PoS parent is in the same epoch, so it had the same validator set
+        validators, err = h.Validators(chain, parent)
+        if err != nil {
+            return nil, err
+        }
+        h.trackLiveness(block, parent, statedb, validators)
+    }
+    
+    // Mint the emission and pay it out with the fees
+    emission := h.emission(block.Header.Number.Uint64(), statedb.Emitted())
+    statedb.AddEmitted(emission)
+    rewards := h.CalculateRewards(block, validators, emission, result.Fees)
+    for _, payout := range rewards.Payouts {
+        statedb.AddBalance(payout.Address, payout.Amount)
+        fmt.Printf("💰 Rewarded %x: +%s SELSI (%s)\n", payout.Address[:4], payout.Amount, payout.Role)
+    }
+    statedb.AddBurned(rewards.Burned)
+    return rewards, nil
 }
 
-// CalculateRewards calculates hybrid reward distribution
-func (h *HybridEngine) CalculateRewards(block *types.Block, state *state.StateDB) map[types.Address]*big.Int {
-    totalReward := h.getBlockReward(block.Header.Number)
-    rewards := make(map[types.Address]*big.Int)
+// CalculateRewards shares out the reward pool of a block, its emission plus
+// its fees, according to RewardDistribution:
+//
+//   - checkpoints pay the miner share to the coinbase, PoS blocks pay the
+//     staker share to the proposer and the voters (see stakerRewards); the
+//     share of the other kind of producer is burned
+//   - the ecosystem share goes to the treasury, or is burned without one
+//   - the burn share is burned
+//
+// What integer division leaves over goes to the block's producer.
+// validators is the validator set that signed the block's LastCommit, nil if
+// it has none.
+func (h *HybridEngine) CalculateRewards(block *types.Block, validators *ValidatorSet, emission *big.Int, fees *big.Int) *types.RewardBreakdown {
+    unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+    pool := new(big.Int).Add(emission, fees)
+    rewards := &types.RewardBreakdown{
+        Number:   block.Header.Number.Uint64(),
+        Emission: new(big.Int).Set(emission),
+        Fees:     new(big.Int).Set(fees),
+    }
     
     fmt.Printf("\n💰 Calculating Rewards for Block #%s\n", block.Header.Number)
-    fmt.Printf("💰 Total Reward: %s SELSI\n", new(big.Int).Div(totalReward, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+    fmt.Printf("💰 Total Reward: %s SELSI (emission %s + fees %s wei)\n", new(big.Int).Div(pool, unit), new(big.Int).Div(emission, unit), fees)
     
     // Calculate percentages
-    minerReward := new(big.Int).Div(new(big.Int).Mul(totalReward, big.NewInt(int64(h.config.RewardDistribution.MinerPercent))), big.NewInt(100))
-    stakerReward := new(big.Int).Div(new(big.Int).Mul(totalReward, big.NewInt(int64(h.config.RewardDistribution.StakerPercent))), big.NewInt(100))
-    ecosystemReward := new(big.Int).Div(new(big.Int).Mul(totalReward, big.NewInt(int64(h.config.RewardDistribution.EcosystemPercent))), big.NewInt(100))
-    burnReward := new(big.Int).Div(new(big.Int).Mul(totalReward, big.NewInt(int64(h.config.RewardDistribution.BurnPercent))), big.NewInt(100))
+    share := func(percent int) *big.Int {
+        return new(big.Int).Div(new(big.Int).Mul(pool, big.NewInt(int64(percent))), big.NewInt(100))
+    }
+    distribution := h.config.RewardDistributionAt(block.Header.Number.Uint64())
+    minerReward := share(distribution.MinerPercent)
+    stakerReward := share(distribution.StakerPercent)
+    ecosystemReward := share(distribution.EcosystemPercent)
+    burnReward := share(distribution.BurnPercent)
+    remainder := new(big.Int).Sub(pool, minerReward)
+    remainder.Sub(remainder, stakerReward).Sub(remainder, ecosystemReward).Sub(remainder, burnReward)
     
     if h.isCheckpointBlock(block.Header.Number) {
         // PoW block - reward goes to miner
-        rewards[block.Header.Coinbase] = minerReward
-        fmt.Printf("💰 Miner %x gets: %s SELSI\n", block.Header.Coinbase[:4], 
-            new(big.Int).Div(minerReward, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+        burnReward.Add(burnReward, stakerReward)
+        rewards.Payouts = append(rewards.Payouts, types.RewardPayout{
+            Role:    types.RewardMiner,
+            Address: block.Header.Coinbase,
+            Amount:  new(big.Int).Add(minerReward, remainder),
+        })
+        fmt.Printf("💰 Miner %x gets: %s SELSI\n", block.Header.Coinbase[:4], new(big.Int).Div(minerReward, unit))
     } else {
-        // PoS block - reward goes to validator
-        rewards[block.Header.Validator] = stakerReward
-        fmt.Printf("💰 Validator %x gets: %s SELSI\n", block.Header.Validator[:4],
-            new(big.Int).Div(stakerReward, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+        // PoS block - reward goes to the proposer and the voters
+        burnReward.Add(burnReward, minerReward)
+        rewards.Payouts = append(rewards.Payouts, h.stakerRewards(block, validators, stakerReward, remainder)...)
     }
     
     // Ecosystem fund
-    ecosystemAddress := types.Address{0xFF} // Mock ecosystem address
-    rewards[ecosystemAddress] = ecosystemReward
-    fmt.Printf("💰 Ecosystem gets: %s SELSI\n", 
-        new(big.Int).Div(ecosystemReward, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+    if h.config.Treasury == (types.Address{}) {
+        burnReward.Add(burnReward, ecosystemReward)
+    } else {
+        rewards.Payouts = append(rewards.Payouts, types.RewardPayout{
+            Role:    types.RewardTreasury,
+            Address: h.config.Treasury,
+            Amount:  ecosystemReward,
+        })
+        fmt.Printf("💰 Treasury %x gets: %s SELSI\n", h.config.Treasury[:4], new(big.Int).Div(ecosystemReward, unit))
+    }
     
     // Burn mechanism
-    fmt.Printf("🔥 Burned: %s SELSI\n", 
-        new(big.Int).Div(burnReward, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+    rewards.Burned = burnReward
+    fmt.Printf("🔥 Burned: %s SELSI\n", new(big.Int).Div(burnReward, unit))
     
     fmt.Printf("💰 Reward distribution completed!\n")
     
     return rewards
 }
 
-func (h *HybridEngine) isCheckpointBlock(blockNumber *big.Int) bool {
-    // Exclude block 0 (genesis) and check every 5 blocks
-    return blockNumber.Cmp(big.NewInt(0)) != 0 && 
-           new(big.Int).Mod(blockNumber, big.NewInt(int64(h.config.PowBlockInterval))).Cmp(big.NewInt(0)) == 0
+// stakerRewards splits the staker share of a PoS block. The proposer gets
+// ProposerBonusPercent of it plus extra, and the rest is shared by stake
+// among the validators whose precommits are in block.LastCommit. The
+// proposer keeps what rounding leaves, and everything when the block
+// carries no votes.
+func (h *HybridEngine) stakerRewards(block *types.Block, validators *ValidatorSet, stakerReward *big.Int, extra *big.Int) []types.RewardPayout {
+    unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+    bonusPercent := h.config.RewardDistributionAt(block.Header.Number.Uint64()).ProposerBonusPercent
+    bonus := new(big.Int).Mul(stakerReward, big.NewInt(int64(bonusPercent)))
+    bonus.Div(bonus, big.NewInt(100))
+    pool := new(big.Int).Sub(stakerReward, bonus)
+    
+    voterStake := big.NewInt(0)
+    if validators != nil {
+        for _, vote := range block.LastCommit {
+            voterStake.Add(voterStake, validators.StakeOf(vote.Validator))
+        }
+    }
+    
+    var payouts []types.RewardPayout
+    left := new(big.Int).Set(pool)
+    if voterStake.Sign() > 0 {
+        for _, vote := range block.LastCommit {
+            reward := new(big.Int).Mul(pool, validators.StakeOf(vote.Validator))
+            reward.Div(reward, voterStake)
+            payouts = append(payouts, types.RewardPayout{
+                Role:    types.RewardVoter,
+                Address: vote.Validator,
+                Amount:  reward,
+            })
+            left.Sub(left, reward)
+        }
+        fmt.Printf("💰 %d voters share: %s SELSI\n", len(block.LastCommit), new(big.Int).Div(new(big.Int).Sub(pool, left), unit))
+    }
+    
+    proposerReward := new(big.Int).Add(bonus, left)
+    proposerReward.Add(proposerReward, extra)
+    fmt.Printf("💰 Proposer %x gets: %s SELSI (bonus %s)\n", block.Header.Validator[:4], new(big.Int).Div(proposerReward, unit), new(big.Int).Div(bonus, unit))
+    return append([]types.RewardPayout{{
+        Role:    types.RewardProposer,
+        Address: block.Header.Validator,
+        Amount:  proposerReward,
+    }}, payouts...)
+}
+
+// SetClock replaces the wall clock the engine stamps blocks and times out
+// agreement rounds with
+func (h *HybridEngine) SetClock(clock Clock) {
+    h.clock = clock
+}
+
+// Clock returns the clock the engine tells the time with
+func (h *HybridEngine) Clock() Clock {
+    return h.clock
+}
+
+// slotSeconds returns the slot length in whole seconds
+func (h *HybridEngine) slotSeconds() uint64 {
+    return slotSeconds(h.config.BlockTime)
 }
 
-func (h *HybridEngine) getBlockReward(blockNumber *big.Int) *big.Int {
-    // 100 SELSI dalam wei (18 decimals)
-    return new(big.Int).Mul(big.NewInt(100), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+// slotTime returns the start of the current slot as the timestamp of a child
+// of parent, or ErrSlotNotReached while the clock is still in the parent's
+// slot
+func (h *HybridEngine) slotTime(parent *types.Header) (uint64, error) {
+    slot := h.slotSeconds()
+    now := unixTime(h.clock.Now())
+    if now < parent.Time+slot {
+        return 0, fmt.Errorf("%w: block after #%s may be produced from %d, now %d", ErrSlotNotReached, parent.Number, parent.Time+slot, now)
+    }
+    return parent.Time + (now-parent.Time)/slot*slot, nil
+}
+
+// IsCheckpoint reports whether the block at number is a PoW checkpoint
+func (h *HybridEngine) IsCheckpoint(number *big.Int) bool {
+    return h.isCheckpointBlock(number)
+}
+
+// LastCheckpoint returns the number of the latest PoW checkpoint at or
+// below number, 0 for genesis
+func (h *HybridEngine) LastCheckpoint(number uint64) uint64 {
+    return h.config.LastCheckpoint(number)
+}
+
+// HasSupermajority reports whether a PoS block carries a valid commit
+// certificate: precommits from more than 2/3 of its epoch's stake
+func (h *HybridEngine) HasSupermajority(chain ChainReader, block *types.Block) bool {
+    parent := chain.GetHeader(block.Header.ParentHash)
+    if parent == nil {
+        return false
+    }
+    validators, err := h.Validators(chain, parent)
+    if err != nil {
+        return false
+    }
+    return h.posEngine.verifyCommit(block, validators) == nil
+}
+
+// verifyLastCommit checks that a block built on a PoS parent carries a valid
+// commit certificate of that parent, and that other blocks carry none
+func (h *HybridEngine) verifyLastCommit(chain ChainReader, block *types.Block, parent *types.Header) error {
+    if commitHash := hash.CalculateCommitHash(block.LastCommit); commitHash != block.Header.LastCommitHash {
+        return fmt.Errorf("%w: hashes to %x, header has %x", ErrInvalidLastCommit, commitHash[:4], block.Header.LastCommitHash[:4])
+    }
+    if !h.isPosBlock(parent.Number) {
+        if len(block.LastCommit) > 0 {
+            return fmt.Errorf("%w: parent #%s is not a PoS block", ErrInvalidLastCommit, parent.Number)
+        }
+        return nil
+    }
+    validators, err := h.Validators(chain, parent)
+    if err != nil {
+        return err
+    }
+    if err := h.posEngine.verifyCommit(&types.Block{Header: parent, Votes: block.LastCommit}, validators); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidLastCommit, err)
+    }
+    return nil
+}
+
+// isPosBlock reports whether the block at number is a PoS block
+func (h *HybridEngine) isPosBlock(blockNumber *big.Int) bool {
+    return blockNumber.Sign() > 0 && !h.isCheckpointBlock(blockNumber)
+}
+
+func (h *HybridEngine) isCheckpointBlock(blockNumber *big.Int) bool {
+    // Genesis is excluded; then every PowBlockInterval blocks
+    return blockNumber.IsUint64() && h.config.IsCheckpoint(blockNumber.Uint64())
 }
 
 func (h *HybridEngine) calculateBlockHash(block *types.Block) types.Hash {
-    // Mock hash calculation
-    var hash types.Hash
-    copy(hash[:], fmt.Sprintf("hash-%d", block.Header.Number))
-    return hash
+    return hash.CalculateBlockHash(block.Header)
 }
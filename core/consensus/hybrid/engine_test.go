@@ -0,0 +1,145 @@
+package hybrid_test
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// testChain is a chain run by the hybrid engine in simulated time
+type testChain struct {
+    *blockchain.Blockchain
+    engine *hybrid.HybridEngine
+    config *hybrid.Config
+    clock  *consensus.SimulatedClock
+}
+
+// newTestChain creates an in-memory chain from the default genesis with
+// trivial proof of work and checkpoints far apart, after applying configure
+// to it. The engine proposes with the dev validator keys.
+func newTestChain(t *testing.T, configure func(*blockchain.Genesis)) *testChain {
+    t.Helper()
+    genesis := blockchain.DefaultGenesis()
+    genesis.Config.MiningDifficulty = big.NewInt(1)
+    genesis.Config.PowBlockInterval = 100
+    if configure != nil {
+        configure(genesis)
+    }
+    clock := consensus.NewSimulatedClock(time.Now())
+    engine := hybrid.NewHybridEngine(genesis.Config)
+    engine.SetClock(clock)
+    engine.Authorize(blockchain.DevValidatorKeys()...)
+
+    bc, err := blockchain.NewBlockchain(&blockchain.Config{Genesis: genesis}, engine)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(bc.Close)
+    return &testChain{Blockchain: bc, engine: engine, config: genesis.Config, clock: clock}
+}
+
+// makeBlock builds the block with txs after the head one slot later, without
+// a commit certificate
+func (tc *testChain) makeBlock(t *testing.T, txs ...*types.Transaction) *types.Block {
+    t.Helper()
+    head, statedb := tc.CurrentState()
+    tc.clock.Advance(tc.config.BlockTime)
+    block, err := tc.engine.CreateBlock(tc, head, txs, types.Address{0xa}, statedb, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return block
+}
+
+// addBlock builds the block with txs after the head, certifies it if it is a
+// PoS block and adds it to the chain
+func (tc *testChain) addBlock(t *testing.T, txs ...*types.Transaction) *types.Block {
+    t.Helper()
+    block := tc.makeBlock(t, txs...)
+    if !block.Header.Checkpoint {
+        certify(block)
+    }
+    if err := tc.AddBlock(block); err != nil {
+        t.Fatal(err)
+    }
+    return block
+}
+
+// certify attaches precommits for block from every dev validator
+func certify(block *types.Block) {
+    blockHash := hash.CalculateBlockHash(block.Header)
+    block.Votes = nil
+    for _, key := range blockchain.DevValidatorKeys() {
+        block.Votes = append(block.Votes, signVote(key, block.Header.Number.Uint64(), block.Header.Round, blockHash))
+    }
+}
+
+// signVote returns the precommit of key for blockHash at number in round
+func signVote(key *keys.KeyPair, number uint64, round uint32, blockHash types.Hash) *types.Vote {
+    vote := &types.Vote{
+        Validator: key.Address,
+        Number:    number,
+        Round:     round,
+        Type:      types.VotePrecommit,
+        BlockHash: blockHash,
+        Decision:  true,
+        Timestamp: time.Now().Unix(),
+    }
+    key.SignVote(vote)
+    return vote
+}
+
+func TestVerifyBodyChecksProofOfWork(t *testing.T) {
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Config.PowBlockInterval = 1
+        genesis.Config.MiningDifficulty = big.NewInt(256)
+    })
+    block := tc.makeBlock(t)
+    if !block.Header.Checkpoint {
+        t.Fatal("block #1 is not a checkpoint")
+    }
+    if err := tc.engine.VerifyBody(tc, block, tc.GetStateDB()); err != nil {
+        t.Fatalf("mined checkpoint rejected: %v", err)
+    }
+
+    // Walk the nonce until the seal misses the target
+    forged := *block.Header
+    for nonce := byte(0); ; nonce++ {
+        forged.Nonce[0] = block.Header.Nonce[0] + nonce
+        err := tc.engine.VerifyBody(tc, &types.Block{Header: &forged}, tc.GetStateDB())
+        if errors.Is(err, hybrid.ErrInvalidPoW) {
+            break
+        }
+        if nonce == 255 {
+            t.Fatal("every nonce meets the target")
+        }
+    }
+
+    forged = *block.Header
+    forged.Difficulty = big.NewInt(1)
+    if err := tc.engine.VerifyBody(tc, &types.Block{Header: &forged}, tc.GetStateDB()); !errors.Is(err, hybrid.ErrInvalidDifficulty) {
+        t.Fatalf("lowered difficulty: got %v, want %v", err, hybrid.ErrInvalidDifficulty)
+    }
+}
+
+func TestPrepareCopiesDifficulty(t *testing.T) {
+    tc := newTestChain(t, nil)
+    block := tc.makeBlock(t)
+    if block.Header.Checkpoint {
+        t.Fatal("block #1 is a checkpoint")
+    }
+
+    // Whoever owns the header may modify it without touching the config
+    block.Header.Difficulty.SetInt64(42)
+    if tc.config.MiningDifficulty.Cmp(big.NewInt(1)) != 0 {
+        t.Fatalf("modifying a header changed the configured difficulty to %s", tc.config.MiningDifficulty)
+    }
+}
@@ -9,4 +9,24 @@ var (
     ErrNoValidators        = errors.New("no eligible validators available")
     ErrBlockTimeTooEarly   = errors.New("block time is too early")
     ErrInvalidBlockNumber  = errors.New("invalid block number")
+    ErrInvalidPoW          = errors.New("block hash does not meet the difficulty target")
+    ErrInvalidCheckpoint   = errors.New("checkpoint flag does not match block number")
+    ErrMiningAborted       = errors.New("mining aborted")
+    ErrUnknownValidator    = errors.New("proposer is not in the active validator set")
+    ErrUnknownAncestor     = errors.New("unknown ancestor")
+    ErrWrongProposer       = errors.New("block proposed by the wrong validator")
+    ErrInvalidRandao       = errors.New("invalid randomness reveal")
+    ErrNotProposer         = errors.New("not the selected proposer")
+    ErrUnauthorizedSigner  = errors.New("block sealed by a different validator")
+    ErrInvalidEvidence     = errors.New("invalid misbehaviour evidence")
+    ErrDuplicateEvidence   = errors.New("offence was already punished")
+    ErrNotJailed           = errors.New("validator is not jailed")
+    ErrStillJailed         = errors.New("validator jail time has not passed")
+    ErrInvalidCommit       = errors.New("invalid commit certificate")
+    ErrInvalidLastCommit   = errors.New("invalid commit certificate of the parent")
+    ErrInvalidProposal     = errors.New("invalid block proposal")
+    ErrAgreementAborted    = errors.New("agreement aborted")
+    ErrInvalidSlot         = errors.New("block time is not the start of a slot")
+    ErrSlotNotReached      = errors.New("slot has not started yet")
+    ErrExtraDataTooLong    = errors.New("header extra data too long")
 )
@@ -0,0 +1,166 @@
+package hybrid
+
+import (
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+const (
+    defaultDoubleSignPercent    = 5
+    defaultDoubleSignJailBlocks = 100000
+    defaultWhistleblowerPercent = 10
+    defaultEvidenceMaxAge       = 10000
+)
+
+// VerifyEvidence checks that ev proves a validator signed two conflicting
+// messages in one round and returns the offender and the height
+func (h *HybridEngine) VerifyEvidence(ev *types.Evidence) (types.Address, uint64, error) {
+    switch ev.Type {
+    case types.EvidenceDoubleProposal:
+        a, b := ev.HeaderA, ev.HeaderB
+        if a == nil || b == nil || a.Number == nil || b.Number == nil {
+            return types.Address{}, 0, fmt.Errorf("%w: missing header", ErrInvalidEvidence)
+        }
+        if a.Number.Cmp(b.Number) != 0 || a.Round != b.Round || a.Checkpoint || b.Checkpoint {
+            return types.Address{}, 0, fmt.Errorf("%w: headers are not PoS blocks of one round", ErrInvalidEvidence)
+        }
+        if hash.CalculateBlockHash(a) == hash.CalculateBlockHash(b) {
+            return types.Address{}, 0, fmt.Errorf("%w: headers are identical", ErrInvalidEvidence)
+        }
+        signerA, errA := keys.HeaderSigner(a)
+        signerB, errB := keys.HeaderSigner(b)
+        if errA != nil || errB != nil || signerA != signerB || signerA != a.Validator || signerB != b.Validator {
+            return types.Address{}, 0, fmt.Errorf("%w: headers are not sealed by one validator", ErrInvalidEvidence)
+        }
+        return signerA, a.Number.Uint64(), nil
+
+    case types.EvidenceDoubleVote:
+        a, b := ev.VoteA, ev.VoteB
+        if a == nil || b == nil {
+            return types.Address{}, 0, fmt.Errorf("%w: missing vote", ErrInvalidEvidence)
+        }
+        if a.Number != b.Number || a.Round != b.Round || a.Type != b.Type || a.Validator != b.Validator || a.BlockHash == b.BlockHash {
+            return types.Address{}, 0, fmt.Errorf("%w: votes do not conflict", ErrInvalidEvidence)
+        }
+        signerA, errA := keys.VoteSigner(a)
+        signerB, errB := keys.VoteSigner(b)
+        if errA != nil || errB != nil || signerA != a.Validator || signerB != b.Validator {
+            return types.Address{}, 0, fmt.Errorf("%w: votes are not signed by their validator", ErrInvalidEvidence)
+        }
+        return a.Validator, a.Number, nil
+    }
+    return types.Address{}, 0, fmt.Errorf("%w: unknown type %d", ErrInvalidEvidence, ev.Type)
+}
+
+// evidenceKey identifies an offence, so that it is punished only once however
+// many different message pairs prove it
+func evidenceKey(kind types.EvidenceType, offender types.Address, height uint64) types.Hash {
+    data := append([]byte{byte(kind)}, offender[:]...)
+    data = binary.BigEndian.AppendUint64(data, height)
+    return types.Hash(sha256.Sum256(data))
+}
+
+// verifyOffenders checks that the offender of every evidence transaction in
+// block was in the validator set of the block it misbehaved in, on the
+// branch block builds on. Stake alone does not make a signature count: a
+// staker outside the set at that height signed nothing the chain relied on.
+// Evidence that fails the other checks is left to applyEvidence to reject.
+func (h *HybridEngine) verifyOffenders(chain ChainReader, block *types.Block) error {
+    for i, tx := range block.Transactions {
+        if tx.Type != types.TxEvidence {
+            continue
+        }
+        var ev types.Evidence
+        if err := json.Unmarshal(tx.Data, &ev); err != nil {
+            continue
+        }
+        offender, height, err := h.VerifyEvidence(&ev)
+        if err != nil || height >= block.Header.Number.Uint64() {
+            continue
+        }
+
+        validators, err := h.validatorsAt(chain, block.Header, height)
+        if err != nil {
+            return fmt.Errorf("tx %d in block #%s: %w", i, block.Header.Number, err)
+        }
+        if !validators.Contains(offender) {
+            return fmt.Errorf("tx %d in block #%s: %w: %x was not a validator at #%d", i, block.Header.Number, ErrInvalidEvidence, offender[:4], height)
+        }
+    }
+    return nil
+}
+
+// validatorsAt returns the validator set of the block at height on the
+// branch header builds on
+func (h *HybridEngine) validatorsAt(chain ChainReader, header *types.Header, height uint64) (*ValidatorSet, error) {
+    if height == 0 {
+        return &ValidatorSet{TotalStake: big.NewInt(0)}, nil // Genesis has no signer
+    }
+    parent := header
+    for parent.Number.Uint64() > height-1 {
+        ancestor := chain.GetHeader(parent.ParentHash)
+        if ancestor == nil {
+            return nil, fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, parent.ParentHash[:4], parent.Number)
+        }
+        parent = ancestor
+    }
+    return h.Validators(chain, parent)
+}
+
+// applyEvidence handles TxEvidence: it slashes and jails the offender and
+// pays the sender a share of the slashed stake. The rest is burned.
+func (h *HybridEngine) applyEvidence(statedb *state.StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    var ev types.Evidence
+    if err := json.Unmarshal(tx.Data, &ev); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+    }
+    offender, height, err := h.VerifyEvidence(&ev)
+    if err != nil {
+        return err
+    }
+
+    current := header.Number.Uint64()
+    slashing := h.config.SlashingAt(current)
+    if height >= current {
+        return fmt.Errorf("%w: offence at #%d reported in block #%d", ErrInvalidEvidence, height, current)
+    }
+    if current-height > h.slashingParam(slashing.EvidenceMaxAge, defaultEvidenceMaxAge) {
+        return fmt.Errorf("%w: offence at #%d has expired", ErrInvalidEvidence, height)
+    }
+    key := evidenceKey(ev.Type, offender, height)
+    if statedb.HasEvidence(key) {
+        return fmt.Errorf("%w: %x at #%d", ErrDuplicateEvidence, offender[:4], height)
+    }
+    if statedb.GetStake(offender).Sign() == 0 {
+        return fmt.Errorf("%w: %x has no stake", ErrInvalidEvidence, offender[:4])
+    }
+
+    percent := int(h.slashingParam(uint64(slashing.DoubleSignPercent), defaultDoubleSignPercent))
+    slashed := statedb.Slash(offender, percent)
+    statedb.Jail(offender, current+h.slashingParam(slashing.DoubleSignJailBlocks, defaultDoubleSignJailBlocks))
+    statedb.AddEvidence(key)
+
+    reward := new(big.Int).Mul(slashed, big.NewInt(int64(h.slashingParam(uint64(slashing.WhistleblowerPercent), defaultWhistleblowerPercent))))
+    reward.Div(reward, big.NewInt(100))
+    statedb.AddBalance(from, reward)
+    statedb.AddBurned(new(big.Int).Sub(slashed, reward))
+
+    fmt.Printf("⚔️  Validator %x slashed %s wei and jailed for equivocation at #%d\n", offender[:4], slashed, height)
+    fmt.Printf("🕵️  Whistleblower %x rewarded %s wei\n", from[:4], reward)
+    return nil
+}
+
+// slashingParam returns value, or fallback when it is not configured
+func (h *HybridEngine) slashingParam(value uint64, fallback uint64) uint64 {
+    if value == 0 {
+        return fallback
+    }
+    return value
+}
@@ -0,0 +1,94 @@
+package hybrid_test
+
+import (
+    "encoding/json"
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// newEvidenceTransaction returns reporter's report of key signing two
+// conflicting precommits at number
+func newEvidenceTransaction(t *testing.T, chainID uint64, reporter *keys.KeyPair, nonce uint64, key *keys.KeyPair, number uint64) *types.Transaction {
+    t.Helper()
+    data, err := json.Marshal(&types.Evidence{
+        Type:  types.EvidenceDoubleVote,
+        VoteA: signVote(key, number, 0, types.Hash{1}),
+        VoteB: signVote(key, number, 0, types.Hash{2}),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    tx := &types.Transaction{
+        Nonce:    nonce,
+        GasPrice: big.NewInt(1),
+        Gas:      500000,
+        Value:    big.NewInt(0),
+        Data:     data,
+        Type:     types.TxEvidence,
+        ChainID:  chainID,
+    }
+    if err := reporter.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+    return tx
+}
+
+func newKey(t *testing.T) *keys.KeyPair {
+    t.Helper()
+    key, err := new(keys.KeyManager).GenerateKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return key
+}
+
+func TestEvidenceSlashesValidator(t *testing.T) {
+    reporter := newKey(t)
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Alloc[reporter.Address] = blockchain.GenesisAccount{Balance: big.NewInt(1e18)}
+    })
+    tc.addBlock(t)
+
+    offender := blockchain.DevValidatorKeys()[0]
+    stake := tc.GetStateDB().GetStake(offender.Address)
+    tc.addBlock(t, newEvidenceTransaction(t, tc.ChainID(), reporter, 0, offender, 1))
+
+    statedb := tc.GetStateDB()
+    if statedb.GetStake(offender.Address).Cmp(stake) >= 0 {
+        t.Fatal("offender was not slashed")
+    }
+    if !statedb.IsJailed(offender.Address) {
+        t.Fatal("offender was not jailed")
+    }
+}
+
+func TestEvidenceRequiresValidatorAtOffence(t *testing.T) {
+    reporter, outsider := newKey(t), newKey(t)
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Alloc[reporter.Address] = blockchain.GenesisAccount{Balance: big.NewInt(1e18)}
+
+        // The smallest staker does not make the three-validator set
+        genesis.Config.MaxValidators = 3
+        genesis.Validators = append(genesis.Validators, blockchain.GenesisValidator{
+            Address: outsider.Address,
+            Stake:   new(big.Int).Set(genesis.Config.MinimumStake),
+        })
+    })
+    tc.addBlock(t)
+    if tc.GetStateDB().GetStake(outsider.Address).Sign() == 0 {
+        t.Fatal("outsider has no stake")
+    }
+
+    head, statedb := tc.CurrentState()
+    tx := newEvidenceTransaction(t, tc.ChainID(), reporter, 0, outsider, 1)
+    tc.clock.Advance(tc.config.BlockTime)
+    if _, err := tc.engine.CreateBlock(tc, head, []*types.Transaction{tx}, types.Address{0xa}, statedb, nil); !errors.Is(err, hybrid.ErrInvalidEvidence) {
+        t.Fatalf("evidence against a staker outside the validator set: got %v, want %v", err, hybrid.ErrInvalidEvidence)
+    }
+}
@@ -0,0 +1,84 @@
+package hybrid
+
+import (
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+const (
+    defaultSignedBlocksWindow   = 100
+    defaultMinSignedPercent     = 50
+    defaultDowntimeSlashPercent = 1
+    defaultDowntimeJailBlocks   = 600
+)
+
+// trackLiveness records, while executing block, which validators of the
+// epoch signed its PoS parent by proposing it or precommitting it (as shown
+// by block.LastCommit), and jails those that missed too many of the last
+// SignedBlocksWindow blocks. A validator is only judged once it has been
+// tracked for a whole window.
+//
+// LastCommit is chosen by the proposer, so it could drop the precommits of
+// validators it wants jailed. Liveness is still credited from it, as it is
+// the only record of the parent's precommits all nodes agree on; instead
+// the agreement keeps proposers from dropping precommits: validators do not
+// prevote a proposal whose LastCommit lacks a precommit they received for
+// the parent (see omitsPrecommits). The price is liveness: a precommit that
+// reached the voters but not the proposer before it committed the parent
+// fails the round. A precommit nobody received in time is not credited: its
+// validator was late.
+// Blocks imported outside the agreement are not covered by the check.
+func (h *HybridEngine) trackLiveness(block *types.Block, parent *types.Header, statedb *state.StateDB, validators *ValidatorSet) {
+    slashing := h.config.SlashingAt(block.Header.Number.Uint64())
+    window := h.slashingParam(slashing.SignedBlocksWindow, defaultSignedBlocksWindow)
+    minSigned := h.slashingParam(uint64(slashing.MinSignedPercent), defaultMinSignedPercent)
+    maxMissed := window - window*minSigned/100
+
+    signed := map[types.Address]bool{parent.Validator: true}
+    for _, vote := range block.LastCommit {
+        signed[vote.Validator] = true
+    }
+
+    for _, validator := range validators.Validators {
+        if statedb.IsJailed(validator.Address) {
+            continue
+        }
+        tracked, missed := statedb.RecordSigning(validator.Address, signed[validator.Address], window)
+        if tracked >= window && missed > maxMissed {
+            h.jailForDowntime(validator.Address, block.Header.Number.Uint64(), missed, window, statedb)
+        }
+    }
+}
+
+// jailForDowntime slashes and jails a validator that stopped signing. The
+// slashed stake is burned.
+func (h *HybridEngine) jailForDowntime(addr types.Address, number uint64, missed uint64, window uint64, statedb *state.StateDB) {
+    slashing := h.config.SlashingAt(number)
+    percent := int(h.slashingParam(uint64(slashing.DowntimeSlashPercent), defaultDowntimeSlashPercent))
+    slashed := statedb.Slash(addr, percent)
+    statedb.AddBurned(slashed)
+    statedb.ResetSigning(addr)
+    statedb.Jail(addr, number+h.slashingParam(slashing.DowntimeJailBlocks, defaultDowntimeJailBlocks))
+
+    fmt.Printf("💤 Validator %x missed %d of the last %d blocks: slashed %s wei and jailed\n", addr[:4], missed, window, slashed)
+}
+
+// applyUnjail handles TxUnjail: a jailed validator whose jail time has passed
+// rejoins the validator set at the next epoch, if it still has enough stake
+func (h *HybridEngine) applyUnjail(statedb *state.StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    info := statedb.GetValidatorInfo(from)
+    if !info.Jailed {
+        return fmt.Errorf("%w: %x", ErrNotJailed, from[:4])
+    }
+    if number := header.Number.Uint64(); number < info.JailedUntil {
+        return fmt.Errorf("%w: %x is jailed until #%d, now #%d", ErrStillJailed, from[:4], info.JailedUntil, number)
+    }
+    if stake := statedb.GetStake(from); h.config.MinimumStake != nil && stake.Cmp(h.config.MinimumStake) < 0 {
+        return fmt.Errorf("%w: %x has %s, minimum %s", ErrInsufficientStake, from[:4], stake, h.config.MinimumStake)
+    }
+
+    statedb.Unjail(from)
+    fmt.Printf("🔓 Validator %x unjailed, active from the next epoch\n", from[:4])
+    return nil
+}
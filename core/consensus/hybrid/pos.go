@@ -3,191 +3,204 @@ package hybrid
 import (
     "fmt"
     "math/big"
+    "sync"
     "github.com/selsichain/selsichain-core/core/types"
-    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
 )
 
 type POSEngine struct {
-    config *Config
+    config  *Config
+    signers map[types.Address]*keys.KeyPair // Validator keys this node proposes with
+    mu      sync.RWMutex
 }
 
 func NewPOSEngine(config *Config) *POSEngine {
     return &POSEngine{
-        config: config,
+        config:  config,
+        signers: make(map[types.Address]*keys.KeyPair),
     }
 }
 
-// VerifyBlock verifies PoS block
-func (p *POSEngine) VerifyBlock(block *types.Block, state *state.StateDB) error {
-    // Verify validator stake
-    if !p.verifyValidatorStake(block.Header.Validator, state) {
-        return ErrInsufficientStake
+// VerifyBlock verifies the header of a PoS block built on parent against the
+// validator set of its epoch: the proposer of its round, the randomness
+// reveal and the seal. The commit certificate is checked by verifyCommit.
+func (p *POSEngine) VerifyBlock(block *types.Block, parent *types.Header, validators *ValidatorSet) error {
+    // Verify validator is active in this epoch
+    if !p.verifyValidatorStake(block.Header.Validator, validators) {
+        return fmt.Errorf("%w: %x in epoch %d", ErrUnknownValidator, block.Header.Validator[:4], validators.Epoch)
     }
     
-    // Verify votes (2/3 majority)
-    if !p.verifyVotes(block.Votes, block, state) {
-        return ErrInsufficientVotes
+    // Verify it was this validator's turn and its randomness reveal
+    expected, err := p.selectValidator(proposerSeed(parent, block.Header.Number, block.Header.Round), validators)
+    if err != nil {
+        return err
+    }
+    if block.Header.Validator != expected {
+        return fmt.Errorf("%w: block #%s round %d by %x, expected %x", ErrWrongProposer, block.Header.Number, block.Header.Round, block.Header.Validator[:4], expected[:4])
+    }
+    if err := verifyRandao(block.Header, parent); err != nil {
+        return err
+    }
+    
+    // Verify the proposer sealed the block
+    signer, err := keys.HeaderSigner(block.Header)
+    if err != nil {
+        return err
+    }
+    if signer != block.Header.Validator || signer != expected {
+        return fmt.Errorf("%w: sealed by %x, validator %x", ErrUnauthorizedSigner, signer[:4], block.Header.Validator[:4])
     }
     
     fmt.Printf("🎯 PoS Block #%s verified\n", block.Header.Number)
     return nil
 }
 
-// PrepareBlock prepares block for staking
-func (p *POSEngine) PrepareBlock(block *types.Block) (*types.Block, error) {
+// PrepareBlock prepares block for staking in the round set in its header. It
+// fails with ErrNotProposer unless this node holds the key of the validator
+// selected for that round.
+func (p *POSEngine) PrepareBlock(block *types.Block, parent *types.Header, validators *ValidatorSet) (*types.Block, error) {
     // Select validator for this block
-    validator, err := p.selectValidator(block.Header.Number)
+    validator, err := p.selectValidator(proposerSeed(parent, block.Header.Number, block.Header.Round), validators)
     if err != nil {
         return nil, err
     }
+    selected, _ := validators.Get(validator)
+    fmt.Printf("🎯 Validator selected: %x (Stake: %s SELSI)\n", 
+        validator[:4], 
+        selected.Stake)
+    
+    p.mu.RLock()
+    signer := p.signers[validator]
+    p.mu.RUnlock()
+    if signer == nil {
+        return nil, fmt.Errorf("%w: block #%s round %d belongs to %x", ErrNotProposer, block.Header.Number, block.Header.Round, validator[:4])
+    }
     
     block.Header.Validator = validator
     block.Header.Checkpoint = false
+    if err := sealRandao(block.Header, parent, signer); err != nil {
+        return nil, err
+    }
     
-    // Generate votes for this block
-    block.Votes = p.generateVotes(block, validator)
-    
-    fmt.Printf("🎯 PoS Block #%s prepared for validation\n", block.Header.Number)
-    fmt.Printf("🎯 Generated %d votes for block\n", len(block.Votes))
-    
+    fmt.Printf("🎯 PoS Block #%s prepared for agreement\n", block.Header.Number)
     return block, nil
 }
 
-// selectValidator selects validator based on stake and block number
-func (p *POSEngine) selectValidator(blockNumber *big.Int) (types.Address, error) {
-    validators := p.getEligibleValidators()
-    if len(validators) == 0 {
-        return types.Address{}, ErrNoValidators
+// Seal signs the finished block with the key of its validator
+func (p *POSEngine) Seal(block *types.Block) (*types.Block, error) {
+    p.mu.RLock()
+    signer := p.signers[block.Header.Validator]
+    p.mu.RUnlock()
+    if signer == nil {
+        return nil, fmt.Errorf("%w: no key for validator %x", ErrNotProposer, block.Header.Validator[:4])
     }
     
-    // Simple round-robin selection based on block number
-    index := new(big.Int).Mod(blockNumber, big.NewInt(int64(len(validators)))).Int64()
-    selectedValidator := validators[index]
-    
-    fmt.Printf("🎯 Validator selected: %x (Stake: %s SELSI)\n", 
-        selectedValidator.Address[:4], 
-        selectedValidator.Stake)
-    
-    return selectedValidator.Address, nil
+    header := *block.Header
+    if err := signer.SignHeader(&header); err != nil {
+        return nil, err
+    }
+    fmt.Printf("✍️  PoS Block #%s sealed by %x\n", header.Number, signer.Address[:4])
+    return &types.Block{
+        Header:       &header,
+        Transactions: block.Transactions,
+        Votes:        block.Votes,
+        LastCommit:   block.LastCommit,
+    }, nil
 }
 
-func (p *POSEngine) verifyValidatorStake(validator types.Address, state *state.StateDB) bool {
-    stake := state.GetStake(validator)
-    isValid := stake.Cmp(p.config.MinimumStake) >= 0
-    
-    if isValid {
-        fmt.Printf("🎯 Validator %x has sufficient stake: %s SELSI\n", 
-            validator[:4], stake)
-    } else {
-        fmt.Printf("❌ Validator %x has insufficient stake: %s SELSI (min: %s SELSI)\n", 
-            validator[:4], stake, p.config.MinimumStake)
+// authorize adds validator keys to propose with
+func (p *POSEngine) authorize(signers ...*keys.KeyPair) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    
+    for _, signer := range signers {
+        p.signers[signer.Address] = signer
+        fmt.Printf("🔑 Proposing as validator %x\n", signer.Address[:4])
     }
-    
-    return isValid
 }
 
-func (p *POSEngine) verifyVotes(votes []*types.Vote, block *types.Block, state *state.StateDB) bool {
-    approvedStake := big.NewInt(0)
-    totalStake := big.NewInt(0)
-    approvedVotes := 0
-    totalVotes := 0
-    
-    // Simple block hash simulation untuk demo
-    expectedBlockHash := p.simulateBlockHash(block)
+// selectValidator draws a validator from the set with probability
+// proportional to its stake, using seed as the randomness
+func (p *POSEngine) selectValidator(seed []byte, validators *ValidatorSet) (types.Address, error) {
+    if validators.Len() == 0 || validators.TotalStake.Sign() <= 0 {
+        return types.Address{}, ErrNoValidators
+    }
     
-    for _, vote := range votes {
-        // Simple hash comparison untuk demo
-        voteIsValid := p.simulateVoteValidation(vote, expectedBlockHash, state)
-        
-        if voteIsValid {
-            stake := state.GetStake(vote.Validator)
-            totalStake.Add(totalStake, stake)
-            totalVotes++
-            
-            if vote.Decision {
-                approvedStake.Add(approvedStake, stake)
-                approvedVotes++
-            }
+    // Point in [0, total stake), then walk the set in its canonical order
+    point := new(big.Int).SetBytes(seed)
+    point.Mod(point, validators.TotalStake)
+    cumulative := new(big.Int)
+    for _, validator := range validators.Validators {
+        cumulative.Add(cumulative, validator.Stake)
+        if point.Cmp(cumulative) < 0 {
+            return validator.Address, nil
         }
     }
+    return validators.Validators[validators.Len()-1].Address, nil
+}
+
+// verifyValidatorStake checks that validator is in the active set of the epoch
+func (p *POSEngine) verifyValidatorStake(validator types.Address, validators *ValidatorSet) bool {
+    stake := validators.StakeOf(validator)
+    isValid := validators.Contains(validator)
     
-    // Butuh 2/3 stake setuju
-    if totalStake.Cmp(big.NewInt(0)) == 0 {
-        fmt.Printf("❌ No valid votes received\n")
-        return false
+    if isValid {
+        fmt.Printf("🎯 Validator %x is active with stake: %s SELSI\n", 
+            validator[:4], stake)
+    } else {
+        fmt.Printf("❌ Validator %x is not in the validator set of epoch %d\n", 
+            validator[:4], validators.Epoch)
     }
     
-    requiredStake := new(big.Int).Div(new(big.Int).Mul(totalStake, big.NewInt(2)), big.NewInt(3))
-    isApproved := approvedStake.Cmp(requiredStake) >= 0
-    
-    fmt.Printf("🎯 Voting Results: %d/%d validators approved (%s/%s stake) - %s\n", 
-        approvedVotes, totalVotes, approvedStake, totalStake, 
-        map[bool]string{true: "APPROVED", false: "REJECTED"}[isApproved])
-    
-    return isApproved
+    return isValid
 }
 
-// generateVotes creates mock votes for demo
-func (p *POSEngine) generateVotes(block *types.Block, blockProposer types.Address) []*types.Vote {
-    var votes []*types.Vote
-    validators := p.getEligibleValidators()
-    
-    blockHash := p.simulateBlockHash(block)
-    
-    for _, validator := range validators {
-        // Skip the block proposer (they don't vote for their own block)
-        if validator.Address == blockProposer {
-            continue
+// verifyCommit checks the commit certificate of block: precommits for it
+// from a single round, signed by validators of the epoch holding more than
+// 2/3 of its stake
+func (p *POSEngine) verifyCommit(block *types.Block, validators *ValidatorSet) error {
+    blockHash := hash.CalculateBlockHash(block.Header)
+    number := block.Header.Number.Uint64()
+    committed := big.NewInt(0)
+    seen := make(map[types.Address]bool)
+    
+    for i, vote := range block.Votes {
+        if vote.Type != types.VotePrecommit || vote.Number != number || vote.BlockHash != blockHash || !vote.Decision {
+            return fmt.Errorf("%w: vote %d is not a precommit for block #%d", ErrInvalidCommit, i, number)
         }
+        if vote.Round != block.Votes[0].Round {
+            return fmt.Errorf("%w: precommits from rounds %d and %d", ErrInvalidCommit, block.Votes[0].Round, vote.Round)
+        }
+        if seen[vote.Validator] {
+            return fmt.Errorf("%w: duplicate precommit from %x", ErrInvalidCommit, vote.Validator[:4])
+        }
+        seen[vote.Validator] = true
         
-        // 80% chance of approval for demo
-        decision := true // Always approve for now to make it work
-        
-        votes = append(votes, &types.Vote{
-            Validator: validator.Address,
-            BlockHash: blockHash,
-            Decision:  decision,
-            Timestamp: 0,
-        })
+        validator, ok := validators.Get(vote.Validator)
+        if !ok {
+            return fmt.Errorf("%w: %x is not in the validator set of epoch %d", ErrInvalidCommit, vote.Validator[:4], validators.Epoch)
+        }
+        if signer, err := keys.VoteSigner(vote); err != nil || signer != vote.Validator {
+            return fmt.Errorf("%w: bad signature on precommit from %x", ErrInvalidCommit, vote.Validator[:4])
+        }
+        committed.Add(committed, validator.Stake)
     }
     
-    return votes
-}
-
-// simulateBlockHash creates a simple hash for demo
-func (p *POSEngine) simulateBlockHash(block *types.Block) types.Hash {
-    var hash types.Hash
-    // Simple hash based on block number and validator
-    copy(hash[:], fmt.Sprintf("block-%d-%x", block.Header.Number, block.Header.Validator[:2]))
-    return hash
+    if !hasQuorum(committed, validators.TotalStake) {
+        return fmt.Errorf("%w: %d precommits with %s of %s stake", ErrInsufficientVotes, len(block.Votes), committed, validators.TotalStake)
+    }
+    fmt.Printf("🎯 Commit certificate: %d precommits with %s/%s stake\n", len(block.Votes), committed, validators.TotalStake)
+    return nil
 }
 
-// simulateVoteValidation simulates vote verification for demo
-func (p *POSEngine) simulateVoteValidation(vote *types.Vote, expectedHash types.Hash, state *state.StateDB) bool {
-    // Check if validator has sufficient stake
-    if !p.verifyValidatorStake(vote.Validator, state) {
-        return false
-    }
-    
-    // Simple hash comparison
-    return vote.BlockHash == expectedHash
+// hasQuorum reports whether stake is more than 2/3 of total
+func hasQuorum(stake, total *big.Int) bool {
+    return new(big.Int).Mul(stake, big.NewInt(3)).Cmp(new(big.Int).Mul(total, big.NewInt(2))) > 0
 }
 
-// getEligibleValidators returns list of validators with sufficient stake
-func (p *POSEngine) getEligibleValidators() []Validator {
-    // For demo, return validators with addresses 1, 2, 3
-    return []Validator{
-        {
-            Address: types.Address{1},
-            Stake:   new(big.Int).Mul(big.NewInt(5000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
-        },
-        {
-            Address: types.Address{2}, 
-            Stake:   new(big.Int).Mul(big.NewInt(3000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
-        },
-        {
-            Address: types.Address{3},
-            Stake:   new(big.Int).Mul(big.NewInt(7000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
-        },
-    }
+// hasOneThird reports whether stake is more than 1/3 of total, so that at
+// least one honest validator is part of it
+func hasOneThird(stake, total *big.Int) bool {
+    return new(big.Int).Mul(stake, big.NewInt(3)).Cmp(total) > 0
 }
@@ -2,35 +2,64 @@ package hybrid
 
 import (
     "crypto/rand"
+    "encoding/binary"
     "fmt"
     "math/big"
+    "runtime"
+    "sync"
+    "sync/atomic"
+    "time"
     "github.com/selsichain/selsichain-core/core/types"
-    "github.com/selsichain/selsichain-core/core/state"
-    "github.com/selsichain/selsichain-core/crypto/hash"
 )
 
+// two256 is 2^256, the size of the hash space
+var two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
 type POWEngine struct {
-    config *Config
+    config  *Config
+    hasher  PowHasher
+    threads int
 }
 
 func NewPOWEngine(config *Config) *POWEngine {
+    hasher, err := NewPowHasher(config.PowAlgorithm)
+    if err != nil {
+        // Genesis validation rejects unknown algorithms before an engine is built
+        fmt.Printf("⚠️  %v, using %s\n", err, PowSHA256)
+        hasher = sha256Hasher{}
+    }
     return &POWEngine{
-        config: config,
+        config:  config,
+        hasher:  hasher,
+        threads: runtime.NumCPU(),
     }
 }
 
-// VerifyBlock verifies PoW block
-func (p *POWEngine) VerifyBlock(block *types.Block, state *state.StateDB) error {
-    // Verify difficulty
-    if !p.verifyDifficulty(block.Header) {
-        return ErrInvalidDifficulty
+// SetThreads sets the number of mining goroutines; n <= 0 uses every CPU
+func (p *POWEngine) SetThreads(n int) {
+    if n <= 0 {
+        n = runtime.NumCPU()
     }
-    
-    // Verify block time
-    if !p.verifyBlockTime(block.Header) {
-        return ErrBlockTimeTooEarly
+    p.threads = n
+}
+
+// VerifyHeader checks the difficulty and the proof of work of a checkpoint header
+func (p *POWEngine) VerifyHeader(header *types.Header, expected *big.Int) error {
+    if !p.verifyDifficulty(header, expected) {
+        return fmt.Errorf("%w: have %v, want %s", ErrInvalidDifficulty, header.Difficulty, expected)
+    }
+    if !p.verifySeal(header) {
+        return ErrInvalidPoW
+    }
+    return nil
+}
+
+// VerifyBlock checks the difficulty and the proof of work of a checkpoint
+// block against the expected difficulty
+func (p *POWEngine) VerifyBlock(block *types.Block, expected *big.Int) error {
+    if err := p.VerifyHeader(block.Header, expected); err != nil {
+        return err
     }
-    
     fmt.Printf("⛏️  PoW Block #%s verified\n", block.Header.Number)
     return nil
 }
@@ -38,50 +67,105 @@ func (p *POWEngine) VerifyBlock(block *types.Block, state *state.StateDB) error
 // PrepareBlock prepares block for mining
 func (p *POWEngine) PrepareBlock(block *types.Block) (*types.Block, error) {
     block.Header.Checkpoint = true
-    block.Header.Difficulty = p.config.MiningDifficulty
-    
-    // Generate random nonce untuk mining
-    block.Header.Nonce = p.generateNonce()
-    
+
     fmt.Printf("⛏️  PoW Block #%s prepared for mining\n", block.Header.Number)
     return block, nil
 }
 
-// MineBlock simulates mining process
-func (p *POWEngine) MineBlock(block *types.Block) (*types.Block, error) {
-    fmt.Printf("⛏️  Mining PoW Block #%s...\n", block.Header.Number)
-    
-    // Simulate mining process (in real implementation, this would be actual PoW)
-    target := new(big.Int).Div(
-        new(big.Int).Exp(big.NewInt(2), big.NewInt(256), nil),
-        p.config.MiningDifficulty,
+// MineBlock searches for a nonce that brings the proof-of-work hash below the
+// difficulty target, using one goroutine per configured thread. It returns
+// ErrMiningAborted as soon as stop is closed, e.g. because a competing block
+// arrived or the node is shutting down.
+func (p *POWEngine) MineBlock(block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+    fmt.Printf("⛏️  Mining PoW Block #%s with %d %s threads (difficulty %s)...\n",
+        block.Header.Number, p.threads, p.hasher.Name(), block.Header.Difficulty)
+
+    target := powTarget(block.Header.Difficulty)
+    found := make(chan *types.Header, p.threads)
+    abort := make(chan struct{})
+    var (
+        attempts atomic.Uint64
+        wg       sync.WaitGroup
     )
-    
-    // Simple mining simulation - try different nonces
-    for i := 0; i < 1000; i++ {
-        block.Header.Nonce = p.generateNonce()
-        blockHash := hash.CalculateBlockHash(block.Header)
-        hashInt := new(big.Int).SetBytes(blockHash[:])
-        
-        if hashInt.Cmp(target) == -1 {
-            fmt.Printf("✅ PoW Block #%s mined successfully!\n", block.Header.Number)
-            return block, nil
+
+    start := time.Now()
+    seed := p.generateNonce()
+    for i := 0; i < p.threads; i++ {
+        wg.Add(1)
+        go func(id int) {
+            defer wg.Done()
+            // Every thread walks its own range of the nonce space
+            header := *block.Header
+            nonce := binary.BigEndian.Uint64(seed[:]) + uint64(id)<<56
+            p.search(&header, nonce, target, abort, found, &attempts)
+        }(i)
+    }
+
+    var sealed *types.Header
+    select {
+    case sealed = <-found:
+    case <-stop:
+    }
+    close(abort)
+    wg.Wait()
+
+    if sealed == nil {
+        fmt.Printf("🛑 Mining PoW Block #%s aborted after %d hashes\n", block.Header.Number, attempts.Load())
+        return nil, ErrMiningAborted
+    }
+
+    elapsed := time.Since(start)
+    fmt.Printf("✅ PoW Block #%s mined successfully! (%d hashes in %v)\n",
+        block.Header.Number, attempts.Load(), elapsed.Round(time.Millisecond))
+    return &types.Block{
+        Header:       sealed,
+        Transactions: block.Transactions,
+        Votes:        block.Votes,
+        LastCommit:   block.LastCommit,
+    }, nil
+}
+
+// search tries consecutive nonces on header until one meets target or abort is closed
+func (p *POWEngine) search(header *types.Header, nonce uint64, target *big.Int, abort <-chan struct{}, found chan<- *types.Header, attempts *atomic.Uint64) {
+    const batch = 1024
+    hashInt := new(big.Int)
+    for {
+        select {
+        case <-abort:
+            return
+        default:
         }
+
+        for i := 0; i < batch; i++ {
+            binary.BigEndian.PutUint64(header.Nonce[:], nonce)
+            powHash := p.hasher.Hash(header)
+            if hashInt.SetBytes(powHash[:]).Cmp(target) < 0 {
+                attempts.Add(uint64(i + 1))
+                found <- header
+                return
+            }
+            nonce++
+        }
+        attempts.Add(batch)
     }
-    
-    // For demo purposes, just return the block even if mining "fails"
-    fmt.Printf("⚠️  PoW Block #%s - using fallback (demo mode)\n", block.Header.Number)
-    return block, nil
 }
 
-func (p *POWEngine) verifyDifficulty(header *types.Header) bool {
-    // For demo, always return true
-    return true
+// powTarget returns 2^256 / difficulty
+func powTarget(difficulty *big.Int) *big.Int {
+    return new(big.Int).Div(two256, difficulty)
 }
 
-func (p *POWEngine) verifyBlockTime(header *types.Header) bool {
-    // Basic time verification
-    return true
+func (p *POWEngine) verifyDifficulty(header *types.Header, expected *big.Int) bool {
+    return header.Difficulty != nil && header.Difficulty.Cmp(expected) == 0
+}
+
+// verifySeal recomputes the proof-of-work hash and checks it against the target
+func (p *POWEngine) verifySeal(header *types.Header) bool {
+    if header.Difficulty == nil || header.Difficulty.Sign() <= 0 {
+        return false
+    }
+    powHash := p.hasher.Hash(header)
+    return new(big.Int).SetBytes(powHash[:]).Cmp(powTarget(header.Difficulty)) < 0
 }
 
 func (p *POWEngine) generateNonce() types.BlockNonce {
@@ -0,0 +1,89 @@
+package hybrid
+
+import (
+    "errors"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "golang.org/x/crypto/argon2"
+    "golang.org/x/crypto/scrypt"
+)
+
+// Proof-of-work hash functions selectable with Config.PowAlgorithm
+const (
+    PowSHA256   = "sha256"
+    PowScrypt   = "scrypt"
+    PowArgon2id = "argon2id"
+)
+
+// Memory-hard parameters. They are consensus rules: changing them forks the
+// chain. A seal needs 128 * scryptN * scryptR bytes of scrypt working set, or
+// argon2Memory KiB of Argon2id; see powhash_test.go for the measured cost.
+const (
+    scryptN = 1 << 14 // 16 MiB per hash with r = 8
+    scryptR = 8
+    scryptP = 1
+
+    argon2Time    = 1
+    argon2Memory  = 4 * 1024 // KiB
+    argon2Threads = 1
+)
+
+var ErrUnknownPowAlgorithm = errors.New("unknown proof-of-work algorithm")
+
+// PowAlgorithms lists the supported proof-of-work hash functions
+var PowAlgorithms = []string{PowSHA256, PowScrypt, PowArgon2id}
+
+// PowHasher computes the proof-of-work hash of a header. Checkpoints are
+// valid when this hash, read as a big-endian number, is below the target.
+type PowHasher interface {
+    Name() string
+    Hash(header *types.Header) types.Hash
+}
+
+// NewPowHasher returns the hasher for a configured algorithm; "" selects SHA-256
+func NewPowHasher(algorithm string) (PowHasher, error) {
+    switch algorithm {
+    case "", PowSHA256:
+        return sha256Hasher{}, nil
+    case PowScrypt:
+        return scryptHasher{}, nil
+    case PowArgon2id:
+        return argon2Hasher{}, nil
+    }
+    return nil, fmt.Errorf("%w: %q (supported: %v)", ErrUnknownPowAlgorithm, algorithm, PowAlgorithms)
+}
+
+// sha256Hasher uses the block hash itself, as checkpoints always did
+type sha256Hasher struct{}
+
+func (sha256Hasher) Name() string { return PowSHA256 }
+
+func (sha256Hasher) Hash(header *types.Header) types.Hash {
+    return hash.CalculateBlockHash(header)
+}
+
+// scryptHasher hashes the header with scrypt, using it as password and salt
+type scryptHasher struct{}
+
+func (scryptHasher) Name() string { return PowScrypt }
+
+func (scryptHasher) Hash(header *types.Header) types.Hash {
+    data := hash.EncodeHeader(header)
+    key, err := scrypt.Key(data, data, scryptN, scryptR, scryptP, len(types.Hash{}))
+    if err != nil {
+        // Only possible with invalid constant parameters
+        panic(err)
+    }
+    return types.Hash(key)
+}
+
+// argon2Hasher hashes the header with Argon2id, salted with the parent hash
+type argon2Hasher struct{}
+
+func (argon2Hasher) Name() string { return PowArgon2id }
+
+func (argon2Hasher) Hash(header *types.Header) types.Hash {
+    key := argon2.IDKey(hash.EncodeHeader(header), header.ParentHash[:], argon2Time, argon2Memory, argon2Threads, uint32(len(types.Hash{})))
+    return types.Hash(key)
+}
@@ -0,0 +1,95 @@
+package hybrid
+
+import (
+    "crypto/sha256"
+    "encoding/binary"
+    "fmt"
+    "math/big"
+    "github.com/ethereum/go-ethereum/crypto"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+    "github.com/selsichain/selsichain-core/crypto/vrf"
+)
+
+// RANDAO rules:
+//
+//   - Every header carries the chain randomness in MixDigest. Genesis starts
+//     from its own MixDigest and PoW checkpoints copy the mix of their parent.
+//   - The proposer of PoS block n in agreement round r is drawn by stake from
+//     the epoch's validator set, using seed(n, r) = sha256(parent.MixDigest ||
+//     n || r).
+//   - The proposer reveals its VRF output for seed(n, r) in RandaoReveal
+//     (compressed public key || proof), and the block's mix becomes
+//     sha256(parent.MixDigest || output).
+//
+// A VRF output is unique for a key and input, so a proposer cannot grind the
+// mix; it can only withhold its block.
+
+const compressedKeyLength = 33
+
+// proposerSeed returns the randomness that selects the proposer of the block
+// at number on top of parent in round. It is also the VRF input of that
+// proposer.
+func proposerSeed(parent *types.Header, number *big.Int, round uint32) []byte {
+    data := append([]byte{}, parent.MixDigest[:]...)
+    data = binary.BigEndian.AppendUint64(data, number.Uint64())
+    data = binary.BigEndian.AppendUint32(data, round)
+    seed := sha256.Sum256(data)
+    return seed[:]
+}
+
+// nextMix folds a VRF output into the parent mix
+func nextMix(parentMix types.Hash, output types.Hash) types.Hash {
+    return types.Hash(sha256.Sum256(append(parentMix[:], output[:]...)))
+}
+
+// ExpectedProposer returns the validator that must propose the PoS block
+// built on parent in round
+func (h *HybridEngine) ExpectedProposer(chain ChainReader, parent *types.Header, round uint32) (types.Address, error) {
+    validators, err := h.Validators(chain, parent)
+    if err != nil {
+        return types.Address{}, err
+    }
+    number := new(big.Int).Add(parent.Number, big.NewInt(1))
+    return h.posEngine.selectValidator(proposerSeed(parent, number, round), validators)
+}
+
+// Authorize gives the engine validator keys to propose PoS blocks with. A
+// node only produces PoS blocks for slots assigned to one of its keys.
+func (h *HybridEngine) Authorize(signers ...*keys.KeyPair) {
+    h.posEngine.authorize(signers...)
+}
+
+// sealRandao reveals the proposer's VRF output in header and updates its mix
+func sealRandao(header *types.Header, parent *types.Header, signer *keys.KeyPair) error {
+    output, proof, err := vrf.Prove(signer.PrivateKey, proposerSeed(parent, header.Number, header.Round))
+    if err != nil {
+        return err
+    }
+    header.RandaoReveal = append(crypto.CompressPubkey(signer.PublicKey), proof...)
+    header.MixDigest = nextMix(parent.MixDigest, output)
+    return nil
+}
+
+// verifyRandao checks that the PoS header reveals the VRF output of its
+// validator for its height and round and carries the resulting mix
+func verifyRandao(header *types.Header, parent *types.Header) error {
+    if len(header.RandaoReveal) != compressedKeyLength+vrf.ProofLength {
+        return fmt.Errorf("%w: reveal is %d bytes", ErrInvalidRandao, len(header.RandaoReveal))
+    }
+    publicKey, err := crypto.DecompressPubkey(header.RandaoReveal[:compressedKeyLength])
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidRandao, err)
+    }
+    if signer := keys.PubkeyToAddress(publicKey); signer != header.Validator {
+        return fmt.Errorf("%w: revealed by %x, validator %x", ErrInvalidRandao, signer[:4], header.Validator[:4])
+    }
+    output, err := vrf.Verify(publicKey, proposerSeed(parent, header.Number, header.Round), header.RandaoReveal[compressedKeyLength:])
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidRandao, err)
+    }
+    if mix := nextMix(parent.MixDigest, output); mix != header.MixDigest {
+        return fmt.Errorf("%w: mix %x, want %x", ErrInvalidRandao, header.MixDigest[:4], mix[:4])
+    }
+    return nil
+}
@@ -0,0 +1,215 @@
+package hybrid
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "sort"
+)
+
+var (
+    ErrInvalidOverride      = errors.New("invalid config override")
+    ErrIncompatibleOverride = errors.New("config overrides rewrite chain history")
+)
+
+// Consensus parameters that may change at a height are read through the
+// accessors below, never from the Config fields directly, so every node
+// switches to the overridden values at the same block.
+
+// PowBlockIntervalAt returns the distance between PoW checkpoints in force
+// at block number
+func (c *Config) PowBlockIntervalAt(number uint64) uint64 {
+    _, interval := c.checkpointSegment(number)
+    return interval
+}
+
+// IsCheckpoint reports whether the block at number is a PoW checkpoint
+func (c *Config) IsCheckpoint(number uint64) bool {
+    start, interval := c.checkpointSegment(number)
+    return number > 0 && (number-start)%interval == 0
+}
+
+// LastCheckpoint returns the number of the latest checkpoint at or below
+// number, 0 for genesis
+func (c *Config) LastCheckpoint(number uint64) uint64 {
+    start, interval := c.checkpointSegment(number)
+    return start + (number-start)/interval*interval
+}
+
+// Epoch returns the number of the epoch opened by the checkpoint at number:
+// the checkpoints up to it. Genesis opens epoch 0.
+func (c *Config) Epoch(number uint64) uint64 {
+    epoch, start, interval := uint64(0), uint64(0), c.PowBlockInterval
+    for _, override := range c.overridesAt(number) {
+        if override.PowBlockInterval != 0 {
+            epoch += (override.Number - start) / interval
+            start, interval = override.Number, override.PowBlockInterval
+        }
+    }
+    return epoch + (number-start)/interval
+}
+
+// EmissionAt returns the emission schedule in force at block number
+func (c *Config) EmissionAt(number uint64) EmissionConfig {
+    emission := c.Emission
+    for _, override := range c.overridesAt(number) {
+        if override.Emission != nil {
+            emission = *override.Emission
+        }
+    }
+    return emission
+}
+
+// RewardDistributionAt returns the reward shares in force at block number
+func (c *Config) RewardDistributionAt(number uint64) RewardConfig {
+    distribution := c.RewardDistribution
+    for _, override := range c.overridesAt(number) {
+        if override.RewardDistribution != nil {
+            distribution = *override.RewardDistribution
+        }
+    }
+    return distribution
+}
+
+// SlashingAt returns the slashing parameters in force at block number
+func (c *Config) SlashingAt(number uint64) SlashingConfig {
+    slashing := c.Slashing
+    for _, override := range c.overridesAt(number) {
+        if override.Slashing != nil {
+            slashing = *override.Slashing
+        }
+    }
+    return slashing
+}
+
+// ForkHeights returns the heights after genesis at which the rules change,
+// by upgrade or override, in ascending order
+func (c *Config) ForkHeights() []uint64 {
+    heights := c.Upgrades.Heights()
+    for _, override := range c.Overrides {
+        heights = append(heights, override.Number)
+    }
+    sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+    distinct := heights[:0]
+    for i, height := range heights {
+        if i == 0 || height != heights[i-1] {
+            distinct = append(distinct, height)
+        }
+    }
+    return distinct
+}
+
+// overridesAt returns the overrides active at block number, oldest first
+func (c *Config) overridesAt(number uint64) []ConfigOverride {
+    count := 0
+    for count < len(c.Overrides) && c.Overrides[count].Number <= number {
+        count++
+    }
+    return c.Overrides[:count]
+}
+
+// checkpointSegment returns the PowBlockInterval in force at block number
+// and the checkpoint (or genesis) it applies from
+func (c *Config) checkpointSegment(number uint64) (start uint64, interval uint64) {
+    interval = c.PowBlockInterval
+    for _, override := range c.overridesAt(number) {
+        if override.PowBlockInterval != 0 {
+            start, interval = override.Number, override.PowBlockInterval
+        }
+    }
+    return start, interval
+}
+
+// ValidateOverrides checks that the overrides of config are in ascending
+// order above genesis and set values the chain can run with
+func ValidateOverrides(config *Config) error {
+    previous := uint64(0)
+    for _, override := range config.Overrides {
+        if override.Number <= previous {
+            return fmt.Errorf("%w: #%d does not follow #%d", ErrInvalidOverride, override.Number, previous)
+        }
+        previous = override.Number
+        if override.PowBlockInterval != 0 {
+            // The new interval counts from a checkpoint of the old one
+            start, interval := config.checkpointSegment(override.Number - 1)
+            if (override.Number-start)%interval != 0 {
+                return fmt.Errorf("%w: powBlockInterval changes at #%d, which is not a checkpoint", ErrInvalidOverride, override.Number)
+            }
+        }
+        if override.Emission != nil {
+            if err := ValidateEmission(*override.Emission); err != nil {
+                return fmt.Errorf("%w at #%d: %v", ErrInvalidOverride, override.Number, err)
+            }
+        }
+        if override.RewardDistribution != nil {
+            if err := ValidateRewards(*override.RewardDistribution); err != nil {
+                return fmt.Errorf("%w at #%d: %v", ErrInvalidOverride, override.Number, err)
+            }
+        }
+        if override.Slashing != nil {
+            if err := ValidateSlashing(*override.Slashing); err != nil {
+                return fmt.Errorf("%w at #%d: %v", ErrInvalidOverride, override.Number, err)
+            }
+        }
+    }
+    return nil
+}
+
+// CheckOverridesCompatible checks that a chain whose head is at head can
+// switch from overrides old to next: those at or below the head were already
+// applied and must stay as they are, the ones above may change freely.
+func CheckOverridesCompatible(old, next []ConfigOverride, head uint64) error {
+    applied := func(overrides []ConfigOverride) []ConfigOverride {
+        count := 0
+        for count < len(overrides) && overrides[count].Number <= head {
+            count++
+        }
+        return overrides[:count]
+    }
+    if !OverridesEqual(applied(old), applied(next)) {
+        return fmt.Errorf("%w: overrides up to head #%d changed", ErrIncompatibleOverride, head)
+    }
+    return nil
+}
+
+// OverridesEqual reports whether a and b change the same parameters at the
+// same heights
+func OverridesEqual(a, b []ConfigOverride) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if !reflect.DeepEqual(a[i], b[i]) {
+            return false
+        }
+    }
+    return true
+}
+
+// ValidateRewards checks that reward shares add up to the whole reward
+func ValidateRewards(distribution RewardConfig) error {
+    total := 0
+    for _, percent := range []int{distribution.MinerPercent, distribution.StakerPercent, distribution.EcosystemPercent, distribution.BurnPercent} {
+        if percent < 0 {
+            return fmt.Errorf("reward percentages must not be negative")
+        }
+        total += percent
+    }
+    if total != 100 {
+        return fmt.Errorf("reward percentages add up to %d, not 100", total)
+    }
+    if distribution.ProposerBonusPercent < 0 || distribution.ProposerBonusPercent > 100 {
+        return fmt.Errorf("proposerBonusPercent must be between 0 and 100")
+    }
+    return nil
+}
+
+// ValidateSlashing checks that slashing percentages are within 0 and 100
+func ValidateSlashing(slashing SlashingConfig) error {
+    for _, percent := range []int{slashing.DoubleSignPercent, slashing.WhistleblowerPercent, slashing.MinSignedPercent, slashing.DowntimeSlashPercent} {
+        if percent < 0 || percent > 100 {
+            return fmt.Errorf("slashing percentages must be between 0 and 100")
+        }
+    }
+    return nil
+}
@@ -0,0 +1,107 @@
+package hybrid_test
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+)
+
+func TestOverrideCheckpoints(t *testing.T) {
+    config := &hybrid.Config{
+        PowBlockInterval: 3,
+        Overrides:        []hybrid.ConfigOverride{{Number: 6, PowBlockInterval: 5}},
+    }
+    checkpoints := map[uint64]bool{3: true, 6: true, 11: true, 16: true}
+    for number := uint64(0); number <= 16; number++ {
+        if config.IsCheckpoint(number) != checkpoints[number] {
+            t.Fatalf("block #%d: checkpoint %v, want %v", number, config.IsCheckpoint(number), checkpoints[number])
+        }
+    }
+    for _, test := range []struct{ number, last, epoch uint64 }{
+        {0, 0, 0}, {2, 0, 0}, {3, 3, 1}, {5, 3, 1}, {6, 6, 2}, {10, 6, 2}, {11, 11, 3}, {16, 16, 4},
+    } {
+        if last := config.LastCheckpoint(test.number); last != test.last {
+            t.Fatalf("LastCheckpoint(%d) = %d, want %d", test.number, last, test.last)
+        }
+        if epoch := config.Epoch(config.LastCheckpoint(test.number)); epoch != test.epoch {
+            t.Fatalf("block #%d is in epoch %d, want %d", test.number, epoch, test.epoch)
+        }
+    }
+}
+
+func TestValidateOverrides(t *testing.T) {
+    rewards := blockchain.DefaultGenesis().Config.RewardDistribution
+    rewards.BurnPercent++
+    for _, test := range []struct {
+        name      string
+        overrides []hybrid.ConfigOverride
+    }{
+        {"at genesis", []hybrid.ConfigOverride{{Number: 0}}},
+        {"out of order", []hybrid.ConfigOverride{{Number: 8}, {Number: 4}}},
+        {"interval off checkpoint", []hybrid.ConfigOverride{{Number: 7, PowBlockInterval: 2}}},
+        {"rewards over 100%", []hybrid.ConfigOverride{{Number: 4, RewardDistribution: &rewards}}},
+        {"slashing over 100%", []hybrid.ConfigOverride{{Number: 4, Slashing: &hybrid.SlashingConfig{DoubleSignPercent: 101}}}},
+    } {
+        genesis := blockchain.DefaultGenesis()
+        genesis.Config.Overrides = test.overrides
+        if err := genesis.Validate(); !errors.Is(err, hybrid.ErrInvalidOverride) {
+            t.Fatalf("%s: got %v, want %v", test.name, err, hybrid.ErrInvalidOverride)
+        }
+    }
+}
+
+func TestOverridesApplyAtHeight(t *testing.T) {
+    rewards := blockchain.DefaultGenesis().Config.RewardDistribution
+    rewards.StakerPercent -= 5
+    rewards.BurnPercent += 5
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Config.PowBlockInterval = 3
+        genesis.Config.Overrides = []hybrid.ConfigOverride{{Number: 6, PowBlockInterval: 5, RewardDistribution: &rewards}}
+    })
+
+    for number := uint64(1); number <= 12; number++ {
+        block := tc.addBlock(t)
+        if want := number == 3 || number == 6 || number == 11; block.Header.Checkpoint != want {
+            t.Fatalf("block #%d: checkpoint %v, want %v", number, block.Header.Checkpoint, want)
+        }
+    }
+    tc.checkSupply(t)
+
+    // PoS blocks burn 5% more of the same emission from block 6 on
+    burned := func(number uint64) *big.Int {
+        return tc.GetBlockRewards(tc.CalculateHash(tc.GetBlockByNumber(number).Header)).Burned
+    }
+    want := new(big.Int).Add(burned(5), big.NewInt(5e18))
+    if burned(7).Cmp(want) != 0 {
+        t.Fatalf("block #7 burned %s, want %s", burned(7), want)
+    }
+
+    // Checkpoint 6 is finalized by the five-block epoch that follows it
+    if finalized := tc.GetFinalizedBlock().Header.Number.Uint64(); finalized != 6 {
+        t.Fatalf("finalized block #%d, want #6", finalized)
+    }
+}
+
+func TestCheckOverridesCompatible(t *testing.T) {
+    old := []hybrid.ConfigOverride{{Number: 4, PowBlockInterval: 2}, {Number: 10, PowBlockInterval: 4}}
+    for _, test := range []struct {
+        name string
+        next []hybrid.ConfigOverride
+        ok   bool
+    }{
+        {"unchanged", old, true},
+        {"moved above head", []hybrid.ConfigOverride{{Number: 4, PowBlockInterval: 2}, {Number: 12, PowBlockInterval: 4}}, true},
+        {"dropped above head", old[:1], true},
+        {"changed below head", []hybrid.ConfigOverride{{Number: 4, PowBlockInterval: 1}, {Number: 10, PowBlockInterval: 4}}, false},
+        {"dropped below head", old[1:], false},
+        {"added below head", append([]hybrid.ConfigOverride{{Number: 2, PowBlockInterval: 2}}, old...), false},
+    } {
+        err := hybrid.CheckOverridesCompatible(old, test.next, 8)
+        if test.ok && err != nil || !test.ok && !errors.Is(err, hybrid.ErrIncompatibleOverride) {
+            t.Fatalf("%s: got %v", test.name, err)
+        }
+    }
+}
@@ -0,0 +1,170 @@
+package hybrid_test
+
+import (
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// checkSupply checks that the head state holds the genesis holdings plus
+// everything minted since, less everything burned
+func (tc *testChain) checkSupply(t *testing.T) {
+    t.Helper()
+    genesis, err := tc.StateAt(tc.CalculateHash(tc.GetBlockByNumber(0).Header))
+    if err != nil {
+        t.Fatal(err)
+    }
+    head, statedb := tc.CurrentState()
+    want := new(big.Int).Add(genesis.Holdings(), statedb.Emitted())
+    want.Sub(want, statedb.Burned())
+    if holdings := statedb.Holdings(); holdings.Cmp(want) != 0 {
+        t.Fatalf("block #%s: holdings %s, want genesis %s + emitted %s - burned %s",
+            head.Header.Number, holdings, genesis.Holdings(), statedb.Emitted(), statedb.Burned())
+    }
+}
+
+// newTransfer returns a transfer of 1 wei from key to to, paying fees
+func newTransfer(t *testing.T, chainID uint64, key *keys.KeyPair, nonce uint64, to types.Address) *types.Transaction {
+    t.Helper()
+    tx := &types.Transaction{
+        Nonce:    nonce,
+        GasPrice: big.NewInt(1000000000),
+        Gas:      21000,
+        To:       &to,
+        Value:    big.NewInt(1),
+        Type:     types.TxRegular,
+        ChainID:  chainID,
+    }
+    if err := key.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+    return tx
+}
+
+func TestSupplyAfterRewards(t *testing.T) {
+    sender := newKey(t)
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Config.PowBlockInterval = 3
+        genesis.Alloc[sender.Address] = blockchain.GenesisAccount{Balance: big.NewInt(1e18)}
+    })
+
+    // PoS blocks and checkpoints, each with fees on top of the emission
+    checkpoints := 0
+    for nonce := uint64(0); nonce < 7; nonce++ {
+        block := tc.addBlock(t, newTransfer(t, tc.ChainID(), sender, nonce, types.Address{0xb}))
+        if block.Header.Checkpoint {
+            checkpoints++
+        }
+        tc.checkSupply(t)
+    }
+    if checkpoints == 0 {
+        t.Fatal("no checkpoint produced")
+    }
+    if statedb := tc.GetStateDB(); statedb.Emitted().Sign() == 0 || statedb.Burned().Sign() == 0 {
+        t.Fatalf("emitted %s and burned %s, want both positive", statedb.Emitted(), statedb.Burned())
+    }
+}
+
+func TestSupplyAfterEvidence(t *testing.T) {
+    reporter := newKey(t)
+    funds := big.NewInt(1e18)
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Alloc[reporter.Address] = blockchain.GenesisAccount{Balance: funds}
+    })
+    tc.addBlock(t)
+
+    // The slashed stake is burned except for the whistleblower's share
+    offender := blockchain.DevValidatorKeys()[0]
+    burned := tc.GetStateDB().Burned()
+    tc.addBlock(t, newEvidenceTransaction(t, tc.ChainID(), reporter, 0, offender, 1))
+    tc.checkSupply(t)
+
+    statedb := tc.GetStateDB()
+    if !statedb.IsJailed(offender.Address) {
+        t.Fatal("offender was not jailed")
+    }
+    if statedb.GetBalance(reporter.Address).Cmp(funds) <= 0 {
+        t.Fatal("whistleblower was not rewarded")
+    }
+    if statedb.Burned().Cmp(burned) <= 0 {
+        t.Fatal("slashed stake was not burned")
+    }
+}
+
+func TestSupplyAfterDowntime(t *testing.T) {
+    tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+        genesis.Config.Slashing.SignedBlocksWindow = 4
+        genesis.Config.Slashing.MinSignedPercent = 50
+    })
+
+    // The smallest validator stops precommitting; the other two are a quorum
+    signers := blockchain.DevValidatorKeys()
+    offline := signers[1]
+    for i := 0; i < 40 && !tc.GetStateDB().IsJailed(offline.Address); i++ {
+        block := tc.makeBlock(t)
+        blockHash := tc.CalculateHash(block.Header)
+        for _, signer := range []*keys.KeyPair{signers[0], signers[2]} {
+            block.Votes = append(block.Votes, signVote(signer, block.Header.Number.Uint64(), block.Header.Round, blockHash))
+        }
+        if err := tc.AddBlock(block); err != nil {
+            t.Fatal(err)
+        }
+        tc.checkSupply(t)
+    }
+    if !tc.GetStateDB().IsJailed(offline.Address) {
+        t.Fatal("offline validator was not jailed")
+    }
+}
+
+func TestSupplyTreasury(t *testing.T) {
+    unit := big.NewInt(1e18)
+    for _, test := range []struct {
+        name     string
+        treasury types.Address
+        burned   int64 // SELSI burned by a PoS block minting 100 SELSI
+    }{
+        // The miner share and the burn share
+        {"treasury", blockchain.DevTreasuryKey().Address, 45 + 3},
+        // The ecosystem share on top
+        {"no treasury", types.Address{}, 45 + 3 + 7},
+    } {
+        t.Run(test.name, func(t *testing.T) {
+            tc := newTestChain(t, func(genesis *blockchain.Genesis) {
+                genesis.Config.Treasury = test.treasury
+            })
+            tc.addBlock(t)
+            block := tc.addBlock(t)
+            tc.checkSupply(t)
+
+            rewards := tc.GetBlockRewards(tc.CalculateHash(block.Header))
+            if rewards == nil {
+                t.Fatal("no rewards recorded")
+            }
+            if want := new(big.Int).Mul(big.NewInt(test.burned), unit); rewards.Burned.Cmp(want) != 0 {
+                t.Fatalf("burned %s, want %s", rewards.Burned, want)
+            }
+            var paid *big.Int
+            for _, payout := range rewards.Payouts {
+                if payout.Role == types.RewardTreasury {
+                    paid = payout.Amount
+                }
+            }
+            if test.treasury == (types.Address{}) {
+                if paid != nil {
+                    t.Fatalf("ecosystem share of %s paid without a treasury", paid)
+                }
+                return
+            }
+            if want := new(big.Int).Mul(big.NewInt(7), unit); paid == nil || paid.Cmp(want) != 0 {
+                t.Fatalf("treasury paid %v, want %s", paid, want)
+            }
+            // Both blocks paid the treasury
+            if balance := tc.GetStateDB().GetBalance(test.treasury); balance.Cmp(new(big.Int).Mul(paid, big.NewInt(2))) != 0 {
+                t.Fatalf("treasury holds %s, want %s", balance, new(big.Int).Mul(paid, big.NewInt(2)))
+            }
+        })
+    }
+}
@@ -0,0 +1,114 @@
+package hybrid
+
+import (
+    "fmt"
+    "sync"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+const localInboxSize = 1024 // Messages a local validator can fall behind by
+
+// ConsensusMessage is a proposal or a vote of the agreement protocol
+type ConsensusMessage struct {
+    Proposal *types.Proposal `json:"proposal,omitempty"`
+    Vote     *types.Vote     `json:"vote,omitempty"`
+}
+
+// number returns the height the message belongs to
+func (m *ConsensusMessage) number() (uint64, bool) {
+    switch {
+    case m.Proposal != nil:
+        return m.Proposal.Number, true
+    case m.Vote != nil:
+        return m.Vote.Number, true
+    }
+    return 0, false
+}
+
+// Transport carries agreement messages between validators. Broadcast sends
+// to every other validator and must not block. Messages may be lost or
+// reordered: that costs extra rounds, never safety.
+type Transport interface {
+    Broadcast(msg *ConsensusMessage)
+    Messages() <-chan *ConsensusMessage
+}
+
+// LocalNetwork connects validators running in one process, such as the demo
+// validators of a single node. With a relay set it also reaches validators on
+// other nodes.
+type LocalNetwork struct {
+    members []*localTransport
+    relay   func(msg *ConsensusMessage)
+    mu      sync.RWMutex
+}
+
+// NewLocalNetwork creates an empty in-process network
+func NewLocalNetwork() *LocalNetwork {
+    return &LocalNetwork{}
+}
+
+// Join adds a validator to the network and returns its transport
+func (n *LocalNetwork) Join() Transport {
+    t := &localTransport{
+        network: n,
+        inbox:   make(chan *ConsensusMessage, localInboxSize),
+    }
+    n.mu.Lock()
+    n.members = append(n.members, t)
+    n.mu.Unlock()
+    return t
+}
+
+// SetRelay hands every message a member broadcasts to relay as well, e.g. to
+// send it to the peers. relay must not block.
+func (n *LocalNetwork) SetRelay(relay func(msg *ConsensusMessage)) {
+    n.mu.Lock()
+    n.relay = relay
+    n.mu.Unlock()
+}
+
+// Deliver hands msg, received from another node, to every member
+func (n *LocalNetwork) Deliver(msg *ConsensusMessage) {
+    n.mu.RLock()
+    defer n.mu.RUnlock()
+
+    for _, member := range n.members {
+        member.deliver(msg)
+    }
+}
+
+type localTransport struct {
+    network *LocalNetwork
+    inbox   chan *ConsensusMessage
+}
+
+// Broadcast delivers msg to every other member whose inbox has room, and to
+// the relay
+func (t *localTransport) Broadcast(msg *ConsensusMessage) {
+    t.network.mu.RLock()
+    defer t.network.mu.RUnlock()
+
+    for _, member := range t.network.members {
+        if member != t {
+            member.deliver(msg)
+        }
+    }
+    if t.network.relay != nil {
+        t.network.relay(msg)
+    }
+}
+
+// deliver queues msg unless the inbox is full
+func (t *localTransport) deliver(msg *ConsensusMessage) {
+    select {
+    case t.inbox <- msg:
+    default:
+        fmt.Printf("⚠️  Consensus message dropped: validator inbox full\n")
+    }
+}
+
+// Messages returns the messages broadcast by the other members or delivered
+// from other nodes
+func (t *localTransport) Messages() <-chan *ConsensusMessage {
+    return t.inbox
+}
@@ -0,0 +1,147 @@
+package hybrid
+
+import (
+    "bytes"
+    "fmt"
+    "math/big"
+    "sort"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+const (
+    defaultMaxValidators  = 100
+    maxValidatorSnapshots = 32 // Epoch snapshots kept in memory
+)
+
+// ChainReader gives the engine access to headers and to the post-state of
+// stored blocks
+type ChainReader = consensus.ChainReader
+
+// ValidatorSet is the active validator set of one epoch. It is taken from the
+// post-state of the block that opens the epoch (genesis or a PoW checkpoint),
+// so stake changes inside an epoch only take effect in the next one.
+type ValidatorSet struct {
+    Epoch      uint64      // Checkpoints up to the one opening the epoch (Config.Epoch)
+    Checkpoint types.Hash  // Block whose post-state the set was taken from
+    Validators []Validator // Ordered by stake, highest first
+    TotalStake *big.Int
+}
+
+// NewValidatorSet selects the stakers in statedb with at least MinimumStake
+// that are not jailed, keeping the MaxValidators largest. Equal stakes are
+// ordered by address.
+func NewValidatorSet(statedb *state.StateDB, config *Config) *ValidatorSet {
+    set := &ValidatorSet{TotalStake: big.NewInt(0)}
+    for _, addr := range statedb.Stakers() {
+        stake := statedb.GetStake(addr)
+        if config.MinimumStake != nil && stake.Cmp(config.MinimumStake) < 0 {
+            continue
+        }
+        if statedb.IsJailed(addr) {
+            continue
+        }
+        set.Validators = append(set.Validators, Validator{
+            Address: addr,
+            Stake:   stake,
+            Power:   new(big.Int).Div(stake, big.NewInt(1e18)).Int64(),
+        })
+    }
+    sort.SliceStable(set.Validators, func(i, j int) bool {
+        if c := set.Validators[i].Stake.Cmp(set.Validators[j].Stake); c != 0 {
+            return c > 0
+        }
+        return bytes.Compare(set.Validators[i].Address[:], set.Validators[j].Address[:]) < 0
+    })
+
+    limit := config.MaxValidators
+    if limit == 0 {
+        limit = defaultMaxValidators
+    }
+    if uint64(len(set.Validators)) > limit {
+        set.Validators = set.Validators[:limit]
+    }
+    for _, validator := range set.Validators {
+        set.TotalStake.Add(set.TotalStake, validator.Stake)
+    }
+    return set
+}
+
+// Len returns the number of active validators
+func (s *ValidatorSet) Len() int {
+    return len(s.Validators)
+}
+
+// Get returns the validator with the given address
+func (s *ValidatorSet) Get(addr types.Address) (Validator, bool) {
+    for _, validator := range s.Validators {
+        if validator.Address == addr {
+            return validator, true
+        }
+    }
+    return Validator{}, false
+}
+
+// Contains reports whether addr is an active validator
+func (s *ValidatorSet) Contains(addr types.Address) bool {
+    _, ok := s.Get(addr)
+    return ok
+}
+
+// StakeOf returns the stake addr validates with, or zero if it is not active
+func (s *ValidatorSet) StakeOf(addr types.Address) *big.Int {
+    if validator, ok := s.Get(addr); ok {
+        return new(big.Int).Set(validator.Stake)
+    }
+    return big.NewInt(0)
+}
+
+// epochStart returns the number of the block whose post-state holds the
+// validator set for the block at number
+func (h *HybridEngine) epochStart(number uint64) uint64 {
+    if number == 0 {
+        return 0
+    }
+    return h.config.LastCheckpoint(number - 1)
+}
+
+// Validators returns the validator set for the block built on parent. The
+// set is computed once per epoch and cached by the hash of the block that
+// opens the epoch, so it is the same on every branch sharing that block.
+func (h *HybridEngine) Validators(chain ChainReader, parent *types.Header) (*ValidatorSet, error) {
+    start := h.epochStart(parent.Number.Uint64() + 1)
+    header := parent
+    for header.Number.Uint64() > start {
+        ancestor := chain.GetHeader(header.ParentHash)
+        if ancestor == nil {
+            return nil, fmt.Errorf("%w: parent %x of block #%s", ErrUnknownAncestor, header.ParentHash[:4], header.Number)
+        }
+        header = ancestor
+    }
+    startHash := hash.CalculateBlockHash(header)
+
+    h.snapshotMu.Lock()
+    defer h.snapshotMu.Unlock()
+
+    if set, ok := h.snapshots[startHash]; ok {
+        return set, nil
+    }
+    statedb, err := chain.StateAt(startHash)
+    if err != nil {
+        return nil, err
+    }
+    set := NewValidatorSet(statedb, h.config)
+    set.Epoch = h.config.Epoch(start)
+    set.Checkpoint = startHash
+
+    h.snapshots[startHash] = set
+    h.snapshotOrder = append(h.snapshotOrder, startHash)
+    if len(h.snapshotOrder) > maxValidatorSnapshots {
+        delete(h.snapshots, h.snapshotOrder[0])
+        h.snapshotOrder = h.snapshotOrder[1:]
+    }
+    fmt.Printf("👥 Validator set for epoch %d: %d validators, %s wei staked\n", set.Epoch, set.Len(), set.TotalStake)
+    return set, nil
+}
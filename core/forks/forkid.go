@@ -0,0 +1,122 @@
+package forks
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "hash/crc32"
+    "math"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+var (
+    ErrRemoteStale              = errors.New("remote needs update")
+    ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// ID identifies the rules a node currently runs (EIP-2124 style): Hash is
+// the CRC32 checksum of the genesis hash, the digest of the consensus config
+// the chain started with and the heights of the upgrades that already
+// activated, Next the height of the next scheduled upgrade, or 0 if none is
+// known. Peers compare IDs at handshake, so nodes that did not
+// schedule an upgrade the others passed are told apart before they exchange
+// blocks.
+type ID struct {
+    Hash [4]byte `json:"hash"`
+    Next uint64  `json:"next"`
+}
+
+// String formats the ID as hash/next
+func (id ID) String() string {
+    return fmt.Sprintf("%x/%d", id.Hash, id.Next)
+}
+
+// NewID returns the fork ID of a chain with the given genesis, initial
+// config digest and upgrade heights (ascending, after genesis) whose head is
+// at head
+func NewID(genesis types.Hash, config types.Hash, heights []uint64, head uint64) ID {
+    checksum := initialChecksum(genesis, config)
+    for _, height := range heights {
+        if height > head {
+            return ID{Hash: checksumBytes(checksum), Next: height}
+        }
+        checksum = checksumUpdate(checksum, height)
+    }
+    return ID{Hash: checksumBytes(checksum)}
+}
+
+// CheckID validates the fork ID a peer announced against the local chain.
+// A peer is accepted if it runs the same rules, or if it is only behind or
+// ahead on a schedule both share:
+//
+//   - same checksum: accepted unless the peer announces a next upgrade the
+//     local head already passed without activating it
+//   - checksum of an earlier point of the local schedule: the peer is
+//     syncing; accepted if its next upgrade is the one local nodes
+//     activated next, otherwise it runs an outdated schedule
+//   - checksum of a later point of the local schedule: the local node is
+//     syncing and accepts
+//
+// Anything else is a different chain or an incompatible schedule.
+func CheckID(genesis types.Hash, config types.Hash, heights []uint64, head uint64, remote ID) error {
+    local := NewID(genesis, config, heights, head)
+    heights = append(append([]uint64{}, heights...), math.MaxUint64)
+
+    // sums[i] is the checksum after the first i upgrades
+    sums := make([][4]byte, len(heights))
+    checksum := initialChecksum(genesis, config)
+    for i, height := range heights {
+        sums[i] = checksumBytes(checksum)
+        if height != math.MaxUint64 {
+            checksum = checksumUpdate(checksum, height)
+        }
+    }
+
+    for i, height := range heights {
+        if head >= height {
+            continue
+        }
+        // i is the first upgrade still ahead of the local head
+        if sums[i] == remote.Hash {
+            if remote.Next > 0 && head >= remote.Next {
+                return fmt.Errorf("%w: peer activates an upgrade at #%d, local head #%d", ErrLocalIncompatibleOrStale, remote.Next, head)
+            }
+            return nil
+        }
+        for j := 0; j < i; j++ {
+            if sums[j] == remote.Hash {
+                if heights[j] != remote.Next {
+                    return fmt.Errorf("%w: peer %s, next upgrade at #%d", ErrRemoteStale, remote, heights[j])
+                }
+                return nil
+            }
+        }
+        for j := i + 1; j < len(sums); j++ {
+            if sums[j] == remote.Hash {
+                return nil
+            }
+        }
+        break
+    }
+    return fmt.Errorf("%w: peer %s, local %s", ErrLocalIncompatibleOrStale, remote, local)
+}
+
+// initialChecksum starts a fork ID checksum from the genesis hash and the
+// config digest, so chains that share a genesis block but not their
+// consensus parameters are told apart
+func initialChecksum(genesis types.Hash, config types.Hash) uint32 {
+    return crc32.Update(crc32.ChecksumIEEE(genesis[:]), crc32.IEEETable, config[:])
+}
+
+// checksumUpdate adds an activation height to a fork ID checksum
+func checksumUpdate(checksum uint32, height uint64) uint32 {
+    var blob [8]byte
+    binary.BigEndian.PutUint64(blob[:], height)
+    return crc32.Update(checksum, crc32.IEEETable, blob[:])
+}
+
+func checksumBytes(checksum uint32) [4]byte {
+    var blob [4]byte
+    binary.BigEndian.PutUint32(blob[:], checksum)
+    return blob
+}
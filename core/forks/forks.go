@@ -0,0 +1,130 @@
+// Package forks schedules the protocol upgrades of a chain. Every rule change
+// that would split nodes running old code from nodes running new code is
+// given a name here and activates at the block height the chain config sets
+// for it, so the whole network switches at the same block.
+package forks
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    "sort"
+)
+
+// Protocol upgrades known to this node
+const (
+    // ExtraDataLimit caps the extra data of block headers at
+    // hybrid.MaxExtraDataSize
+    ExtraDataLimit = "extraDataLimit"
+    // BlockGasLimit caps the gas the transactions of a block may use at
+    // state.BlockGasLimit
+    BlockGasLimit = "blockGasLimit"
+)
+
+// Known lists the upgrades this node implements
+var Known = []string{ExtraDataLimit, BlockGasLimit}
+
+var (
+    ErrUnknownUpgrade      = errors.New("unknown protocol upgrade")
+    ErrIncompatibleUpgrade = errors.New("upgrade schedule rewrites chain history")
+)
+
+// Schedule maps upgrade names to the height they activate at. Upgrades that
+// are not listed never activate.
+type Schedule map[string]uint64
+
+// IsActive reports whether fork is active in the block at number
+func (s Schedule) IsActive(fork string, number *big.Int) bool {
+    height, scheduled := s[fork]
+    return scheduled && number != nil && number.Cmp(new(big.Int).SetUint64(height)) >= 0
+}
+
+// Validate rejects upgrades this node does not implement. Running a chain
+// with such an upgrade would silently keep the old rules past its height.
+func (s Schedule) Validate() error {
+    for name := range s {
+        if !isKnown(name) {
+            return fmt.Errorf("%w: %q (supported: %v)", ErrUnknownUpgrade, name, Known)
+        }
+    }
+    return nil
+}
+
+// Equal reports whether s and other schedule the same upgrades at the same
+// heights
+func (s Schedule) Equal(other Schedule) bool {
+    if len(s) != len(other) {
+        return false
+    }
+    for name, height := range s {
+        if otherHeight, ok := other[name]; !ok || otherHeight != height {
+            return false
+        }
+    }
+    return true
+}
+
+// Heights returns the distinct activation heights after genesis in
+// ascending order. Upgrades active from genesis are part of the chain's
+// initial rules, not a fork of it.
+func (s Schedule) Heights() []uint64 {
+    seen := make(map[uint64]bool)
+    var heights []uint64
+    for _, height := range s {
+        if height > 0 && !seen[height] {
+            seen[height] = true
+            heights = append(heights, height)
+        }
+    }
+    sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+    return heights
+}
+
+// CheckCompatible checks that a chain whose head is at head can switch from
+// schedule s to next. Upgrades may be added, moved or dropped freely above
+// the head, but not where the blocks were already processed under s.
+func (s Schedule) CheckCompatible(next Schedule, head uint64) error {
+    for _, name := range scheduledNames(s, next) {
+        old, wasScheduled := s[name]
+        height, isScheduled := next[name]
+        if wasScheduled == isScheduled && old == height {
+            continue
+        }
+        if (wasScheduled && old <= head) || (isScheduled && height <= head) {
+            return fmt.Errorf("%w: %s moves from %s to %s, head is #%d", ErrIncompatibleUpgrade, name, describe(old, wasScheduled), describe(height, isScheduled), head)
+        }
+    }
+    return nil
+}
+
+// scheduledNames returns the names scheduled in either a or b, sorted
+func scheduledNames(a, b Schedule) []string {
+    var names []string
+    for name := range a {
+        names = append(names, name)
+    }
+    for name := range b {
+        if _, ok := a[name]; !ok {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    return names
+}
+
+// describe formats an activation height for error messages
+func describe(height uint64, scheduled bool) string {
+    if !scheduled {
+        return "unscheduled"
+    }
+    return fmt.Sprintf("#%d", height)
+}
+
+func isKnown(name string) bool {
+    for _, known := range Known {
+        if known == name {
+            return true
+        }
+    }
+    return false
+}
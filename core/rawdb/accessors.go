@@ -0,0 +1,314 @@
+package rawdb
+
+import (
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// Database key schema
+var (
+    genesisHashKey = []byte("GenesisHash")
+    genesisSpecKey = []byte("GenesisSpec")
+    headBlockKey   = []byte("LastBlock")
+    finalizedKey   = []byte("LastFinalized")
+    safeKey        = []byte("LastSafe")
+    badBlocksKey   = []byte("BadBlocks")
+
+    canonicalPrefix   = []byte("h") // canonicalPrefix + number -> hash
+    blockNumberPrefix = []byte("H") // blockNumberPrefix + hash -> number
+    blockPrefix       = []byte("b") // blockPrefix + hash -> block
+    statePrefix       = []byte("s") // statePrefix + block hash -> post-state
+    txLookupPrefix    = []byte("l") // txLookupPrefix + tx hash -> block hash
+    rewardsPrefix     = []byte("r") // rewardsPrefix + block hash -> reward breakdown
+    blockHashesPrefix = []byte("n") // blockHashesPrefix + number -> hashes of the stored blocks
+)
+
+// Writer is the write side of a Batch; chain data is always written in batches
+type Writer interface {
+    Put(key []byte, value []byte)
+    Delete(key []byte)
+}
+
+func encodeNumber(number uint64) []byte {
+    return binary.BigEndian.AppendUint64(nil, number)
+}
+
+func canonicalKey(number uint64) []byte {
+    return append(append([]byte{}, canonicalPrefix...), encodeNumber(number)...)
+}
+
+func blockNumberKey(hash types.Hash) []byte {
+    return append(append([]byte{}, blockNumberPrefix...), hash[:]...)
+}
+
+func blockHashesKey(number uint64) []byte {
+    return append(append([]byte{}, blockHashesPrefix...), encodeNumber(number)...)
+}
+
+func blockKey(hash types.Hash) []byte {
+    return append(append([]byte{}, blockPrefix...), hash[:]...)
+}
+
+func stateKey(hash types.Hash) []byte {
+    return append(append([]byte{}, statePrefix...), hash[:]...)
+}
+
+func txLookupKey(hash types.Hash) []byte {
+    return append(append([]byte{}, txLookupPrefix...), hash[:]...)
+}
+
+func rewardsKey(hash types.Hash) []byte {
+    return append(append([]byte{}, rewardsPrefix...), hash[:]...)
+}
+
+// ReadGenesisHash returns the hash of the genesis block the database was initialised with
+func ReadGenesisHash(db Database) (types.Hash, bool) {
+    data, err := db.Get(genesisHashKey)
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteGenesisHash stores the genesis block hash
+func WriteGenesisHash(w Writer, hash types.Hash) {
+    w.Put(genesisHashKey, hash[:])
+}
+
+// ReadGenesisSpec returns the raw genesis specification the database was initialised with
+func ReadGenesisSpec(db Database) []byte {
+    data, _ := db.Get(genesisSpecKey)
+    return data
+}
+
+// WriteGenesisSpec stores the raw genesis specification
+func WriteGenesisSpec(w Writer, spec []byte) {
+    w.Put(genesisSpecKey, spec)
+}
+
+// ReadHeadBlockHash returns the hash of the current canonical head
+func ReadHeadBlockHash(db Database) (types.Hash, bool) {
+    data, err := db.Get(headBlockKey)
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteHeadBlockHash stores the hash of the current canonical head
+func WriteHeadBlockHash(w Writer, hash types.Hash) {
+    w.Put(headBlockKey, hash[:])
+}
+
+// ReadFinalizedBlockHash returns the hash of the latest finalized block
+func ReadFinalizedBlockHash(db Database) (types.Hash, bool) {
+    data, err := db.Get(finalizedKey)
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteFinalizedBlockHash stores the hash of the latest finalized block
+func WriteFinalizedBlockHash(w Writer, hash types.Hash) {
+    w.Put(finalizedKey, hash[:])
+}
+
+// ReadSafeBlockHash returns the hash of the latest safe block
+func ReadSafeBlockHash(db Database) (types.Hash, bool) {
+    data, err := db.Get(safeKey)
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteSafeBlockHash stores the hash of the latest safe block
+func WriteSafeBlockHash(w Writer, hash types.Hash) {
+    w.Put(safeKey, hash[:])
+}
+
+// ReadBadBlocks returns the raw bad block registry
+func ReadBadBlocks(db Database) []byte {
+    data, _ := db.Get(badBlocksKey)
+    return data
+}
+
+// WriteBadBlocks stores the raw bad block registry
+func WriteBadBlocks(w Writer, data []byte) {
+    w.Put(badBlocksKey, data)
+}
+
+// ReadCanonicalHash returns the canonical block hash at number
+func ReadCanonicalHash(db Database, number uint64) (types.Hash, bool) {
+    data, err := db.Get(canonicalKey(number))
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteCanonicalHash marks hash as the canonical block at number
+func WriteCanonicalHash(w Writer, hash types.Hash, number uint64) {
+    w.Put(canonicalKey(number), hash[:])
+}
+
+// DeleteCanonicalHash removes the canonical mapping at number
+func DeleteCanonicalHash(w Writer, number uint64) {
+    w.Delete(canonicalKey(number))
+}
+
+// ReadBlockHashes returns the hashes of every block stored at number,
+// canonical or not
+func ReadBlockHashes(db Database, number uint64) []types.Hash {
+    data, err := db.Get(blockHashesKey(number))
+    if err != nil || len(data)%len(types.Hash{}) != 0 {
+        return nil
+    }
+    hashes := make([]types.Hash, len(data)/len(types.Hash{}))
+    for i := range hashes {
+        copy(hashes[i][:], data[i*len(types.Hash{}):])
+    }
+    return hashes
+}
+
+// WriteBlockHashes stores the hashes of every block stored at number
+func WriteBlockHashes(w Writer, number uint64, hashes []types.Hash) {
+    data := make([]byte, 0, len(hashes)*len(types.Hash{}))
+    for _, hash := range hashes {
+        data = append(data, hash[:]...)
+    }
+    w.Put(blockHashesKey(number), data)
+}
+
+// DeleteBlockHashes removes the hashes of the blocks stored at number
+func DeleteBlockHashes(w Writer, number uint64) {
+    w.Delete(blockHashesKey(number))
+}
+
+// ReadBlockNumber returns the number of the block with the given hash
+func ReadBlockNumber(db Database, hash types.Hash) (uint64, bool) {
+    data, err := db.Get(blockNumberKey(hash))
+    if err != nil || len(data) != 8 {
+        return 0, false
+    }
+    return binary.BigEndian.Uint64(data), true
+}
+
+// HasBlock checks if the block with the given hash is stored
+func HasBlock(db Database, hash types.Hash) bool {
+    return db.Has(blockKey(hash))
+}
+
+// ReadBlock returns the block with the given hash
+func ReadBlock(db Database, hash types.Hash) (*types.Block, error) {
+    data, err := db.Get(blockKey(hash))
+    if err != nil {
+        return nil, err
+    }
+    block := new(types.Block)
+    if err := json.Unmarshal(data, block); err != nil {
+        return nil, fmt.Errorf("corrupt block %x: %w", hash[:4], err)
+    }
+    return block, nil
+}
+
+// WriteBlock stores block together with its hash to number index
+func WriteBlock(w Writer, hash types.Hash, block *types.Block) error {
+    data, err := json.Marshal(block)
+    if err != nil {
+        return err
+    }
+    w.Put(blockKey(hash), data)
+    w.Put(blockNumberKey(hash), encodeNumber(block.Header.Number.Uint64()))
+    return nil
+}
+
+// DeleteBlock removes the block and its hash to number index
+func DeleteBlock(w Writer, hash types.Hash) {
+    w.Delete(blockKey(hash))
+    w.Delete(blockNumberKey(hash))
+}
+
+// ReadState returns the post-state of the block with the given hash
+func ReadState(db Database, blockHash types.Hash) (*state.StateDB, error) {
+    data, err := db.Get(stateKey(blockHash))
+    if err != nil {
+        return nil, err
+    }
+    statedb, err := state.Decode(data)
+    if err != nil {
+        return nil, fmt.Errorf("corrupt state for block %x: %w", blockHash[:4], err)
+    }
+    return statedb, nil
+}
+
+// WriteState stores the post-state of the block with the given hash
+func WriteState(w Writer, blockHash types.Hash, statedb *state.StateDB) error {
+    data, err := statedb.Encode()
+    if err != nil {
+        return err
+    }
+    w.Put(stateKey(blockHash), data)
+    return nil
+}
+
+// HasState checks if the post-state of the block with the given hash is stored
+func HasState(db Database, blockHash types.Hash) bool {
+    return db.Has(stateKey(blockHash))
+}
+
+// DeleteState removes the post-state of the block with the given hash
+func DeleteState(w Writer, blockHash types.Hash) {
+    w.Delete(stateKey(blockHash))
+}
+
+// ReadTxLookup returns the hash of the canonical block containing a transaction
+func ReadTxLookup(db Database, txHash types.Hash) (types.Hash, bool) {
+    data, err := db.Get(txLookupKey(txHash))
+    if err != nil || len(data) != len(types.Hash{}) {
+        return types.Hash{}, false
+    }
+    return types.Hash(data), true
+}
+
+// WriteTxLookup indexes a transaction under the block that contains it
+func WriteTxLookup(w Writer, txHash types.Hash, blockHash types.Hash) {
+    w.Put(txLookupKey(txHash), blockHash[:])
+}
+
+// DeleteTxLookup removes a transaction index entry
+func DeleteTxLookup(w Writer, txHash types.Hash) {
+    w.Delete(txLookupKey(txHash))
+}
+
+// ReadRewards returns how the reward of the block with the given hash was paid out
+func ReadRewards(db Database, blockHash types.Hash) (*types.RewardBreakdown, error) {
+    data, err := db.Get(rewardsKey(blockHash))
+    if err != nil {
+        return nil, err
+    }
+    rewards := new(types.RewardBreakdown)
+    if err := json.Unmarshal(data, rewards); err != nil {
+        return nil, fmt.Errorf("corrupt rewards for block %x: %w", blockHash[:4], err)
+    }
+    return rewards, nil
+}
+
+// WriteRewards stores the reward breakdown of the block with the given hash
+func WriteRewards(w Writer, blockHash types.Hash, rewards *types.RewardBreakdown) error {
+    data, err := json.Marshal(rewards)
+    if err != nil {
+        return err
+    }
+    w.Put(rewardsKey(blockHash), data)
+    return nil
+}
+
+// DeleteRewards removes the reward breakdown of the block with the given hash
+func DeleteRewards(w Writer, blockHash types.Hash) {
+    w.Delete(rewardsKey(blockHash))
+}
@@ -0,0 +1,121 @@
+// Package rawdb implements the on-disk storage of chain data
+package rawdb
+
+import (
+    "errors"
+    "sync"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Database is a minimal key-value store used for chain data
+type Database interface {
+    Has(key []byte) bool
+    Get(key []byte) ([]byte, error)
+    Put(key []byte, value []byte) error
+    Delete(key []byte) error
+    NewBatch() Batch
+    Close() error
+}
+
+// Batch collects writes that are applied to the database atomically
+type Batch interface {
+    Put(key []byte, value []byte)
+    Delete(key []byte)
+    Write() error
+}
+
+// batchOp is a single queued write; a nil value means delete
+type batchOp struct {
+    key   []byte
+    value []byte
+}
+
+// MemoryDatabase is an in-memory Database, used for tests and ephemeral nodes
+type MemoryDatabase struct {
+    data map[string][]byte
+    mu   sync.RWMutex
+}
+
+// NewMemoryDatabase creates an empty in-memory database
+func NewMemoryDatabase() *MemoryDatabase {
+    return &MemoryDatabase{
+        data: make(map[string][]byte),
+    }
+}
+
+// Has checks if key exists
+func (db *MemoryDatabase) Has(key []byte) bool {
+    db.mu.RLock()
+    defer db.mu.RUnlock()
+
+    _, exists := db.data[string(key)]
+    return exists
+}
+
+// Get returns a copy of the value stored at key
+func (db *MemoryDatabase) Get(key []byte) ([]byte, error) {
+    db.mu.RLock()
+    defer db.mu.RUnlock()
+
+    if value, exists := db.data[string(key)]; exists {
+        return append([]byte(nil), value...), nil
+    }
+    return nil, ErrNotFound
+}
+
+// Put stores value at key
+func (db *MemoryDatabase) Put(key []byte, value []byte) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+
+    db.data[string(key)] = append([]byte(nil), value...)
+    return nil
+}
+
+// Delete removes key
+func (db *MemoryDatabase) Delete(key []byte) error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+
+    delete(db.data, string(key))
+    return nil
+}
+
+// NewBatch creates a batch bound to the database
+func (db *MemoryDatabase) NewBatch() Batch {
+    return &memoryBatch{db: db}
+}
+
+// Close is a no-op for the in-memory database
+func (db *MemoryDatabase) Close() error {
+    return nil
+}
+
+type memoryBatch struct {
+    db  *MemoryDatabase
+    ops []batchOp
+}
+
+func (b *memoryBatch) Put(key []byte, value []byte) {
+    b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...), value: append([]byte{}, value...)})
+}
+
+func (b *memoryBatch) Delete(key []byte) {
+    b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...)})
+}
+
+func (b *memoryBatch) Write() error {
+    b.db.mu.Lock()
+    defer b.db.mu.Unlock()
+
+    for _, op := range b.ops {
+        if op.value == nil {
+            delete(b.db.data, string(op.key))
+        } else {
+            b.db.data[string(op.key)] = op.value
+        }
+    }
+    b.ops = nil
+    return nil
+}
@@ -0,0 +1,258 @@
+package rawdb
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "hash/crc32"
+    "io"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+const logFileName = "chain.log"
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// FileDatabase is a Database persisted as an append-only log of batches.
+// Every batch is written as a single checksummed record and synced before it
+// becomes visible, so a crash can only lose the batch being written, never
+// apply half of it.
+type FileDatabase struct {
+    file *os.File
+    size int64 // Offset of the end of the last complete record
+    data map[string][]byte
+    mu   sync.RWMutex
+}
+
+// OpenFileDatabase opens (or creates) the database stored in dir, replaying
+// the log and discarding a trailing incomplete write
+func OpenFileDatabase(dir string) (*FileDatabase, error) {
+    if err := os.MkdirAll(dir, 0700); err != nil {
+        return nil, fmt.Errorf("failed to create database directory: %w", err)
+    }
+
+    file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0600)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open database: %w", err)
+    }
+
+    db := &FileDatabase{
+        file: file,
+        data: make(map[string][]byte),
+    }
+    if err := db.replay(); err != nil {
+        file.Close()
+        return nil, err
+    }
+    return db, nil
+}
+
+// replay rebuilds the in-memory view from the log
+func (db *FileDatabase) replay() error {
+    content, err := io.ReadAll(db.file)
+    if err != nil {
+        return fmt.Errorf("failed to read database: %w", err)
+    }
+
+    offset := 0
+    for offset < len(content) {
+        ops, size, err := decodeRecord(content[offset:])
+        if err != nil {
+            break
+        }
+        db.apply(ops)
+        offset += size
+    }
+
+    if offset < len(content) {
+        fmt.Printf("⚠️  Discarding %d bytes of incomplete database write\n", len(content)-offset)
+        if err := db.file.Truncate(int64(offset)); err != nil {
+            return fmt.Errorf("failed to repair database: %w", err)
+        }
+    }
+    db.size = int64(offset)
+    _, err = db.file.Seek(db.size, io.SeekStart)
+    return err
+}
+
+// Has checks if key exists
+func (db *FileDatabase) Has(key []byte) bool {
+    db.mu.RLock()
+    defer db.mu.RUnlock()
+
+    _, exists := db.data[string(key)]
+    return exists
+}
+
+// Get returns a copy of the value stored at key
+func (db *FileDatabase) Get(key []byte) ([]byte, error) {
+    db.mu.RLock()
+    defer db.mu.RUnlock()
+
+    if value, exists := db.data[string(key)]; exists {
+        return append([]byte(nil), value...), nil
+    }
+    return nil, ErrNotFound
+}
+
+// Put stores value at key
+func (db *FileDatabase) Put(key []byte, value []byte) error {
+    batch := db.NewBatch()
+    batch.Put(key, value)
+    return batch.Write()
+}
+
+// Delete removes key
+func (db *FileDatabase) Delete(key []byte) error {
+    batch := db.NewBatch()
+    batch.Delete(key)
+    return batch.Write()
+}
+
+// NewBatch creates a batch bound to the database
+func (db *FileDatabase) NewBatch() Batch {
+    return &fileBatch{db: db}
+}
+
+// Close closes the underlying log file
+func (db *FileDatabase) Close() error {
+    db.mu.Lock()
+    defer db.mu.Unlock()
+
+    return db.file.Close()
+}
+
+// writeOps appends ops as one record and applies them once durable
+func (db *FileDatabase) writeOps(ops []batchOp) error {
+    if len(ops) == 0 {
+        return nil
+    }
+    record := encodeRecord(ops)
+
+    db.mu.Lock()
+    defer db.mu.Unlock()
+
+    if _, err := db.file.Write(record); err != nil {
+        db.rollback()
+        return fmt.Errorf("failed to write database: %w", err)
+    }
+    if err := db.file.Sync(); err != nil {
+        db.rollback()
+        return fmt.Errorf("failed to sync database: %w", err)
+    }
+    db.size += int64(len(record))
+    db.apply(ops)
+    return nil
+}
+
+// rollback drops a partially written record so later records stay readable
+func (db *FileDatabase) rollback() {
+    db.file.Truncate(db.size)
+    db.file.Seek(db.size, io.SeekStart)
+}
+
+func (db *FileDatabase) apply(ops []batchOp) {
+    for _, op := range ops {
+        if op.value == nil {
+            delete(db.data, string(op.key))
+        } else {
+            db.data[string(op.key)] = op.value
+        }
+    }
+}
+
+type fileBatch struct {
+    db  *FileDatabase
+    ops []batchOp
+}
+
+func (b *fileBatch) Put(key []byte, value []byte) {
+    b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...), value: append([]byte{}, value...)})
+}
+
+func (b *fileBatch) Delete(key []byte) {
+    b.ops = append(b.ops, batchOp{key: append([]byte(nil), key...)})
+}
+
+func (b *fileBatch) Write() error {
+    err := b.db.writeOps(b.ops)
+    b.ops = nil
+    return err
+}
+
+// Record layout: [length uint32][crc32 uint32][payload]
+// Payload: repeated [op byte][key length uint32][key][value length uint32][value]
+const (
+    opPut    byte = 1
+    opDelete byte = 2
+)
+
+func encodeRecord(ops []batchOp) []byte {
+    var payload []byte
+    for _, op := range ops {
+        if op.value == nil {
+            payload = append(payload, opDelete)
+        } else {
+            payload = append(payload, opPut)
+        }
+        payload = binary.BigEndian.AppendUint32(payload, uint32(len(op.key)))
+        payload = append(payload, op.key...)
+        payload = binary.BigEndian.AppendUint32(payload, uint32(len(op.value)))
+        payload = append(payload, op.value...)
+    }
+
+    record := make([]byte, 8, 8+len(payload))
+    binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+    binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+    return append(record, payload...)
+}
+
+func decodeRecord(data []byte) ([]batchOp, int, error) {
+    if len(data) < 8 {
+        return nil, 0, errCorruptRecord
+    }
+    length := int(binary.BigEndian.Uint32(data[0:4]))
+    if len(data)-8 < length {
+        return nil, 0, errCorruptRecord
+    }
+    payload := data[8 : 8+length]
+    if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[4:8]) {
+        return nil, 0, errCorruptRecord
+    }
+
+    var ops []batchOp
+    for len(payload) > 0 {
+        op := payload[0]
+        key, rest, err := readChunk(payload[1:])
+        if err != nil {
+            return nil, 0, err
+        }
+        value, rest, err := readChunk(rest)
+        if err != nil {
+            return nil, 0, err
+        }
+        switch op {
+        case opPut:
+            ops = append(ops, batchOp{key: key, value: append([]byte{}, value...)})
+        case opDelete:
+            ops = append(ops, batchOp{key: key})
+        default:
+            return nil, 0, errCorruptRecord
+        }
+        payload = rest
+    }
+    return ops, 8 + length, nil
+}
+
+func readChunk(data []byte) ([]byte, []byte, error) {
+    if len(data) < 4 {
+        return nil, nil, errCorruptRecord
+    }
+    length := int(binary.BigEndian.Uint32(data[:4]))
+    if len(data)-4 < length {
+        return nil, nil, errCorruptRecord
+    }
+    return append([]byte(nil), data[4:4+length]...), data[4+length:], nil
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+    "encoding/json"
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// Dump is the serialisable form of a StateDB
+type Dump struct {
+    Accounts   map[types.Address]DumpAccount   `json:"accounts"`
+    Stakes     map[types.Address]*big.Int      `json:"stakes"`
+    Validators map[types.Address]ValidatorInfo `json:"validators,omitempty"`
+    Evidence   []types.Hash                    `json:"evidence,omitempty"`
+    Emitted    *big.Int                        `json:"emitted,omitempty"`
+    Burned     *big.Int                        `json:"burned,omitempty"`
+}
+
+// DumpAccount is the serialisable form of an Account
+type DumpAccount struct {
+    Balance *big.Int `json:"balance"`
+    Nonce   uint64   `json:"nonce"`
+    Code    []byte   `json:"code,omitempty"`
+}
+
+// Dump returns a snapshot of the state that can be encoded
+func (s *StateDB) Dump() *Dump {
+    dump := &Dump{
+        Accounts: make(map[types.Address]DumpAccount, len(s.accounts)),
+        Stakes:   make(map[types.Address]*big.Int, len(s.stakes)),
+    }
+    for addr, account := range s.accounts {
+        dump.Accounts[addr] = DumpAccount{
+            Balance: new(big.Int).Set(account.Balance),
+            Nonce:   account.Nonce,
+            Code:    append([]byte(nil), account.Code...),
+        }
+    }
+    for addr, stake := range s.stakes {
+        dump.Stakes[addr] = new(big.Int).Set(stake)
+    }
+    if len(s.validators) > 0 {
+        dump.Validators = make(map[types.Address]ValidatorInfo, len(s.validators))
+        for addr, info := range s.validators {
+            infoCopy := *info
+            infoCopy.MissedBitmap = append([]byte(nil), info.MissedBitmap...)
+            dump.Validators[addr] = infoCopy
+        }
+    }
+    dump.Evidence = sortedHashes(s.evidence)
+    if s.emitted.Sign() > 0 {
+        dump.Emitted = new(big.Int).Set(s.emitted)
+    }
+    if s.burned.Sign() > 0 {
+        dump.Burned = new(big.Int).Set(s.burned)
+    }
+    return dump
+}
+
+// FromDump rebuilds a state from a snapshot
+func FromDump(dump *Dump) *StateDB {
+    s := NewStateDB()
+    for addr, account := range dump.Accounts {
+        balance := big.NewInt(0)
+        if account.Balance != nil {
+            balance.Set(account.Balance)
+        }
+        s.accounts[addr] = &Account{
+            Balance: balance,
+            Nonce:   account.Nonce,
+            Code:    append([]byte(nil), account.Code...),
+        }
+    }
+    for addr, stake := range dump.Stakes {
+        if stake != nil {
+            s.stakes[addr] = new(big.Int).Set(stake)
+        }
+    }
+    for addr, info := range dump.Validators {
+        infoCopy := info
+        infoCopy.MissedBitmap = append([]byte(nil), info.MissedBitmap...)
+        s.validators[addr] = &infoCopy
+    }
+    for _, key := range dump.Evidence {
+        s.evidence[key] = true
+    }
+    if dump.Emitted != nil {
+        s.emitted.Set(dump.Emitted)
+    }
+    if dump.Burned != nil {
+        s.burned.Set(dump.Burned)
+    }
+    return s
+}
+
+// Encode serialises the state
+func (s *StateDB) Encode() ([]byte, error) {
+    return json.Marshal(s.Dump())
+}
+
+// Decode deserialises a state produced by Encode
+func Decode(data []byte) (*StateDB, error) {
+    var dump Dump
+    if err := json.Unmarshal(data, &dump); err != nil {
+        return nil, err
+    }
+    return FromDump(&dump), nil
+}
@@ -0,0 +1,210 @@
+package state
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/forks"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+const (
+    TxGas            uint64 = 21000 // Base gas of every transaction
+    TxDataZeroGas    uint64 = 4     // Gas per zero byte of tx data
+    TxDataNonZeroGas uint64 = 16    // Gas per non-zero byte of tx data
+
+    BlockGasLimit uint64 = 10000000 // Gas a block may use once forks.BlockGasLimit is active
+)
+
+var (
+    ErrNonceTooLow         = errors.New("nonce too low")
+    ErrNonceTooHigh        = errors.New("nonce too high")
+    ErrIntrinsicGas        = errors.New("intrinsic gas too low")
+    ErrInsufficientFunds   = errors.New("insufficient funds for gas * price + value")
+    ErrInsufficientStake   = errors.New("insufficient stake for unstaking")
+    ErrNotStaker           = errors.New("sender has no stake")
+    ErrMissingRecipient    = errors.New("transaction has no recipient")
+    ErrUnknownTxType       = errors.New("unknown transaction type")
+    ErrNegativeValue       = errors.New("negative value or gas price")
+    ErrBlockGasLimit       = errors.New("block gas limit exceeded")
+)
+
+// valueFromBalance lists the transaction types that pay Value out of the
+// sender balance. The others only pay for gas: unstaking takes Value from
+// the stake, and the remaining types move no value.
+var valueFromBalance = map[types.TxType]bool{
+    types.TxRegular: true,
+    types.TxStaking: true,
+}
+
+// TxHandler applies the type-specific effect of a transaction. It runs after
+// gas has been charged and the sender nonce has been incremented.
+type TxHandler func(statedb *StateDB, tx *types.Transaction, from types.Address, header *types.Header) error
+
+// ProcessResult summarises the execution of a block
+type ProcessResult struct {
+    GasUsed uint64
+    Fees    *big.Int
+}
+
+// Processor executes block transactions on top of a state
+type Processor struct {
+    handlers map[types.TxType]TxHandler
+    upgrades forks.Schedule
+}
+
+// NewProcessor creates a processor with the built-in transaction handlers
+// that follows the rules of the upgrades in schedule
+func NewProcessor(upgrades forks.Schedule) *Processor {
+    p := &Processor{
+        handlers: make(map[types.TxType]TxHandler),
+        upgrades: upgrades,
+    }
+    p.RegisterHandler(types.TxRegular, applyTransfer)
+    p.RegisterHandler(types.TxStaking, applyStaking)
+    p.RegisterHandler(types.TxUnstaking, applyUnstaking)
+    p.RegisterHandler(types.TxVoting, applyVoting)
+    return p
+}
+
+// RegisterHandler installs the handler for a transaction type, replacing any existing one
+func (p *Processor) RegisterHandler(txType types.TxType, handler TxHandler) {
+    p.handlers[txType] = handler
+}
+
+// Process applies every transaction of block to statedb in order. Any invalid
+// transaction invalidates the whole block, so callers should pass a copy of
+// the parent state and discard it on error. The fees are charged to the
+// senders but not credited to anyone: the consensus engine distributes them
+// with the block reward.
+func (p *Processor) Process(block *types.Block, statedb *StateDB) (*ProcessResult, error) {
+    result := &ProcessResult{Fees: big.NewInt(0)}
+
+    for i, tx := range block.Transactions {
+        gasUsed, fee, err := p.ApplyTransaction(block.Header, tx, statedb)
+        if err != nil {
+            return nil, fmt.Errorf("tx %d in block #%s: %w", i, block.Header.Number, err)
+        }
+        result.GasUsed += gasUsed
+        result.Fees.Add(result.Fees, fee)
+        if p.upgrades.IsActive(forks.BlockGasLimit, block.Header.Number) && result.GasUsed > BlockGasLimit {
+            return nil, fmt.Errorf("%w: block #%s uses %d gas after tx %d, limit %d", ErrBlockGasLimit, block.Header.Number, result.GasUsed, i, BlockGasLimit)
+        }
+    }
+    return result, nil
+}
+
+// ApplyTransaction validates and executes a single transaction, returning the
+// gas used and the fee charged to the sender
+func (p *Processor) ApplyTransaction(header *types.Header, tx *types.Transaction, statedb *StateDB) (uint64, *big.Int, error) {
+    from, err := keys.TransactionSender(tx)
+    if err != nil {
+        return 0, nil, err
+    }
+
+    handler, exists := p.handlers[tx.Type]
+    if !exists {
+        return 0, nil, fmt.Errorf("%w: %d", ErrUnknownTxType, tx.Type)
+    }
+
+    // Check nonce
+    nonce := statedb.GetNonce(from)
+    if tx.Nonce < nonce {
+        return 0, nil, fmt.Errorf("%w: address %x, tx: %d state: %d", ErrNonceTooLow, from[:4], tx.Nonce, nonce)
+    }
+    if tx.Nonce > nonce {
+        return 0, nil, fmt.Errorf("%w: address %x, tx: %d state: %d", ErrNonceTooHigh, from[:4], tx.Nonce, nonce)
+    }
+
+    // Check gas
+    gasUsed := IntrinsicGas(tx.Data)
+    if tx.Gas < gasUsed {
+        return 0, nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.Gas, gasUsed)
+    }
+
+    // Sender must afford the full gas limit plus the value it pays out
+    gasPrice := valueOrZero(tx.GasPrice)
+    value := valueOrZero(tx.Value)
+    if gasPrice.Sign() < 0 || value.Sign() < 0 {
+        return 0, nil, ErrNegativeValue
+    }
+    cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas), gasPrice)
+    if valueFromBalance[tx.Type] {
+        cost.Add(cost, value)
+    }
+    if balance := statedb.GetBalance(from); balance.Cmp(cost) < 0 {
+        return 0, nil, fmt.Errorf("%w: address %x have %s want %s", ErrInsufficientFunds, from[:4], balance, cost)
+    }
+
+    // Charge gas and bump nonce
+    fee := new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
+    statedb.SubBalance(from, fee)
+    statedb.SetNonce(from, nonce+1)
+
+    if err := handler(statedb, tx, from, header); err != nil {
+        return 0, nil, err
+    }
+
+    return gasUsed, fee, nil
+}
+
+// IntrinsicGas returns the gas every transaction carrying data must pay
+func IntrinsicGas(data []byte) uint64 {
+    gas := TxGas
+    for _, b := range data {
+        if b == 0 {
+            gas += TxDataZeroGas
+        } else {
+            gas += TxDataNonZeroGas
+        }
+    }
+    return gas
+}
+
+// applyTransfer moves Value from the sender to the recipient
+func applyTransfer(statedb *StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    if tx.To == nil {
+        return ErrMissingRecipient
+    }
+    value := valueOrZero(tx.Value)
+    statedb.SubBalance(from, value)
+    statedb.AddBalance(*tx.To, value)
+    return nil
+}
+
+// applyStaking locks Value from the sender balance into stake
+func applyStaking(statedb *StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    value := valueOrZero(tx.Value)
+    statedb.SubBalance(from, value)
+    statedb.SetStake(from, new(big.Int).Add(statedb.GetStake(from), value))
+    return nil
+}
+
+// applyUnstaking releases Value from the sender stake back into balance
+func applyUnstaking(statedb *StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    value := valueOrZero(tx.Value)
+    stake := statedb.GetStake(from)
+    if stake.Cmp(value) < 0 {
+        return fmt.Errorf("%w: address %x have %s want %s", ErrInsufficientStake, from[:4], stake, value)
+    }
+    statedb.SetStake(from, new(big.Int).Sub(stake, value))
+    statedb.AddBalance(from, value)
+    return nil
+}
+
+// applyVoting only requires the sender to be a staker; the vote itself is
+// carried in tx.Data and picked up by consensus
+func applyVoting(statedb *StateDB, tx *types.Transaction, from types.Address, header *types.Header) error {
+    if statedb.GetStake(from).Sign() == 0 {
+        return fmt.Errorf("%w: address %x", ErrNotStaker, from[:4])
+    }
+    return nil
+}
+
+func valueOrZero(value *big.Int) *big.Int {
+    if value == nil {
+        return big.NewInt(0)
+    }
+    return value
+}
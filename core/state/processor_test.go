@@ -0,0 +1,47 @@
+package state
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// Unstaking pays its value out of the stake, so a staker only needs the
+// balance for gas
+func TestUnstakeWithLowBalance(t *testing.T) {
+    key, err := new(keys.KeyManager).GenerateKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    gasPrice := big.NewInt(1000000000)
+    fee := new(big.Int).Mul(big.NewInt(int64(TxGas)), gasPrice)
+    stake := big.NewInt(1e18)
+
+    newTx := func(txType types.TxType, nonce uint64) *types.Transaction {
+        to := types.Address{0xb}
+        tx := &types.Transaction{Nonce: nonce, GasPrice: gasPrice, Gas: TxGas, To: &to, Value: stake, Type: txType, ChainID: 1}
+        if err := key.SignTransaction(tx); err != nil {
+            t.Fatal(err)
+        }
+        return tx
+    }
+    statedb := NewStateDB()
+    statedb.SetBalance(key.Address, fee)
+    statedb.SetStake(key.Address, stake)
+    header := &types.Header{Number: big.NewInt(1)}
+    processor := NewProcessor(nil)
+
+    // The same value cannot be transferred from the balance
+    if _, _, err := processor.ApplyTransaction(header, newTx(types.TxRegular, 0), statedb.Copy()); !errors.Is(err, ErrInsufficientFunds) {
+        t.Fatalf("transfer: got %v, want %v", err, ErrInsufficientFunds)
+    }
+    if _, _, err := processor.ApplyTransaction(header, newTx(types.TxUnstaking, 0), statedb); err != nil {
+        t.Fatalf("unstaking refused: %v", err)
+    }
+    if statedb.GetStake(key.Address).Sign() != 0 || statedb.GetBalance(key.Address).Cmp(stake) != 0 {
+        t.Fatalf("after unstaking: stake %s, balance %s, want 0 and %s", statedb.GetStake(key.Address), statedb.GetBalance(key.Address), stake)
+    }
+}
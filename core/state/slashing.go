@@ -0,0 +1,124 @@
+package state
+
+import (
+    "math/big"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// ValidatorInfo tracks the punishments and the liveness of a validator
+type ValidatorInfo struct {
+    Jailed      bool   `json:"jailed"`
+    JailedUntil uint64 `json:"jailedUntil"` // First block number that may unjail the validator
+
+    // Liveness over a sliding window of the PoS blocks the validator was
+    // expected to sign. Bit i%window of MissedBitmap is set when the i-th of
+    // those blocks was missed.
+    SignedIndex  uint64 `json:"signedIndex,omitempty"`
+    MissedCount  uint64 `json:"missedCount,omitempty"`
+    MissedBitmap []byte `json:"missedBitmap,omitempty"`
+}
+
+// GetValidatorInfo returns the punishment record of a validator
+func (s *StateDB) GetValidatorInfo(address types.Address) ValidatorInfo {
+    if info, exists := s.validators[address]; exists {
+        return *info
+    }
+    return ValidatorInfo{}
+}
+
+// IsJailed reports whether a validator is excluded from the validator set
+func (s *StateDB) IsJailed(address types.Address) bool {
+    return s.GetValidatorInfo(address).Jailed
+}
+
+// Jail excludes a validator until at least block until. An existing longer
+// sentence is kept.
+func (s *StateDB) Jail(address types.Address, until uint64) {
+    info := s.getOrNewValidatorInfo(address)
+    info.Jailed = true
+    if until > info.JailedUntil {
+        info.JailedUntil = until
+    }
+}
+
+// Unjail lets a validator back into the validator set. The served sentence
+// is forgotten, so a validator with a clean liveness record leaves no trace.
+func (s *StateDB) Unjail(address types.Address) {
+    info := s.getOrNewValidatorInfo(address)
+    info.Jailed = false
+    info.JailedUntil = 0
+    s.pruneValidatorInfo(address)
+}
+
+// Slash removes percent of the stake of address and returns the amount taken
+func (s *StateDB) Slash(address types.Address, percent int) *big.Int {
+    stake := s.GetStake(address)
+    amount := new(big.Int).Mul(stake, big.NewInt(int64(percent)))
+    amount.Div(amount, big.NewInt(100))
+    s.SetStake(address, stake.Sub(stake, amount))
+    return amount
+}
+
+// RecordSigning adds one block to the liveness window of address, which
+// holds the last window blocks it was expected to sign. It returns how many
+// blocks were tracked since the window was last reset and how many of the
+// blocks in the window were missed.
+func (s *StateDB) RecordSigning(address types.Address, signed bool, window uint64) (uint64, uint64) {
+    info := s.getOrNewValidatorInfo(address)
+    if uint64(len(info.MissedBitmap)) != (window+7)/8 {
+        // First record, or the window size changed: start over
+        info.SignedIndex = 0
+        info.MissedCount = 0
+        info.MissedBitmap = make([]byte, (window+7)/8)
+    }
+
+    index := info.SignedIndex % window
+    mask := byte(1) << (index % 8)
+    wasMissed := info.MissedBitmap[index/8]&mask != 0
+    switch {
+    case wasMissed && signed:
+        info.MissedBitmap[index/8] &^= mask
+        info.MissedCount--
+    case !wasMissed && !signed:
+        info.MissedBitmap[index/8] |= mask
+        info.MissedCount++
+    }
+    info.SignedIndex++
+    return info.SignedIndex, info.MissedCount
+}
+
+// ResetSigning clears the liveness window of address
+func (s *StateDB) ResetSigning(address types.Address) {
+    if info, exists := s.validators[address]; exists {
+        info.SignedIndex = 0
+        info.MissedCount = 0
+        info.MissedBitmap = nil
+        s.pruneValidatorInfo(address)
+    }
+}
+
+// HasEvidence reports whether the offence identified by key was punished
+func (s *StateDB) HasEvidence(key types.Hash) bool {
+    return s.evidence[key]
+}
+
+// AddEvidence records that the offence identified by key was punished
+func (s *StateDB) AddEvidence(key types.Hash) {
+    s.evidence[key] = true
+}
+
+func (s *StateDB) getOrNewValidatorInfo(address types.Address) *ValidatorInfo {
+    info, exists := s.validators[address]
+    if !exists {
+        info = new(ValidatorInfo)
+        s.validators[address] = info
+    }
+    return info
+}
+
+// pruneValidatorInfo drops records that hold nothing, keeping the state small
+func (s *StateDB) pruneValidatorInfo(address types.Address) {
+    if info, exists := s.validators[address]; exists && !info.Jailed && info.JailedUntil == 0 && info.SignedIndex == 0 && len(info.MissedBitmap) == 0 {
+        delete(s.validators, address)
+    }
+}
@@ -0,0 +1,24 @@
+package state
+
+import (
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// A validator that served its sentence and has a clean liveness record
+// leaves nothing behind in the state
+func TestUnjailPrunesRecord(t *testing.T) {
+    address := types.Address{0xa}
+    statedb := NewStateDB()
+    clean := statedb.Root()
+
+    statedb.Jail(address, 100)
+    statedb.Unjail(address)
+    if _, exists := statedb.validators[address]; exists {
+        t.Fatalf("record kept after unjail: %+v", statedb.GetValidatorInfo(address))
+    }
+    if statedb.Root() != clean {
+        t.Fatal("unjailed validator changed the state root")
+    }
+}
@@ -1,14 +1,23 @@
 package state
 
 import (
+    "crypto/sha256"
+    "encoding/binary"
+    "io"
     "math/big"
+    "sort"
     "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
 )
 
 // StateDB manages the state of accounts and stakes
 type StateDB struct {
-    accounts map[types.Address]*Account
-    stakes   map[types.Address]*big.Int
+    accounts   map[types.Address]*Account
+    stakes     map[types.Address]*big.Int
+    validators map[types.Address]*ValidatorInfo // Jail and liveness records
+    evidence   map[types.Hash]bool              // Offences already punished
+    emitted    *big.Int                         // Minted by block rewards since genesis
+    burned     *big.Int                         // Destroyed since genesis
 }
 
 // Account represents a user account
@@ -21,8 +30,12 @@ type Account struct {
 // NewStateDB creates a new state database
 func NewStateDB() *StateDB {
     return &StateDB{
-        accounts: make(map[types.Address]*Account),
-        stakes:   make(map[types.Address]*big.Int),
+        accounts:   make(map[types.Address]*Account),
+        stakes:     make(map[types.Address]*big.Int),
+        validators: make(map[types.Address]*ValidatorInfo),
+        evidence:   make(map[types.Hash]bool),
+        emitted:    big.NewInt(0),
+        burned:     big.NewInt(0),
     }
 }
 
@@ -36,10 +49,7 @@ func (s *StateDB) GetBalance(address types.Address) *big.Int {
 
 // SetBalance sets the balance of an address
 func (s *StateDB) SetBalance(address types.Address, amount *big.Int) {
-    if _, exists := s.accounts[address]; !exists {
-        s.accounts[address] = &Account{}
-    }
-    s.accounts[address].Balance = new(big.Int).Set(amount)
+    s.getOrNewAccount(address).Balance = new(big.Int).Set(amount)
 }
 
 // GetStake returns the staked amount of an address
@@ -55,6 +65,17 @@ func (s *StateDB) SetStake(address types.Address, amount *big.Int) {
     s.stakes[address] = new(big.Int).Set(amount)
 }
 
+// Stakers returns every address with a non-zero stake, in address order
+func (s *StateDB) Stakers() []types.Address {
+    stakers := make([]types.Address, 0, len(s.stakes))
+    for _, addr := range sortedAddresses(s.stakes) {
+        if s.stakes[addr].Sign() > 0 {
+            stakers = append(stakers, addr)
+        }
+    }
+    return stakers
+}
+
 // GetNonce returns the nonce of an address
 func (s *StateDB) GetNonce(address types.Address) uint64 {
     if account, exists := s.accounts[address]; exists {
@@ -65,10 +86,7 @@ func (s *StateDB) GetNonce(address types.Address) uint64 {
 
 // SetNonce sets the nonce of an address
 func (s *StateDB) SetNonce(address types.Address, nonce uint64) {
-    if _, exists := s.accounts[address]; !exists {
-        s.accounts[address] = &Account{}
-    }
-    s.accounts[address].Nonce = nonce
+    s.getOrNewAccount(address).Nonce = nonce
 }
 
 // AddBalance adds amount to the balance of an address
@@ -99,3 +117,122 @@ func (s *StateDB) Empty(address types.Address) bool {
         account.Nonce == 0 &&
         len(account.Code) == 0
 }
+
+// Copy returns an independent deep copy of the state
+func (s *StateDB) Copy() *StateDB {
+    cpy := NewStateDB()
+    for addr, account := range s.accounts {
+        cpy.accounts[addr] = &Account{
+            Balance: new(big.Int).Set(account.Balance),
+            Nonce:   account.Nonce,
+            Code:    append([]byte(nil), account.Code...),
+        }
+    }
+    for addr, stake := range s.stakes {
+        cpy.stakes[addr] = new(big.Int).Set(stake)
+    }
+    for addr, info := range s.validators {
+        infoCopy := *info
+        infoCopy.MissedBitmap = append([]byte(nil), info.MissedBitmap...)
+        cpy.validators[addr] = &infoCopy
+    }
+    for key := range s.evidence {
+        cpy.evidence[key] = true
+    }
+    cpy.emitted.Set(s.emitted)
+    cpy.burned.Set(s.burned)
+    return cpy
+}
+
+// Root returns a deterministic commitment to the whole state
+func (s *StateDB) Root() types.Hash {
+    hasher := sha256.New()
+    var buf [8]byte
+    
+    for _, addr := range sortedAddresses(s.accounts) {
+        account := s.accounts[addr]
+        hasher.Write(addr[:])
+        writeBigInt(hasher, account.Balance)
+        binary.BigEndian.PutUint64(buf[:], account.Nonce)
+        hasher.Write(buf[:])
+        codeHash := sha256.Sum256(account.Code)
+        hasher.Write(codeHash[:])
+    }
+    
+    for _, addr := range sortedAddresses(s.stakes) {
+        hasher.Write(addr[:])
+        writeBigInt(hasher, s.stakes[addr])
+    }
+    
+    // Slashing records only enter the root once they exist, so roots of
+    // states without any stay unchanged
+    if len(s.validators) > 0 || len(s.evidence) > 0 {
+        hasher.Write([]byte("slashing"))
+        for _, addr := range sortedAddresses(s.validators) {
+            info := s.validators[addr]
+            hasher.Write(addr[:])
+            if info.Jailed {
+                hasher.Write([]byte{1})
+            } else {
+                hasher.Write([]byte{0})
+            }
+            binary.BigEndian.PutUint64(buf[:], info.JailedUntil)
+            hasher.Write(buf[:])
+            binary.BigEndian.PutUint64(buf[:], info.SignedIndex)
+            hasher.Write(buf[:])
+            binary.BigEndian.PutUint64(buf[:], info.MissedCount)
+            hasher.Write(buf[:])
+            hasher.Write(info.MissedBitmap)
+        }
+        for _, key := range sortedHashes(s.evidence) {
+            hasher.Write(key[:])
+        }
+    }
+    
+    // Likewise the supply counters
+    if s.emitted.Sign() > 0 || s.burned.Sign() > 0 {
+        hasher.Write([]byte("supply"))
+        writeBigInt(hasher, s.emitted)
+        writeBigInt(hasher, s.burned)
+    }
+    
+    var root types.Hash
+    copy(root[:], hasher.Sum(nil))
+    return root
+}
+
+// getOrNewAccount returns the account at address, creating an empty one if needed
+func (s *StateDB) getOrNewAccount(address types.Address) *Account {
+    account, exists := s.accounts[address]
+    if !exists {
+        account = &Account{Balance: big.NewInt(0)}
+        s.accounts[address] = account
+    }
+    return account
+}
+
+func sortedAddresses[V any](m map[types.Address]V) []types.Address {
+    addrs := make([]types.Address, 0, len(m))
+    for addr := range m {
+        addrs = append(addrs, addr)
+    }
+    sort.Slice(addrs, func(i, j int) bool {
+        return string(addrs[i][:]) < string(addrs[j][:])
+    })
+    return addrs
+}
+
+func sortedHashes(m map[types.Hash]bool) []types.Hash {
+    keys := make([]types.Hash, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool {
+        return string(keys[i][:]) < string(keys[j][:])
+    })
+    return keys
+}
+
+func writeBigInt(w io.Writer, value *big.Int) {
+    w.Write(hash.AppendBigInt(nil, value))
+}
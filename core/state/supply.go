@@ -0,0 +1,39 @@
+package state
+
+import (
+    "math/big"
+)
+
+// Emitted returns the SELSI minted by block rewards since genesis
+func (s *StateDB) Emitted() *big.Int {
+    return new(big.Int).Set(s.emitted)
+}
+
+// AddEmitted records amount of newly minted block rewards
+func (s *StateDB) AddEmitted(amount *big.Int) {
+    s.emitted = new(big.Int).Add(s.emitted, amount)
+}
+
+// Burned returns the SELSI destroyed since genesis, by reward burns and
+// slashing
+func (s *StateDB) Burned() *big.Int {
+    return new(big.Int).Set(s.burned)
+}
+
+// AddBurned records that amount was destroyed
+func (s *StateDB) AddBurned(amount *big.Int) {
+    s.burned = new(big.Int).Add(s.burned, amount)
+}
+
+// Holdings returns the SELSI held in all balances and stakes. It always
+// equals the genesis holdings plus Emitted minus Burned.
+func (s *StateDB) Holdings() *big.Int {
+    total := big.NewInt(0)
+    for _, account := range s.accounts {
+        total.Add(total, account.Balance)
+    }
+    for _, stake := range s.stakes {
+        total.Add(total, stake)
+    }
+    return total
+}
@@ -15,26 +15,31 @@ type BlockNonce [8]byte
 type Block struct {
     Header       *Header
     Transactions []*Transaction
-    Votes        []*Vote  // Untuk PoS
+    Votes        []*Vote  // Untuk PoS: precommits that committed the block
+    LastCommit   []*Vote  // Precommits that committed the parent, if it is a PoS block
 }
 
 // Header represents block header
 type Header struct {
-    ParentHash   Hash
-    Coinbase     Address  // Miner/validator address
-    Root         Hash
-    TxHash       Hash
-    Difficulty   *big.Int
-    Number       *big.Int
-    Time         uint64
-    Extra        []byte
-    MixDigest    Hash
-    Nonce        BlockNonce
+    ParentHash     Hash
+    Coinbase       Address   // Miner/validator address
+    Root           Hash
+    TxHash         Hash
+    Difficulty     *big.Int
+    Number         *big.Int
+    Time           uint64
+    Extra          []byte
+    MixDigest      Hash
+    Nonce          BlockNonce
     
     // SelsiChain Hybrid Fields
-    Validator    Address  // PoS validator
-    StakeHash    Hash     // Stake merkle root  
-    Checkpoint   bool     // Is PoW checkpoint block?
+    Validator      Address   // PoS validator
+    StakeHash      Hash      // Stake merkle root  
+    Checkpoint     bool      // Is PoW checkpoint block?
+    RandaoReveal   []byte    // PoS proposer's compressed public key and VRF proof
+    Seal           []byte    // PoS proposer's signature over the rest of the header
+    Round          uint32    // PoS agreement round the block was proposed in
+    LastCommitHash Hash      // Commitment to the block's LastCommit
 }
 
 // Transaction represents a transaction
@@ -49,17 +54,41 @@ type Transaction struct {
     
     // SelsiChain specific
     Type     TxType
+    ChainID  uint64      // Chain the transaction is valid on; covered by the signature
 }
 
-// Vote represents a PoS vote
+// VoteType is the step of the agreement protocol a vote belongs to
+type VoteType uint8
+
+const (
+    VotePrevote VoteType = iota
+    VotePrecommit
+)
+
+// Vote represents a PoS vote. A vote with Decision false and a zero
+// BlockHash is a vote for no block (nil) in its round.
 type Vote struct {
     Validator   Address
+    Number      uint64      // Height of the block voted on
+    Round       uint32
+    Type        VoteType
     BlockHash   Hash
     Decision    bool
     Signature   []byte
     Timestamp   int64
 }
 
+// Proposal is a PoS block offered for agreement in one round. POLRound is
+// the earlier round in which the block gathered 2/3 prevotes when it is
+// proposed again, or -1.
+type Proposal struct {
+    Number    uint64
+    Round     uint32
+    POLRound  int32
+    Block     *Block
+    Signature []byte
+}
+
 // TxType represents transaction type
 type TxType uint8
 
@@ -68,4 +97,6 @@ const (
     TxStaking
     TxUnstaking
     TxVoting
+    TxEvidence  // Data carries Evidence of a validator misbehaving
+    TxUnjail    // Sender asks to rejoin the validator set after its jail time
 )
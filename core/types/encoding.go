@@ -0,0 +1,73 @@
+package types
+
+import (
+    "encoding/hex"
+    "fmt"
+    "strings"
+)
+
+// Hex returns the 0x-prefixed hex encoding of the hash
+func (h Hash) Hex() string {
+    return "0x" + hex.EncodeToString(h[:])
+}
+
+// MarshalText encodes the hash as 0x-prefixed hex
+func (h Hash) MarshalText() ([]byte, error) {
+    return []byte(h.Hex()), nil
+}
+
+// UnmarshalText decodes a 0x-prefixed hex hash
+func (h *Hash) UnmarshalText(input []byte) error {
+    return decodeFixedHex("hash", input, h[:])
+}
+
+// HexToHash parses a hex string (with or without 0x) into a hash
+func HexToHash(s string) (Hash, error) {
+    var h Hash
+    err := h.UnmarshalText([]byte(s))
+    return h, err
+}
+
+// Hex returns the 0x-prefixed hex encoding of the address
+func (a Address) Hex() string {
+    return "0x" + hex.EncodeToString(a[:])
+}
+
+// MarshalText encodes the address as 0x-prefixed hex
+func (a Address) MarshalText() ([]byte, error) {
+    return []byte(a.Hex()), nil
+}
+
+// UnmarshalText decodes a 0x-prefixed hex address
+func (a *Address) UnmarshalText(input []byte) error {
+    return decodeFixedHex("address", input, a[:])
+}
+
+// HexToAddress parses a hex string (with or without 0x) into an address
+func HexToAddress(s string) (Address, error) {
+    var a Address
+    err := a.UnmarshalText([]byte(s))
+    return a, err
+}
+
+// MarshalText encodes the nonce as 0x-prefixed hex
+func (n BlockNonce) MarshalText() ([]byte, error) {
+    return []byte("0x" + hex.EncodeToString(n[:])), nil
+}
+
+// UnmarshalText decodes a 0x-prefixed hex nonce
+func (n *BlockNonce) UnmarshalText(input []byte) error {
+    return decodeFixedHex("nonce", input, n[:])
+}
+
+func decodeFixedHex(kind string, input []byte, out []byte) error {
+    s := strings.TrimPrefix(string(input), "0x")
+    if len(s) != 2*len(out) {
+        return fmt.Errorf("invalid %s length: want %d hex chars, got %d", kind, 2*len(out), len(s))
+    }
+    _, err := hex.Decode(out, []byte(s))
+    if err != nil {
+        return fmt.Errorf("invalid %s: %w", kind, err)
+    }
+    return nil
+}
@@ -0,0 +1,19 @@
+package types
+
+// EvidenceType identifies the kind of misbehaviour an Evidence proves
+type EvidenceType uint8
+
+const (
+    EvidenceDoubleProposal EvidenceType = iota // Two sealed headers in one round
+    EvidenceDoubleVote                         // Two signed votes of one step for different blocks in one round
+)
+
+// Evidence carries two conflicting messages signed by the same validator.
+// It is submitted on chain in the Data of a TxEvidence transaction.
+type Evidence struct {
+    Type    EvidenceType `json:"type"`
+    HeaderA *Header      `json:"headerA,omitempty"`
+    HeaderB *Header      `json:"headerB,omitempty"`
+    VoteA   *Vote        `json:"voteA,omitempty"`
+    VoteB   *Vote        `json:"voteB,omitempty"`
+}
@@ -0,0 +1,37 @@
+package types
+
+import "math/big"
+
+// Roles a block reward payout is made for
+const (
+    RewardMiner    = "miner"    // Miner share of a checkpoint
+    RewardProposer = "proposer" // Proposer bonus and leftovers of a PoS block
+    RewardVoter    = "voter"    // Stake-weighted part of the staker share
+    RewardTreasury = "treasury" // Ecosystem share
+)
+
+// RewardBreakdown records how the reward pool of a block, its emission plus
+// its fees, was paid out. Payouts plus Burned always add up to the pool.
+type RewardBreakdown struct {
+    Number   uint64         `json:"number"`
+    Emission *big.Int       `json:"emission"`
+    Fees     *big.Int       `json:"fees"`
+    Burned   *big.Int       `json:"burned"`
+    Payouts  []RewardPayout `json:"payouts"`
+}
+
+// RewardPayout is one credit of a block reward
+type RewardPayout struct {
+    Role    string   `json:"role"`
+    Address Address  `json:"address"`
+    Amount  *big.Int `json:"amount"`
+}
+
+// Paid returns the total credited by the payouts
+func (r *RewardBreakdown) Paid() *big.Int {
+    total := big.NewInt(0)
+    for _, payout := range r.Payouts {
+        total.Add(total, payout.Amount)
+    }
+    return total
+}
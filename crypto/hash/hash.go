@@ -2,6 +2,8 @@ package hash
 
 import (
     "crypto/sha256"
+    "encoding/binary"
+    "math/big"
     "github.com/selsichain/selsichain-core/core/types"
 )
 
@@ -11,31 +13,142 @@ func CalculateBlockHash(header *types.Header) types.Hash {
     return types.Hash(hash)
 }
 
+// SealHash returns the hash a PoS proposer signs: the block hash computed
+// without the seal itself
+func SealHash(header *types.Header) types.Hash {
+    unsealed := *header
+    unsealed.Seal = nil
+    return CalculateBlockHash(&unsealed)
+}
+
+// CalculateTransactionHash returns the identity of a transaction: the hash of
+// its signed encoding, so equal transactions from different senders hash
+// differently
 func CalculateTransactionHash(tx *types.Transaction) types.Hash {
     data := serializeTransaction(tx)
+    data = AppendBigInt(data, tx.V)
+    data = AppendBigInt(data, tx.R)
+    data = AppendBigInt(data, tx.S)
     hash := sha256.Sum256(data)
     return types.Hash(hash)
 }
 
+// TransactionSigningHash returns the hash a sender signs: the transaction
+// without its signature, including the chain ID so it cannot be replayed on
+// another chain
+func TransactionSigningHash(tx *types.Transaction) types.Hash {
+    return types.Hash(sha256.Sum256(serializeTransaction(tx)))
+}
+
+// CalculateVoteHash returns the hash a validator signs when voting
+func CalculateVoteHash(vote *types.Vote) types.Hash {
+    var data []byte
+    data = append(data, vote.Validator[:]...)
+    data = binary.BigEndian.AppendUint64(data, vote.Number)
+    data = binary.BigEndian.AppendUint32(data, vote.Round)
+    data = append(data, byte(vote.Type))
+    data = append(data, vote.BlockHash[:]...)
+    if vote.Decision {
+        data = append(data, 1)
+    } else {
+        data = append(data, 0)
+    }
+    data = binary.BigEndian.AppendUint64(data, uint64(vote.Timestamp))
+    return types.Hash(sha256.Sum256(data))
+}
+
+// CalculateProposalHash returns the hash a proposer signs when proposing
+func CalculateProposalHash(proposal *types.Proposal) types.Hash {
+    var data []byte
+    data = binary.BigEndian.AppendUint64(data, proposal.Number)
+    data = binary.BigEndian.AppendUint32(data, proposal.Round)
+    data = binary.BigEndian.AppendUint32(data, uint32(proposal.POLRound))
+    if proposal.Block != nil {
+        blockHash := CalculateBlockHash(proposal.Block.Header)
+        data = append(data, blockHash[:]...)
+    }
+    return types.Hash(sha256.Sum256(data))
+}
+
+// CalculateCommitHash returns the commitment to a list of signed votes, or
+// the zero hash for an empty list
+func CalculateCommitHash(votes []*types.Vote) types.Hash {
+    if len(votes) == 0 {
+        return types.Hash{}
+    }
+    var data []byte
+    for _, vote := range votes {
+        voteHash := CalculateVoteHash(vote)
+        data = append(data, voteHash[:]...)
+        data = binary.BigEndian.AppendUint32(data, uint32(len(vote.Signature)))
+        data = append(data, vote.Signature...)
+    }
+    return types.Hash(sha256.Sum256(data))
+}
+
+// CalculateTxRoot returns the commitment to an ordered list of transactions
+func CalculateTxRoot(txs []*types.Transaction) types.Hash {
+    var data []byte
+    for _, tx := range txs {
+        txHash := CalculateTransactionHash(tx)
+        data = append(data, txHash[:]...)
+    }
+    return types.Hash(sha256.Sum256(data))
+}
+
+// EncodeHeader returns the canonical byte encoding of a header that block
+// and proof-of-work hashes are computed over
+func EncodeHeader(header *types.Header) []byte {
+    return serializeHeader(header)
+}
+
 func serializeHeader(header *types.Header) []byte {
     var data []byte
     data = append(data, header.ParentHash[:]...)
     data = append(data, header.Coinbase[:]...)
-    if header.Number != nil {
-        data = append(data, header.Number.Bytes()...)
-    }
-    data = append(data, byte(header.Time))
-    if header.Difficulty != nil {
-        data = append(data, header.Difficulty.Bytes()...)
-    }
+    data = append(data, header.Root[:]...)
+    data = append(data, header.TxHash[:]...)
+    data = AppendBigInt(data, header.Number)
+    data = binary.BigEndian.AppendUint64(data, header.Time)
+    data = AppendBigInt(data, header.Difficulty)
+    data = binary.BigEndian.AppendUint32(data, uint32(len(header.Extra)))
+    data = append(data, header.Extra...)
+    data = append(data, header.MixDigest[:]...)
     data = append(data, header.Nonce[:]...)
     data = append(data, header.Validator[:]...)
+    data = append(data, header.StakeHash[:]...)
+    if header.Checkpoint {
+        data = append(data, 1)
+    } else {
+        data = append(data, 0)
+    }
+    // Optional trailing fields keep the hashes of blocks without them unchanged
+    data = appendOptional(data, 1, header.RandaoReveal)
+    data = appendOptional(data, 2, header.Seal)
+    if header.Round > 0 {
+        data = appendOptional(data, 3, binary.BigEndian.AppendUint32(nil, header.Round))
+    }
+    if header.LastCommitHash != (types.Hash{}) {
+        data = appendOptional(data, 4, header.LastCommitHash[:])
+    }
     return data
 }
 
+// appendOptional appends a tagged, length-prefixed field unless it is empty
+func appendOptional(data []byte, tag byte, value []byte) []byte {
+    if len(value) == 0 {
+        return data
+    }
+    data = append(data, tag)
+    data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+    return append(data, value...)
+}
+
 func serializeTransaction(tx *types.Transaction) []byte {
     var data []byte
-    data = append(data, byte(tx.Nonce))
+    data = binary.BigEndian.AppendUint64(data, tx.Nonce)
+    data = AppendBigInt(data, tx.GasPrice)
+    data = binary.BigEndian.AppendUint64(data, tx.Gas)
     
     // Handle To address (bisa nil untuk contract creation)
     if tx.To != nil {
@@ -45,12 +158,44 @@ func serializeTransaction(tx *types.Transaction) []byte {
         data = append(data, make([]byte, 20)...)
     }
     
-    if tx.Value != nil {
-        data = append(data, tx.Value.Bytes()...)
-    }
+    data = AppendBigInt(data, tx.Value)
     
     // Use Data field (dulunya Input)
+    data = binary.BigEndian.AppendUint32(data, uint32(len(tx.Data)))
     data = append(data, tx.Data...)
+    data = append(data, byte(tx.Type))
+    data = binary.BigEndian.AppendUint64(data, tx.ChainID)
     
     return data
 }
+
+// Prefixes of big integers that do not fit the one-byte length form
+const (
+    bigIntLong     = 0x80 // Non-negative, uvarint length follows
+    bigIntNegative = 0xc0 // Negative, uvarint length of the magnitude follows
+    bigIntNil      = 0xff
+)
+
+// AppendBigInt appends a length-prefixed big-endian integer so that adjacent
+// variable-size fields cannot be confused with each other: a one-byte length
+// and the big-endian bytes for non-negative values shorter than 128 bytes,
+// otherwise a prefix telling nil, negative and long values apart and, for
+// the latter two, a uvarint length and the magnitude. Distinct values never
+// share an encoding.
+func AppendBigInt(data []byte, value *big.Int) []byte {
+    if value == nil {
+        return append(data, bigIntNil)
+    }
+    b := value.Bytes()
+    switch {
+    case value.Sign() < 0:
+        data = append(data, bigIntNegative)
+    case len(b) >= bigIntLong:
+        data = append(data, bigIntLong)
+    default:
+        data = append(data, byte(len(b)))
+        return append(data, b...)
+    }
+    data = binary.AppendUvarint(data, uint64(len(b)))
+    return append(data, b...)
+}
@@ -0,0 +1,37 @@
+package hash
+
+import (
+    "bytes"
+    "math/big"
+    "testing"
+
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+func TestAppendBigIntIsInjective(t *testing.T) {
+    long := new(big.Int).Lsh(big.NewInt(1), 8*256) // 257 bytes
+    values := []*big.Int{nil, big.NewInt(0), big.NewInt(1), big.NewInt(-1), big.NewInt(255), big.NewInt(-255),
+        long, new(big.Int).Neg(long), new(big.Int).Lsh(big.NewInt(1), 8*127)}
+    seen := make(map[string]int)
+    for i, value := range values {
+        encoded := string(AppendBigInt(nil, value))
+        if j, ok := seen[encoded]; ok {
+            t.Fatalf("%v and %v share the encoding %x", values[j], value, encoded)
+        }
+        seen[encoded] = i
+    }
+
+    // Small non-negative values keep the one-byte length form
+    if encoded := AppendBigInt(nil, big.NewInt(1000)); !bytes.Equal(encoded, []byte{2, 0x03, 0xe8}) {
+        t.Fatalf("1000 encodes as %x", encoded)
+    }
+}
+
+func TestBlockHashCoversSign(t *testing.T) {
+    header := &types.Header{Number: big.NewInt(7), Difficulty: big.NewInt(1000)}
+    negated := *header
+    negated.Number = big.NewInt(-7)
+    if CalculateBlockHash(header) == CalculateBlockHash(&negated) {
+        t.Fatal("#7 and #-7 share a block hash")
+    }
+}
@@ -127,12 +127,7 @@ func (km *KeyManager) privateKeyToKeyPair(privateKey *ecdsa.PrivateKey) *KeyPair
 
 // publicKeyToAddress converts public key to address
 func (km *KeyManager) publicKeyToAddress(publicKey *ecdsa.PublicKey) types.Address {
-    publicKeyBytes := crypto.FromECDSAPub(publicKey)
-    // Ethereum-style address: last 20 bytes of keccak256 hash
-    hash := crypto.Keccak256(publicKeyBytes[1:]) // Remove prefix
-    var address types.Address
-    copy(address[:], hash[12:]) // Last 20 bytes
-    return address
+    return PubkeyToAddress(publicKey)
 }
 
 // Simple encryption for demo (in production, use proper encryption)
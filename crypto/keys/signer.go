@@ -0,0 +1,162 @@
+package keys
+
+import (
+    "crypto/ecdsa"
+    "errors"
+    "fmt"
+    "math/big"
+
+    "github.com/ethereum/go-ethereum/crypto"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+var (
+    ErrMissingSignature = errors.New("transaction is not signed")
+    ErrInvalidSignature = errors.New("invalid transaction signature")
+    ErrMissingSeal      = errors.New("block header is not sealed")
+    ErrInvalidSeal      = errors.New("invalid block seal")
+    ErrInvalidVote      = errors.New("invalid vote signature")
+    ErrInvalidProposal  = errors.New("invalid proposal signature")
+)
+
+// SignTransaction signs tx in place with the key pair. The signature covers
+// tx.ChainID, so set it first.
+func (kp *KeyPair) SignTransaction(tx *types.Transaction) error {
+    txHash := hash.TransactionSigningHash(tx)
+    sig, err := crypto.Sign(txHash[:], kp.PrivateKey)
+    if err != nil {
+        return fmt.Errorf("failed to sign transaction: %w", err)
+    }
+
+    tx.R = new(big.Int).SetBytes(sig[:32])
+    tx.S = new(big.Int).SetBytes(sig[32:64])
+    tx.V = new(big.Int).SetUint64(uint64(sig[64]))
+    return nil
+}
+
+// TransactionSender recovers the address that signed tx
+func TransactionSender(tx *types.Transaction) (types.Address, error) {
+    if tx.V == nil || tx.R == nil || tx.S == nil {
+        return types.Address{}, ErrMissingSignature
+    }
+    if !tx.V.IsUint64() || tx.V.Uint64() > 1 || tx.R.BitLen() > 256 || tx.S.BitLen() > 256 {
+        return types.Address{}, ErrInvalidSignature
+    }
+    // Only low-s signatures, so a transaction has exactly one valid signature
+    // and one hash
+    if !crypto.ValidateSignatureValues(byte(tx.V.Uint64()), tx.R, tx.S, true) {
+        return types.Address{}, ErrInvalidSignature
+    }
+
+    sig := make([]byte, crypto.SignatureLength)
+    tx.R.FillBytes(sig[:32])
+    tx.S.FillBytes(sig[32:64])
+    sig[64] = byte(tx.V.Uint64())
+
+    txHash := hash.TransactionSigningHash(tx)
+    publicKey, err := crypto.SigToPub(txHash[:], sig)
+    if err != nil {
+        return types.Address{}, ErrInvalidSignature
+    }
+    return PubkeyToAddress(publicKey), nil
+}
+
+// SignHeader seals header in place with the key pair
+func (kp *KeyPair) SignHeader(header *types.Header) error {
+    sealHash := hash.SealHash(header)
+    sig, err := crypto.Sign(sealHash[:], kp.PrivateKey)
+    if err != nil {
+        return fmt.Errorf("failed to seal header: %w", err)
+    }
+    header.Seal = sig
+    return nil
+}
+
+// HeaderSigner recovers the address that sealed header
+func HeaderSigner(header *types.Header) (types.Address, error) {
+    if len(header.Seal) == 0 {
+        return types.Address{}, ErrMissingSeal
+    }
+    if len(header.Seal) != crypto.SignatureLength {
+        return types.Address{}, ErrInvalidSeal
+    }
+    // Only low-s signatures, so a block has exactly one valid seal
+    if !isLowS(header.Seal) {
+        return types.Address{}, ErrInvalidSeal
+    }
+
+    sealHash := hash.SealHash(header)
+    publicKey, err := crypto.SigToPub(sealHash[:], header.Seal)
+    if err != nil {
+        return types.Address{}, ErrInvalidSeal
+    }
+    return PubkeyToAddress(publicKey), nil
+}
+
+// SignVote signs vote in place with the key pair
+func (kp *KeyPair) SignVote(vote *types.Vote) error {
+    voteHash := hash.CalculateVoteHash(vote)
+    sig, err := crypto.Sign(voteHash[:], kp.PrivateKey)
+    if err != nil {
+        return fmt.Errorf("failed to sign vote: %w", err)
+    }
+    vote.Signature = sig
+    return nil
+}
+
+// VoteSigner recovers the address that signed vote
+func VoteSigner(vote *types.Vote) (types.Address, error) {
+    if len(vote.Signature) != crypto.SignatureLength || !isLowS(vote.Signature) {
+        return types.Address{}, ErrInvalidVote
+    }
+    voteHash := hash.CalculateVoteHash(vote)
+    publicKey, err := crypto.SigToPub(voteHash[:], vote.Signature)
+    if err != nil {
+        return types.Address{}, ErrInvalidVote
+    }
+    return PubkeyToAddress(publicKey), nil
+}
+
+// SignProposal signs proposal in place with the key pair
+func (kp *KeyPair) SignProposal(proposal *types.Proposal) error {
+    proposalHash := hash.CalculateProposalHash(proposal)
+    sig, err := crypto.Sign(proposalHash[:], kp.PrivateKey)
+    if err != nil {
+        return fmt.Errorf("failed to sign proposal: %w", err)
+    }
+    proposal.Signature = sig
+    return nil
+}
+
+// ProposalSigner recovers the address that signed proposal
+func ProposalSigner(proposal *types.Proposal) (types.Address, error) {
+    if len(proposal.Signature) != crypto.SignatureLength || !isLowS(proposal.Signature) || proposal.Block == nil {
+        return types.Address{}, ErrInvalidProposal
+    }
+    proposalHash := hash.CalculateProposalHash(proposal)
+    publicKey, err := crypto.SigToPub(proposalHash[:], proposal.Signature)
+    if err != nil {
+        return types.Address{}, ErrInvalidProposal
+    }
+    return PubkeyToAddress(publicKey), nil
+}
+
+// isLowS reports whether the 65-byte signature sig has valid values and a
+// low s. Its malleated twin (r, n-s) would otherwise recover the same signer,
+// letting anyone relaying a vote or proposal change its bytes.
+func isLowS(sig []byte) bool {
+    r := new(big.Int).SetBytes(sig[:32])
+    s := new(big.Int).SetBytes(sig[32:64])
+    return crypto.ValidateSignatureValues(sig[64], r, s, true)
+}
+
+// PubkeyToAddress converts a public key to its SelsiChain address
+func PubkeyToAddress(publicKey *ecdsa.PublicKey) types.Address {
+    publicKeyBytes := crypto.FromECDSAPub(publicKey)
+    // Ethereum-style address: last 20 bytes of keccak256 hash
+    hash := crypto.Keccak256(publicKeyBytes[1:]) // Remove prefix
+    var address types.Address
+    copy(address[:], hash[12:]) // Last 20 bytes
+    return address
+}
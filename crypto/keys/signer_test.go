@@ -0,0 +1,128 @@
+package keys
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/ethereum/go-ethereum/crypto"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+)
+
+func newTestTransaction(chainID uint64) *types.Transaction {
+    to := types.Address{2}
+    return &types.Transaction{
+        Nonce:    1,
+        GasPrice: big.NewInt(1000000000),
+        Gas:      21000,
+        To:       &to,
+        Value:    big.NewInt(10),
+        Type:     types.TxRegular,
+        ChainID:  chainID,
+    }
+}
+
+func newTestKey(t *testing.T) *KeyPair {
+    t.Helper()
+    key, err := new(KeyManager).GenerateKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return key
+}
+
+func TestTransactionSenderRoundTrip(t *testing.T) {
+    key := newTestKey(t)
+    tx := newTestTransaction(769)
+    if err := key.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+    from, err := TransactionSender(tx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if from != key.Address {
+        t.Fatalf("sender %x, want %x", from, key.Address)
+    }
+}
+
+func TestTransactionReplayOnOtherChain(t *testing.T) {
+    key := newTestKey(t)
+    tx := newTestTransaction(1337)
+    if err := key.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+
+    // The same signature presented for another chain recovers someone else
+    replayed := *tx
+    replayed.ChainID = 769
+    if from, err := TransactionSender(&replayed); err == nil && from == key.Address {
+        t.Fatal("signature for chain 1337 is valid on chain 769")
+    }
+}
+
+func TestTransactionHashCoversSignature(t *testing.T) {
+    first, second := newTestKey(t), newTestKey(t)
+    a, b := newTestTransaction(769), newTestTransaction(769)
+    if err := first.SignTransaction(a); err != nil {
+        t.Fatal(err)
+    }
+    if err := second.SignTransaction(b); err != nil {
+        t.Fatal(err)
+    }
+    if hash.TransactionSigningHash(a) != hash.TransactionSigningHash(b) {
+        t.Fatal("identical transactions have different signing hashes")
+    }
+    if hash.CalculateTransactionHash(a) == hash.CalculateTransactionHash(b) {
+        t.Fatal("transactions of different senders share a hash")
+    }
+}
+
+func TestTransactionSenderRejectsHighS(t *testing.T) {
+    key := newTestKey(t)
+    tx := newTestTransaction(769)
+    if err := key.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+
+    // (r, n-s) with the recovery id flipped is the same signature's
+    // malleated twin
+    malleated := *tx
+    malleated.S = new(big.Int).Sub(crypto.S256().Params().N, tx.S)
+    malleated.V = new(big.Int).Xor(tx.V, big.NewInt(1))
+    if _, err := TransactionSender(&malleated); !errors.Is(err, ErrInvalidSignature) {
+        t.Fatalf("high-s signature: got %v, want %v", err, ErrInvalidSignature)
+    }
+}
+
+// malleate returns the high-s twin of the 65-byte signature sig
+func malleate(sig []byte) []byte {
+    twin := make([]byte, crypto.SignatureLength)
+    copy(twin, sig)
+    s := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(sig[32:64]))
+    s.FillBytes(twin[32:64])
+    twin[64] ^= 1
+    return twin
+}
+
+func TestVoteAndProposalSignersRejectHighS(t *testing.T) {
+    key := newTestKey(t)
+    vote := &types.Vote{Validator: key.Address, Number: 1, BlockHash: types.Hash{1}, Decision: true}
+    if err := key.SignVote(vote); err != nil {
+        t.Fatal(err)
+    }
+    proposal := &types.Proposal{Number: 1, Block: &types.Block{Header: &types.Header{Number: big.NewInt(1)}}}
+    if err := key.SignProposal(proposal); err != nil {
+        t.Fatal(err)
+    }
+
+    vote.Signature = malleate(vote.Signature)
+    if _, err := VoteSigner(vote); !errors.Is(err, ErrInvalidVote) {
+        t.Fatalf("high-s vote: got %v, want %v", err, ErrInvalidVote)
+    }
+    proposal.Signature = malleate(proposal.Signature)
+    if _, err := ProposalSigner(proposal); !errors.Is(err, ErrInvalidProposal) {
+        t.Fatalf("high-s proposal: got %v, want %v", err, ErrInvalidProposal)
+    }
+}
@@ -0,0 +1,156 @@
+package vrf
+
+import (
+    "crypto/ecdsa"
+    "crypto/sha256"
+    "errors"
+    "math/big"
+
+    "github.com/ethereum/go-ethereum/crypto"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// Verifiable random function over secp256k1, following the structure of
+// ECVRF-SECP256K1-SHA256-TAI (RFC 9381): the output is unique for a key and
+// input, and anyone holding the public key can check it with the proof.
+//
+// proof = compressed Gamma (33) || c (32) || s (32)
+
+const (
+    pointLength = 33
+    ProofLength = pointLength + 32 + 32
+)
+
+var ErrInvalidProof = errors.New("invalid VRF proof")
+
+var suite = []byte("SelsiChain-VRF-secp256k1-SHA256-TAI")
+
+// Prove evaluates the VRF on alpha with the private key and returns the
+// output together with a proof of it
+func Prove(privateKey *ecdsa.PrivateKey, alpha []byte) (types.Hash, []byte, error) {
+    curve := crypto.S256()
+    n := curve.Params().N
+
+    hx, hy, err := hashToCurve(&privateKey.PublicKey, alpha)
+    if err != nil {
+        return types.Hash{}, nil, err
+    }
+    gx, gy := curve.ScalarMult(hx, hy, privateKey.D.Bytes())
+
+    k := nonce(privateKey, hx, hy)
+    ux, uy := curve.ScalarBaseMult(k.Bytes())
+    vx, vy := curve.ScalarMult(hx, hy, k.Bytes())
+    c := challenge(hx, hy, gx, gy, ux, uy, vx, vy)
+
+    s := new(big.Int).Mul(c, privateKey.D)
+    s.Add(s, k).Mod(s, n)
+
+    proof := make([]byte, 0, ProofLength)
+    proof = append(proof, compress(gx, gy)...)
+    proof = append(proof, c.FillBytes(make([]byte, 32))...)
+    proof = append(proof, s.FillBytes(make([]byte, 32))...)
+    return output(gx, gy), proof, nil
+}
+
+// Verify checks that proof was produced by the owner of publicKey for alpha
+// and returns the VRF output
+func Verify(publicKey *ecdsa.PublicKey, alpha []byte, proof []byte) (types.Hash, error) {
+    curve := crypto.S256()
+    n := curve.Params().N
+
+    if len(proof) != ProofLength {
+        return types.Hash{}, ErrInvalidProof
+    }
+    gamma, err := crypto.DecompressPubkey(proof[:pointLength])
+    if err != nil {
+        return types.Hash{}, ErrInvalidProof
+    }
+    c := new(big.Int).SetBytes(proof[pointLength : pointLength+32])
+    s := new(big.Int).SetBytes(proof[pointLength+32:])
+    if c.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+        return types.Hash{}, ErrInvalidProof
+    }
+
+    hx, hy, err := hashToCurve(publicKey, alpha)
+    if err != nil {
+        return types.Hash{}, ErrInvalidProof
+    }
+    negC := new(big.Int).Sub(n, c).Bytes()
+
+    // U = s*G - c*Y, V = s*H - c*Gamma
+    sgx, sgy := curve.ScalarBaseMult(s.Bytes())
+    cyx, cyy := curve.ScalarMult(publicKey.X, publicKey.Y, negC)
+    ux, uy := curve.Add(sgx, sgy, cyx, cyy)
+    shx, shy := curve.ScalarMult(hx, hy, s.Bytes())
+    cgx, cgy := curve.ScalarMult(gamma.X, gamma.Y, negC)
+    vx, vy := curve.Add(shx, shy, cgx, cgy)
+    if isInfinity(ux, uy) || isInfinity(vx, vy) {
+        return types.Hash{}, ErrInvalidProof
+    }
+
+    if challenge(hx, hy, gamma.X, gamma.Y, ux, uy, vx, vy).Cmp(c) != 0 {
+        return types.Hash{}, ErrInvalidProof
+    }
+    return output(gamma.X, gamma.Y), nil
+}
+
+// hashToCurve maps the public key and alpha to a curve point by
+// try-and-increment
+func hashToCurve(publicKey *ecdsa.PublicKey, alpha []byte) (*big.Int, *big.Int, error) {
+    pk := crypto.CompressPubkey(publicKey)
+    for ctr := 0; ctr < 256; ctr++ {
+        h := sha256.New()
+        h.Write(suite)
+        h.Write([]byte{0x01})
+        h.Write(pk)
+        h.Write(alpha)
+        h.Write([]byte{byte(ctr), 0x00})
+        candidate := append([]byte{0x02}, h.Sum(nil)...)
+        if point, err := crypto.DecompressPubkey(candidate); err == nil {
+            return point.X, point.Y, nil
+        }
+    }
+    return nil, nil, ErrInvalidProof // Probability 2^-256
+}
+
+// nonce derives the proof nonce deterministically from the key and H
+func nonce(privateKey *ecdsa.PrivateKey, hx, hy *big.Int) *big.Int {
+    n := crypto.S256().Params().N
+    h := sha256.New()
+    h.Write(suite)
+    h.Write(privateKey.D.FillBytes(make([]byte, 32)))
+    h.Write(compress(hx, hy))
+    k := new(big.Int).SetBytes(h.Sum(nil))
+    k.Mod(k, new(big.Int).Sub(n, big.NewInt(1)))
+    return k.Add(k, big.NewInt(1)) // 1 <= k < n
+}
+
+func challenge(hx, hy, gx, gy, ux, uy, vx, vy *big.Int) *big.Int {
+    h := sha256.New()
+    h.Write(suite)
+    h.Write([]byte{0x02})
+    h.Write(compress(hx, hy))
+    h.Write(compress(gx, gy))
+    h.Write(compress(ux, uy))
+    h.Write(compress(vx, vy))
+    h.Write([]byte{0x00})
+    c := new(big.Int).SetBytes(h.Sum(nil))
+    return c.Mod(c, crypto.S256().Params().N)
+}
+
+func output(gx, gy *big.Int) types.Hash {
+    h := sha256.New()
+    h.Write(suite)
+    h.Write([]byte{0x03})
+    h.Write(compress(gx, gy))
+    h.Write([]byte{0x00})
+    return types.Hash(h.Sum(nil))
+}
+
+func compress(x, y *big.Int) []byte {
+    return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})
+}
+
+func isInfinity(x, y *big.Int) bool {
+    return x.Sign() == 0 && y.Sign() == 0
+}
@@ -0,0 +1,149 @@
+package vrf
+
+import (
+    "crypto/ecdsa"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "testing"
+
+    "github.com/ethereum/go-ethereum/crypto"
+)
+
+// testKey derives a fixed private key from seed
+func testKey(t *testing.T, seed string) *ecdsa.PrivateKey {
+    t.Helper()
+    d := sha256.Sum256([]byte(seed))
+    key, err := crypto.ToECDSA(d[:])
+    if err != nil {
+        t.Fatal(err)
+    }
+    return key
+}
+
+// Regression vectors: the suite string is SelsiChain's own, so the outputs
+// differ from the RFC 9381 vectors, but they must never change: the outputs
+// are folded into the RANDAO mix of every PoS block
+var vectors = []struct {
+    seed   string
+    alpha  string
+    output string
+    proof  string
+}{
+    {
+        seed:   "selsichain-vrf-test-1",
+        alpha:  "",
+        output: "031c4d4b47e6c876e310478eab1c73af2d38db00f5df7ad0c2571a2d7504cb4d",
+        proof:  "032fd4207f9c2cbd71d8d3694acbd644517ecec4d5180215d2077e71508a9dd5cd1f4b8ba5d5e79249c77e4ba29ae29c79b0ede586e48728e8e81169409627a8515f5744dfa122a4d96debf233707f635cf2f5ee4fe374a012b20cbe1bfd3e6bfb",
+    },
+    {
+        seed:   "selsichain-vrf-test-2",
+        alpha:  "epoch 7",
+        output: "eb8c8bd21cb06a535674f58c6b93a480b49c272b4f7d2456bcdc9885ba8bd585",
+        proof:  "02ea2fad66ebc2cfde84409469419e7d8fc751fb81e6e3323202e2a1a37104177fcab781acefce5265bc3502b114e7a59ebc53f18f7e9463a9c651f101d0ab61479a30824ec398c8a93c5920efa39b19795c03aac891ab7644ac46770bad82fbf6",
+    },
+}
+
+func TestVectors(t *testing.T) {
+    for _, v := range vectors {
+        key := testKey(t, v.seed)
+        output, proof, err := Prove(key, []byte(v.alpha))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if hex.EncodeToString(output[:]) != v.output {
+            t.Errorf("%s: output %x, want %s", v.seed, output, v.output)
+        }
+        if hex.EncodeToString(proof) != v.proof {
+            t.Errorf("%s: proof %x, want %s", v.seed, proof, v.proof)
+        }
+
+        want, _ := hex.DecodeString(v.proof)
+        verified, err := Verify(&key.PublicKey, []byte(v.alpha), want)
+        if err != nil {
+            t.Fatalf("%s: vector proof rejected: %v", v.seed, err)
+        }
+        if hex.EncodeToString(verified[:]) != v.output {
+            t.Errorf("%s: verified output %x, want %s", v.seed, verified, v.output)
+        }
+    }
+}
+
+func TestProveVerifyRoundTrip(t *testing.T) {
+    key, err := crypto.GenerateKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    alpha := []byte("round trip")
+    output, proof, err := Prove(key, alpha)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(proof) != ProofLength {
+        t.Fatalf("proof of %d bytes, want %d", len(proof), ProofLength)
+    }
+    verified, err := Verify(&key.PublicKey, alpha, proof)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if verified != output {
+        t.Fatalf("verified output %x, prover got %x", verified, output)
+    }
+
+    // The output is unique: proving again gives the same one
+    again, _, err := Prove(key, alpha)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if again != output {
+        t.Fatal("VRF output is not deterministic")
+    }
+}
+
+func TestVerifyRejectsTamperedProof(t *testing.T) {
+    key := testKey(t, "selsichain-vrf-test-1")
+    alpha := []byte("tamper")
+    _, proof, err := Prove(key, alpha)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Flipping a bit in any byte of Gamma, c or s breaks the proof
+    for i := 0; i < len(proof); i++ {
+        tampered := append([]byte(nil), proof...)
+        tampered[i] ^= 0x01
+        if _, err := Verify(&key.PublicKey, alpha, tampered); !errors.Is(err, ErrInvalidProof) {
+            t.Fatalf("proof with byte %d flipped: got %v, want %v", i, err, ErrInvalidProof)
+        }
+    }
+
+    // c or s not reduced modulo n
+    n := crypto.S256().Params().N
+    for _, offset := range []int{pointLength, pointLength + 32} {
+        tampered := append([]byte(nil), proof...)
+        n.FillBytes(tampered[offset : offset+32])
+        if _, err := Verify(&key.PublicKey, alpha, tampered); !errors.Is(err, ErrInvalidProof) {
+            t.Fatalf("unreduced scalar at %d: got %v, want %v", offset, err, ErrInvalidProof)
+        }
+    }
+
+    if _, err := Verify(&key.PublicKey, alpha, proof[:ProofLength-1]); !errors.Is(err, ErrInvalidProof) {
+        t.Fatalf("truncated proof: got %v, want %v", err, ErrInvalidProof)
+    }
+}
+
+func TestVerifyRejectsOtherKeyOrInput(t *testing.T) {
+    key := testKey(t, "selsichain-vrf-test-1")
+    other := testKey(t, "selsichain-vrf-test-2")
+    alpha := []byte("input")
+    _, proof, err := Prove(key, alpha)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Verify(&other.PublicKey, alpha, proof); !errors.Is(err, ErrInvalidProof) {
+        t.Fatalf("proof checked against another key: got %v, want %v", err, ErrInvalidProof)
+    }
+    if _, err := Verify(&key.PublicKey, []byte("other input"), proof); !errors.Is(err, ErrInvalidProof) {
+        t.Fatalf("proof checked against another input: got %v, want %v", err, ErrInvalidProof)
+    }
+}
@@ -1,163 +1,24 @@
 package main
 
 import (
+    "errors"
     "flag"
     "fmt"
     "math/big"
     "os"
     "os/signal"
     "syscall"
-    "time"
-)
-
-// Config untuk blockchain
-type BlockchainConfig struct {
-    DataDir string
-}
-
-// Config untuk hybrid consensus
-type HybridConfig struct {
-    PowBlockInterval int
-    MiningDifficulty *big.Int
-    MinimumStake     *big.Int
-    BlockTime        time.Duration
-    RewardDistribution RewardConfig
-}
-
-type RewardConfig struct {
-    MinerPercent     int
-    StakerPercent    int
-    EcosystemPercent int
-    BurnPercent      int
-}
-
-// Config untuk network
-type NetworkConfig struct {
-    ListenAddr     string
-    BootstrapPeers []string
-    ChainID        uint64
-    ProtocolID     string
-}
-
-// Mock types untuk demo
-type Address [20]byte
-type Block struct {
-    Header BlockHeader
-}
-type BlockHeader struct {
-    Number *big.Int
-}
-type Transaction struct {
-    Nonce    uint64
-    GasPrice *big.Int
-    Gas      uint64
-    To       *Address
-    Value    *big.Int
-    Data     []byte
-    Type     string
-}
-
-// Mock functions untuk demo
-func NewBlockchain(config *BlockchainConfig, engine interface{}) (*Blockchain, error) {
-    fmt.Println("📦 Creating new blockchain...")
-    return &Blockchain{currentBlock: &Block{Header: BlockHeader{Number: big.NewInt(0)}}}, nil
-}
-
-type Blockchain struct {
-    currentBlock *Block
-}
 
-func (bc *Blockchain) GetCurrentBlock() *Block {
-    return bc.currentBlock
-}
-
-func (bc *Blockchain) GetBlockCount() int {
-    return 1
-}
-
-func (bc *Blockchain) AddBlock(block *Block) {
-    bc.currentBlock = block
-    fmt.Printf("✅ Added block #%s to chain\n", block.Header.Number)
-}
-
-func (bc *Blockchain) Close() {
-    fmt.Println("💾 Closing blockchain...")
-}
-
-func (bc *Blockchain) GetStateDB() interface{} {
-    return nil
-}
-
-func NewHybridEngine(config *HybridConfig) *HybridEngine {
-    fmt.Println("⚙️  Creating hybrid consensus engine...")
-    return &HybridEngine{config: config}
-}
-
-type HybridEngine struct {
-    config *HybridConfig
-}
-
-func (he *HybridEngine) CreateBlock(parent *Block, txs []*Transaction, validator Address) (*Block, error) {
-    newBlock := &Block{
-        Header: BlockHeader{
-            Number: new(big.Int).Add(parent.Header.Number, big.NewInt(1)),
-        },
-    }
-    fmt.Printf("🎯 Created block #%s\n", newBlock.Header.Number)
-    return newBlock, nil
-}
-
-func (he *HybridEngine) VerifyBlock(block *Block, state interface{}) error {
-    fmt.Printf("🔍 Verifying block #%s... ✅\n", block.Header.Number)
-    return nil
-}
-
-func NewNetwork(config *NetworkConfig, chain *Blockchain) (*Network, error) {
-    fmt.Println("🌐 Creating P2P network...")
-    return &Network{config: config}, nil
-}
-
-type Network struct {
-    config *NetworkConfig
-}
-
-func (n *Network) Start() error {
-    fmt.Printf("📍 Listening on: %s\n", n.config.ListenAddr)
-    fmt.Printf("🔗 Protocol: %s\n", n.config.ProtocolID)
-    fmt.Printf("⛓️  Chain ID: %d\n", n.config.ChainID)
-    fmt.Printf("🔌 Connecting to %d bootstrap peers...\n", len(n.config.BootstrapPeers))
-    
-    for _, peer := range n.config.BootstrapPeers {
-        fmt.Printf("   🔗 Attempting: %s\n", peer)
-    }
-    
-    fmt.Println("✅ P2P Network started successfully!")
-    return nil
-}
-
-func (n *Network) Stop() {
-    fmt.Println("🛑 Stopping P2P network...")
-}
-
-func (n *Network) GetListenAddr() string {
-    return n.config.ListenAddr
-}
-
-func (n *Network) GetPeerID() string {
-    return "selsichain-node-" + n.config.ListenAddr
-}
-
-func (n *Network) GetActivePeers() []interface{} {
-    return []interface{}{}
-}
-
-func (n *Network) GetPeers() []interface{} {
-    return []interface{}{}
-}
-
-func (n *Network) BroadcastBlock(block *Block) {
-    fmt.Printf("📤 Broadcasting block #%s to peers...\n", block.Header.Number)
-}
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus"
+    "github.com/selsichain/selsichain-core/core/consensus/dev"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/state"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+    "github.com/selsichain/selsichain-core/p2p/network"
+    "github.com/selsichain/selsichain-core/rpc"
+)
 
 func main() {
     // Cloud environment detection
@@ -172,15 +33,33 @@ func main() {
         fmt.Println("☁️  =================================")
     }
 
+    // Subcommands (init, ...) run instead of the node
+    if len(os.Args) > 1 {
+        if command, exists := commands[os.Args[1]]; exists {
+            if err := command(os.Args[2:]); err != nil {
+                fmt.Printf("❌ %s failed: %v\n", os.Args[1], err)
+                os.Exit(1)
+            }
+            return
+        }
+    }
+
     // Parse command line flags
     p2pPort := flag.String("p2p-port", "7690", "P2P network port")
     testnet := flag.Bool("testnet", false, "Enable testnet mode")
+    dataDir := flag.String("datadir", "./data", "Data directory for the chain database")
+    genesisFile := flag.String("genesis", "", "Genesis specification file (must match the initialised data directory)")
+    rpcAddr := flag.String("rpc-addr", "", "JSON-RPC listen address, e.g. 127.0.0.1:8545 (disabled if empty)")
+    rpcAdmin := flag.Bool("rpc-admin", false, "Expose the admin_ RPC methods (chain rewind, ...)")
+    mineThreads := flag.Int("mine-threads", 0, "Goroutines used to mine PoW checkpoints (0 = all CPUs)")
+    validatorKey := flag.String("validator-key", "", "Hex private key of the validator to propose and vote on PoS blocks with, agreeing with the other validators over directly connected peers (default: demo validators)")
+    devMode := flag.Bool("dev", false, "Run the dev consensus engine: seal a block instantly for every transaction sent with dev_sendTransaction, or on demand with dev_seal")
     flag.Parse()
 
-    startFullNode(*p2pPort, *testnet)
+    startFullNode(*p2pPort, *testnet, *dataDir, *genesisFile, *rpcAddr, *rpcAdmin, *mineThreads, *validatorKey, *devMode)
 }
 
-func startFullNode(p2pPort string, testnet bool) {
+func startFullNode(p2pPort string, testnet bool, dataDir string, genesisFile string, rpcAddr string, rpcAdmin bool, mineThreads int, validatorKey string, devMode bool) {
     // Use PORT from environment if running in cloud
     if envPort := os.Getenv("PORT"); envPort != "" && p2pPort == "7690" {
         p2pPort = envPort
@@ -191,40 +70,36 @@ func startFullNode(p2pPort string, testnet bool) {
     fmt.Println("🚀 Starting SelsiChain Full Node...")
     fmt.Println("")
 
-    // Initialize hybrid consensus
-    fmt.Println("🔄 Initializing Hybrid Consensus Engine...")
-    consensusConfig := &HybridConfig{
-        PowBlockInterval: 5,
-        MiningDifficulty: big.NewInt(1000000),
-        MinimumStake:     new(big.Int).Mul(big.NewInt(1000), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
-        BlockTime:        12 * time.Second,
-        RewardDistribution: RewardConfig{
-            MinerPercent:     45,
-            StakerPercent:    45,
-            EcosystemPercent: 7,
-            BurnPercent:      3,
-        },
-    }
-
     // Initialize P2P network config
-    networkConfig := &NetworkConfig{
+    networkConfig := &network.Config{
         ListenAddr: "/ip4/0.0.0.0/tcp/" + p2pPort,
         BootstrapPeers: []string{
             "/ip4/127.0.0.1/tcp/7691",
             "/ip4/127.0.0.1/tcp/7692",
         },
-        ChainID:    769,
         ProtocolID: "/selsichain",
     }
 
+    // Genesis: explicit file, testnet preset, or whatever the data directory was initialised with
+    var configuredGenesis *blockchain.Genesis
+    if genesisFile != "" {
+        genesis, err := blockchain.LoadGenesis(genesisFile)
+        if err != nil {
+            fmt.Printf("❌ Error: %v\n", err)
+            return
+        }
+        configuredGenesis = genesis
+    }
+
     // Testnet configuration
     if testnet {
         fmt.Println("🌐 TESTNET MODE ACTIVATED!")
         fmt.Println("🔧 Testnet Chain ID: 1337")
         fmt.Println("🎯 Testnet Validators: 5")
         
-        consensusConfig.PowBlockInterval = 3
-        consensusConfig.BlockTime = 10 * time.Second
+        if configuredGenesis == nil {
+            configuredGenesis = blockchain.TestnetGenesis()
+        }
         
         networkConfig.BootstrapPeers = []string{
             "/ip4/127.0.0.1/tcp/7690",
@@ -233,36 +108,122 @@ func startFullNode(p2pPort string, testnet bool) {
             "/ip4/127.0.0.1/tcp/7693",
             "/ip4/127.0.0.1/tcp/7694",
         }
-        networkConfig.ChainID = 1337
     }
 
-    consensusEngine := NewHybridEngine(consensusConfig)
+    // Open chain database and check it against the configured genesis
+    db, err := blockchain.OpenDatabase(dataDir)
+    if err != nil {
+        fmt.Printf("❌ Error: %v\n", err)
+        return
+    }
+    genesis, _, err := blockchain.SetupGenesis(db, configuredGenesis)
+    if err != nil {
+        db.Close()
+        if errors.Is(err, blockchain.ErrGenesisMismatch) {
+            fmt.Printf("❌ Refusing to start: %v\n", err)
+            fmt.Printf("💡 %s was initialised with a different genesis; use another --datadir or the matching --genesis\n", dataDir)
+            os.Exit(1)
+        }
+        fmt.Printf("❌ Error: %v\n", err)
+        return
+    }
+    networkConfig.ChainID = genesis.ChainID
+
+    // Initialize consensus: hybrid, or the instant-seal dev engine
+    var (
+        engine          consensus.Engine
+        consensusEngine *hybrid.HybridEngine
+        devEngine       *dev.Engine
+        validatorKeys   []*keys.KeyPair
+    )
+    if devMode {
+        fmt.Println("🧪 Initializing Dev Consensus Engine...")
+        devEngine = dev.NewEngine(&dev.Config{Upgrades: genesis.Config.Upgrades})
+        engine = devEngine
+    } else {
+        fmt.Println("🔄 Initializing Hybrid Consensus Engine...")
+        consensusEngine = hybrid.NewHybridEngine(genesis.Config)
+        consensusEngine.SetMiningThreads(mineThreads)
+        if validatorKey != "" {
+            key, err := new(keys.KeyManager).ImportPrivateKey(validatorKey)
+            if err != nil {
+                fmt.Printf("❌ Invalid --validator-key: %v\n", err)
+                return
+            }
+            validatorKeys = append(validatorKeys, key)
+        } else if usesDevValidators(genesis) {
+            fmt.Println("⚠️  Validating with the public demo validator keys")
+            validatorKeys = blockchain.DevValidatorKeys()
+        }
+        consensusEngine.Authorize(validatorKeys...)
+        engine = consensusEngine
+    }
 
     // Initialize blockchain
     fmt.Println("🔄 Creating blockchain...")
-    chain, err := NewBlockchain(&BlockchainConfig{
-        DataDir: "./data",
-    }, consensusEngine)
+    chain, err := blockchain.NewBlockchain(&blockchain.Config{
+        DataDir:  dataDir,
+        Genesis:  genesis,
+        Database: db,
+    }, engine)
 
     if err != nil {
         fmt.Printf("❌ Error: %v\n", err)
         return
     }
 
+    // Dev: blocks are sealed by the sealer, fed by the dev_ RPC methods
+    var sealer *dev.Sealer
+    if devEngine != nil {
+        sealer = dev.NewSealer(devEngine, chain, blockchain.DevValidatorKeys()[0].Address)
+    }
+
+    // Local validators agree on PoS blocks over an in-process network, bridged
+    // to the peers below
+    agreementNetwork := hybrid.NewLocalNetwork()
+    var validators []*hybrid.BFT
+    for _, key := range validatorKeys {
+        validators = append(validators, hybrid.NewBFT(consensusEngine, chain, key, agreementNetwork.Join(), hybrid.BFTConfig{}))
+    }
+
     // Initialize P2P network
     fmt.Println("🌐 Initializing P2P Network...")
-    p2pNetwork, err := NewNetwork(networkConfig, chain)
+    p2pNetwork, err := network.NewNetwork(networkConfig, chain)
     if err != nil {
         fmt.Printf("❌ Failed to initialize P2P network: %v\n", err)
         return
     }
 
+    // A validator of a multi-node set agrees with the others over the peers.
+    // The demo keys stay in-process: every demo node holds all of them.
+    if validatorKey != "" {
+        agreementNetwork.SetRelay(p2pNetwork.BroadcastConsensus)
+        p2pNetwork.SetConsensusHandler(agreementNetwork.Deliver)
+    }
+
     // Start P2P network
     if err := p2pNetwork.Start(); err != nil {
         fmt.Printf("❌ Failed to start P2P network: %v\n", err)
         return
     }
 
+    // Start JSON-RPC server
+    rpcServer := rpc.NewServer()
+    rpc.RegisterChainAPI(rpcServer, chain)
+    rpc.RegisterDebugAPI(rpcServer, chain)
+    if rpcAdmin {
+        rpc.RegisterAdminAPI(rpcServer, chain)
+    }
+    if sealer != nil {
+        rpc.RegisterDevAPI(rpcServer, chain, sealer)
+    }
+    if rpcAddr != "" {
+        if err := rpcServer.Start(rpcAddr); err != nil {
+            fmt.Printf("❌ Failed to start RPC server: %v\n", err)
+            return
+        }
+    }
+
     // Display node info
     currentBlock := chain.GetCurrentBlock()
     
@@ -279,63 +240,209 @@ func startFullNode(p2pPort string, testnet bool) {
     fmt.Println("💡 Cloud deployment active - Demo mode")
     fmt.Println("")
 
-    // Demo: Create blocks
-    fmt.Println("🧪 Creating demo blocks...")
-    createDemoBlocks(chain, consensusEngine, p2pNetwork)
+    quit := make(chan struct{})
+    producerDone := make(chan struct{})
+    if sealer != nil {
+        // Dev: seal a block for every batch of pending transactions
+        fmt.Println("🧪 Sealing dev blocks on dev_sendTransaction and dev_seal...")
+        sealer.Start()
+        go func() {
+            defer close(producerDone)
+            <-quit
+            sealer.Stop()
+        }()
+    } else {
+        // Demo: Create blocks in the background until shutdown, one per slot
+        fmt.Printf("🧪 Creating demo blocks every %v...\n", genesis.Config.BlockTime)
+        slots := hybrid.NewSlotClock(genesis.Timestamp, genesis.Config.BlockTime, consensusEngine.Clock())
+        go func() {
+            defer close(producerDone)
+            createDemoBlocks(chain, consensusEngine, slots, validators, quit)
+        }()
+    }
     
     fmt.Println("")
     fmt.Println("✅ Node is running and ready!")
     fmt.Println("⏳ Press Ctrl+C to shutdown")
 
-    waitForShutdown(chain, p2pNetwork)
+    waitForShutdown(chain, p2pNetwork, rpcServer, quit, producerDone)
 }
 
-func createDemoBlocks(chain *Blockchain, consensus *HybridEngine, p2pNetwork *Network) {
-    blockCount := 1
+// miningInterrupt returns a channel that is closed once a block at number or
+// higher becomes the head (mined elsewhere) or quit is closed. Call release
+// when the block is done.
+func miningInterrupt(chain *blockchain.Blockchain, number *big.Int, quit <-chan struct{}) (<-chan struct{}, func()) {
+    stop := make(chan struct{})
+    heads := chain.SubscribeNewHead(4)
+    go func() {
+        defer close(stop)
+        for {
+            select {
+            case event, ok := <-heads.Chan():
+                if !ok {
+                    return // Released
+                }
+                if event.Block.Header.Number.Cmp(number) >= 0 {
+                    fmt.Printf("📥 Competing block #%s arrived\n", event.Block.Header.Number)
+                    return
+                }
+            case <-quit:
+                return
+            }
+        }
+    }()
+    return stop, heads.Unsubscribe
+}
+
+// agree runs the agreement on the PoS block on top of parent with every local
+// validator and returns the block they commit. The other validators stop once
+// one of them committed.
+func agree(chain *blockchain.Blockchain, consensus *hybrid.HybridEngine, validators []*hybrid.BFT, parent *types.Block, txs []*types.Transaction, coinbase types.Address, parentState *state.StateDB, stop <-chan struct{}) (*types.Block, error) {
+    if len(validators) == 0 {
+        return nil, fmt.Errorf("%w: no local validator keys", hybrid.ErrNotProposer)
+    }
+    build := func(round uint32) (*types.Block, error) {
+        return consensus.CreateProposal(chain, parent, round, txs, coinbase, parentState)
+    }
+    
+    committed := make(chan struct{})
+    abort := make(chan struct{})
+    go func() {
+        select {
+        case <-stop:
+        case <-committed:
+        }
+        close(abort)
+    }()
+    
+    type result struct {
+        block *types.Block
+        err   error
+    }
+    results := make(chan result, len(validators))
+    for _, validator := range validators {
+        go func(validator *hybrid.BFT) {
+            block, err := validator.Agree(parent, build, abort)
+            results <- result{block, err}
+        }(validator)
+    }
+    
+    var block *types.Block
+    var err error
+    for range validators {
+        r := <-results
+        if r.err == nil && block == nil {
+            block = r.block
+            close(committed)
+        } else if r.err != nil && err == nil {
+            err = r.err
+        }
+    }
+    if block == nil {
+        close(committed)
+        return nil, err
+    }
+    return block, nil
+}
+
+func createDemoBlocks(chain *blockchain.Blockchain, consensus *hybrid.HybridEngine, slots *hybrid.SlotClock, validators []*hybrid.BFT, quit <-chan struct{}) {
+    // Demo account: mines the blocks and spends its rewards on sample transfers
+    demoKey, err := new(keys.KeyManager).GenerateKey()
+    if err != nil {
+        fmt.Printf("❌ Failed to create demo key: %v\n", err)
+        return
+    }
+    fmt.Printf("🔑 Demo miner account: %x\n", demoKey.Address[:4])
     
+    next := uint64(0) // Earliest slot to try in
     for {
-        fmt.Printf("\n🎯 Creating block #%d...\n", blockCount)
+        // One attempt per slot, in the first slot after the head's
+        head := chain.GetCurrentBlock()
+        if slot := slots.SlotAt(head.Header.Time) + 1; slot > next {
+            next = slot
+        }
+        if !slots.WaitForSlot(next, quit) {
+            return
+        }
+        select {
+        case <-quit:
+            return
+        default:
+        }
+        next = slots.CurrentSlot() + 1
         
-        currentBlock := chain.GetCurrentBlock()
+        currentBlock, statedb := chain.CurrentState()
+        blockCount := int(currentBlock.Header.Number.Int64()) + 1
+        fmt.Printf("\n🎯 Creating block #%d...\n", blockCount)
         
-        // Create sample transaction
-        toAddr := Address{2}
-        tx := &Transaction{
-            Nonce:    uint64(blockCount),
+        // Create sample transaction once the demo account has earned enough
+        var txs []*types.Transaction
+        toAddr := types.Address{2}
+        tx := &types.Transaction{
+            Nonce:    statedb.GetNonce(demoKey.Address),
             GasPrice: big.NewInt(1000000000),
             Gas:      21000,
             To:       &toAddr,
             Value:    new(big.Int).Mul(big.NewInt(10), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
             Data:     []byte{},
-            Type:     "regular",
+            Type:     types.TxRegular,
+            ChainID:  chain.ChainID(),
+        }
+        cost := new(big.Int).Add(tx.Value, new(big.Int).Mul(tx.GasPrice, new(big.Int).SetUint64(tx.Gas)))
+        if statedb.GetBalance(demoKey.Address).Cmp(cost) >= 0 && demoKey.SignTransaction(tx) == nil {
+            txs = append(txs, tx)
         }
         
-        // Create block
-        newBlock, err := consensus.CreateBlock(
-            currentBlock,
-            []*Transaction{tx},
-            Address{byte(blockCount % 3 + 1)},
-        )
+        // Mine a checkpoint or agree on a PoS block; both stop early if
+        // someone else produces this height
+        number := new(big.Int).SetInt64(int64(blockCount))
+        stop, release := miningInterrupt(chain, number, quit)
+        var newBlock *types.Block
+        if consensus.IsCheckpoint(number) {
+            newBlock, err = consensus.CreateBlock(chain, currentBlock, txs, demoKey.Address, statedb, stop)
+        } else {
+            newBlock, err = agree(chain, consensus, validators, currentBlock, txs, demoKey.Address, statedb, stop)
+        }
+        release()
         
-        if err == nil {
-            if consensus.VerifyBlock(newBlock, nil) == nil {
-                chain.AddBlock(newBlock)
-                p2pNetwork.BroadcastBlock(newBlock)
+        if errors.Is(err, hybrid.ErrMiningAborted) || errors.Is(err, hybrid.ErrAgreementAborted) {
+            fmt.Printf("🛑 Stopped producing block #%d\n", blockCount)
+        } else if errors.Is(err, hybrid.ErrSlotNotReached) {
+            fmt.Printf("⏳ Block #%d waits for the next slot: %v\n", blockCount, err)
+        } else if errors.Is(err, hybrid.ErrNotProposer) {
+            fmt.Printf("⏳ Waiting for block #%d from another validator: %v\n", blockCount, err)
+        } else if err == nil {
+            // The network broadcasts it when the new head event fires
+            if err := chain.AddBlock(newBlock); err == nil {
                 fmt.Printf("✅ Block #%d created and broadcasted\n", blockCount)
                 
                 if blockCount%10 == 0 {
                     fmt.Printf("🎉 Milestone: %d blocks produced!\n", blockCount)
                 }
+            } else {
+                fmt.Printf("❌ Block #%d rejected: %v\n", blockCount, err)
             }
+        } else {
+            fmt.Printf("❌ Failed to create block #%d: %v\n", blockCount, err)
         }
-        
-        // Wait 15 seconds before next block
-        time.Sleep(15 * time.Second)
-        blockCount++
     }
 }
 
-func waitForShutdown(chain *Blockchain, p2pNetwork *Network) {
+// usesDevValidators reports whether every genesis validator is a demo validator
+func usesDevValidators(genesis *blockchain.Genesis) bool {
+    devAddresses := make(map[types.Address]bool)
+    for _, key := range blockchain.DevValidatorKeys() {
+        devAddresses[key.Address] = true
+    }
+    for _, validator := range genesis.Validators {
+        if !devAddresses[validator.Address] {
+            return false
+        }
+    }
+    return len(genesis.Validators) > 0
+}
+
+func waitForShutdown(chain *blockchain.Blockchain, p2pNetwork *network.Network, rpcServer *rpc.Server, quit chan struct{}, producerDone <-chan struct{}) {
     sigCh := make(chan os.Signal, 1)
     signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
     
@@ -343,8 +450,13 @@ func waitForShutdown(chain *Blockchain, p2pNetwork *Network) {
     fmt.Println("")
     fmt.Println("🛑 Shutdown signal received...")
     
+    // Stop block production (aborts mining in progress) before closing the chain
+    close(quit)
+    <-producerDone
+    
+    rpcServer.Stop()
     p2pNetwork.Stop()
     chain.Close()
     
     fmt.Println("👋 SelsiChain node stopped gracefully")
-}
+}
\ No newline at end of file
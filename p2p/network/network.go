@@ -1,12 +1,16 @@
 package network
 
 import (
+    "encoding/json"
+    "errors"
     "fmt"
     "net"
     "sync"
     "time"
     
     "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/forks"
     "github.com/selsichain/selsichain-core/core/types"
 )
 
@@ -16,7 +20,13 @@ type Network struct {
     config  *Config
     peers   map[string]*PeerInfo
     mu      sync.RWMutex
-    running bool
+    quit    chan struct{} // Closed by Stop
+    headSub *blockchain.Subscription[blockchain.NewHeadEvent]
+
+    consensusHandler func(msg *hybrid.ConsensusMessage) // Set by SetConsensusHandler
+    
+    listener   net.Listener
+    listenAddr string // Bound address, once started
 }
 
 // Config holds network configuration
@@ -27,21 +37,38 @@ type Config struct {
     ProtocolID    string
 }
 
+var (
+    ErrChainIDMismatch = errors.New("peer is on a different chain ID")
+    ErrGenesisMismatch = errors.New("peer has a different genesis block")
+)
+
+// Status is exchanged by peers when they connect (see handshake). Peers on
+// another chain, with another consensus config or an incompatible upgrade
+// schedule are refused before they exchange blocks.
+type Status struct {
+    ChainID uint64     `json:"chainId"`
+    Genesis types.Hash `json:"genesis"`
+    ForkID  forks.ID   `json:"forkId"`
+    Head    uint64     `json:"head"`
+}
+
 // PeerInfo holds information about connected peers
 type PeerInfo struct {
     ID        string
     Address   string
     Connected bool
     LastSeen  time.Time
+    
+    conn    net.Conn   // Open once the handshake succeeded
+    writeMu sync.Mutex // Serialises messages sent on conn
 }
 
 // NewNetwork creates a new P2P network
 func NewNetwork(config *Config, chain *blockchain.Blockchain) (*Network, error) {
     network := &Network{
-        chain:   chain,
-        config:  config,
-        peers:   make(map[string]*PeerInfo),
-        running: false,
+        chain:  chain,
+        config: config,
+        peers:  make(map[string]*PeerInfo),
     }
 
     return network, nil
@@ -53,8 +80,21 @@ func (n *Network) Start() error {
     fmt.Printf("📍 Will listen on: %s\n", n.config.ListenAddr)
     fmt.Printf("🔗 Protocol: %s\n", n.config.ProtocolID)
     fmt.Printf("⛓️  Chain ID: %d\n", n.config.ChainID)
+    fmt.Printf("🍴 Fork ID: %s\n", n.chain.ForkID())
     
-    n.running = true
+    host, port, err := n.parseAddress(n.config.ListenAddr)
+    if err != nil {
+        return err
+    }
+    listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+    if err != nil {
+        return fmt.Errorf("cannot listen on %s: %w", n.config.ListenAddr, err)
+    }
+    n.listener = listener
+    n.listenAddr = fmt.Sprintf("/ip4/%s/tcp/%d", host, listener.Addr().(*net.TCPAddr).Port)
+    go n.listen(listener)
+    
+    n.quit = make(chan struct{})
     
     // Connect to bootstrap peers
     if len(n.config.BootstrapPeers) > 0 {
@@ -66,6 +106,10 @@ func (n *Network) Start() error {
     go n.monitorNetwork()
     go n.cleanupDeadPeers()
     
+    // Announce every new canonical head
+    n.headSub = n.chain.SubscribeNewHead(16)
+    go n.broadcastNewHeads(n.headSub)
+    
     fmt.Printf("✅ P2P Network started successfully!\n")
     return nil
 }
@@ -73,7 +117,22 @@ func (n *Network) Start() error {
 // Stop shuts down the network
 func (n *Network) Stop() {
     fmt.Printf("🛑 Stopping P2P Network...\n")
-    n.running = false
+    if n.quit != nil {
+        close(n.quit)
+    }
+    if n.headSub != nil {
+        n.headSub.Unsubscribe()
+    }
+    if n.listener != nil {
+        n.listener.Close()
+    }
+    n.mu.Lock()
+    for _, peer := range n.peers {
+        if peer.conn != nil {
+            peer.conn.Close()
+        }
+    }
+    n.mu.Unlock()
     fmt.Printf("✅ P2P Network stopped\n")
 }
 
@@ -82,31 +141,50 @@ func (n *Network) GetPeerID() string {
     return "selsichain-node-" + n.config.ListenAddr
 }
 
-// GetListenAddr returns the listening address
+// GetListenAddr returns the listening address, with the bound port once the
+// network started
 func (n *Network) GetListenAddr() string {
+    if n.listenAddr != "" {
+        return n.listenAddr
+    }
     return n.config.ListenAddr
 }
 
-// AddPeer adds a peer to the network WITH REAL CONNECTION CHECK
+// AddPeer connects to a peer and adds it once the handshake accepted its
+// chain and fork ID (see HandleStatus)
 func (n *Network) AddPeer(address string) error {
-    // ✅ REAL CONNECTION CHECK sebelum tambah peer
-    if !n.checkPeerConnection(address) {
+    conn := n.dialPeer(address)
+    if conn == nil {
         return fmt.Errorf("cannot connect to peer %s", address)
     }
-    
+    decoder := json.NewDecoder(conn)
+    if err := n.handshake(address, conn, decoder, true); err != nil {
+        conn.Close()
+        return err
+    }
+    n.addConnectedPeer(address, conn, decoder)
+    return nil
+}
+
+// addConnectedPeer registers a peer that passed the handshake and starts
+// reading its messages. A previous connection to the same address is closed.
+func (n *Network) addConnectedPeer(address string, conn net.Conn, decoder *json.Decoder) {
     n.mu.Lock()
-    defer n.mu.Unlock()
-    
     peerID := "peer-" + address
+    if old, exists := n.peers[peerID]; exists && old.conn != nil {
+        old.conn.Close()
+    }
     n.peers[peerID] = &PeerInfo{
         ID:        peerID,
         Address:   address,
         Connected: true,
         LastSeen:  time.Now(),
+        conn:      conn,
     }
+    n.mu.Unlock()
     
     fmt.Printf("✅ Added peer: %s\n", address)
-    return nil
+    go n.readLoop(address, conn, decoder)
 }
 
 // parseAddress extracts host and port from libp2p-style address ✅ FIXED
@@ -145,51 +223,53 @@ func (n *Network) parseAddress(address string) (string, string, error) {
     return ip, port, nil
 }
 
-// checkPeerConnection checks if we can actually connect to the peer ✅ IMPROVED
-func (n *Network) checkPeerConnection(address string) bool {
+// dialPeer opens a TCP connection to the peer, nil if it cannot be reached
+func (n *Network) dialPeer(address string) net.Conn {
     // Extract host:port from address
     host, port, err := n.parseAddress(address)
     if err != nil {
         fmt.Printf("❌ Invalid peer address %s: %v\n", address, err)
-        return false
+        return nil
     }
     
     // Skip self-connection check
     if n.isSelfConnection(host, port) {
         fmt.Printf("🔇 Skipping self-connection: %s\n", address)
-        return false
+        return nil
     }
     
     // Validate port
     if port == "" {
         fmt.Printf("❌ Empty port in address: %s\n", address)
-        return false
+        return nil
     }
     
     // Try to establish TCP connection
-    timeout := 2 * time.Second
     target := net.JoinHostPort(host, port)
-    
-    conn, err := net.DialTimeout("tcp", target, timeout)
+    conn, err := net.DialTimeout("tcp", target, dialTimeout)
     if err != nil {
         fmt.Printf("🌐 Cannot connect to peer %s (%s): %v\n", address, target, err)
-        return false
+        return nil
     }
-    conn.Close()
     
     fmt.Printf("🔗 Successfully connected to peer: %s (%s)\n", address, target)
-    return true
+    return conn
 }
 
 // isSelfConnection checks if the peer address is our own address ✅ IMPROVED
 func (n *Network) isSelfConnection(host, port string) bool {
-    selfHost, selfPort, err := n.parseAddress(n.config.ListenAddr)
+    selfHost, selfPort, err := n.parseAddress(n.GetListenAddr())
     if err != nil {
-        fmt.Printf("⚠️  Cannot parse self address %s: %v\n", n.config.ListenAddr, err)
+        fmt.Printf("⚠️  Cannot parse self address %s: %v\n", n.GetListenAddr(), err)
         return false
     }
     
-    isSelf := host == selfHost && port == selfPort
+    // Listening on all interfaces (0.0.0.0) includes loopback
+    sameHost := host == selfHost
+    if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() && net.ParseIP(selfHost).IsUnspecified() {
+        sameHost = true
+    }
+    isSelf := sameHost && port == selfPort
     return isSelf
 }
 
@@ -199,12 +279,30 @@ func (n *Network) RemovePeer(address string) {
     defer n.mu.Unlock()
     
     peerID := "peer-" + address
-    if _, exists := n.peers[peerID]; exists {
+    if peer, exists := n.peers[peerID]; exists {
+        if peer.conn != nil {
+            peer.conn.Close()
+        }
         delete(n.peers, peerID)
+        n.chain.DropPeerBlocks(peerID)
         fmt.Printf("🧹 Removed peer: %s\n", address)
     }
 }
 
+// removePeerConn removes the peer at address if conn is still its
+// connection, and not one that replaced it
+func (n *Network) removePeerConn(address string, conn net.Conn) {
+    n.mu.RLock()
+    peer, exists := n.peers["peer-"+address]
+    current := exists && peer.conn == conn
+    n.mu.RUnlock()
+    if current {
+        n.RemovePeer(address)
+    } else {
+        conn.Close()
+    }
+}
+
 // GetPeers returns list of all peers in memory
 func (n *Network) GetPeers() []*PeerInfo {
     n.mu.RLock()
@@ -219,21 +317,16 @@ func (n *Network) GetPeers() []*PeerInfo {
     return peers
 }
 
-// GetActivePeers returns only ACTUALLY connected peers ✅ ENHANCED
+// GetActivePeers returns the peers with an open connection. A connection
+// that fails removes its peer (see readLoop).
 func (n *Network) GetActivePeers() []*PeerInfo {
     n.mu.RLock()
     defer n.mu.RUnlock()
 
     activePeers := make([]*PeerInfo, 0)
     for _, peer := range n.peers {
-        // Double-check connection status dengan real TCP check
-        if peer.Connected && n.checkPeerConnection(peer.Address) {
+        if peer.Connected && peer.conn != nil {
             activePeers = append(activePeers, peer)
-            peer.LastSeen = time.Now() // Update last seen time
-        } else {
-            // Mark as disconnected if check fails
-            peer.Connected = false
-            fmt.Printf("🔴 Peer disconnected: %s\n", peer.Address)
         }
     }
     return activePeers
@@ -248,11 +341,9 @@ func (n *Network) BroadcastBlock(block *types.Block) {
     fmt.Printf("📤 [P2P] Broadcasting block #%s to %d active peers\n", block.Header.Number, peerCount)
     
     for _, peer := range activePeers {
-        if peer.Connected {
-            fmt.Printf("   ➡️  Sending to %s\n", peer.Address)
-            // Update last seen time
-            peer.LastSeen = time.Now()
-            // In real implementation, this would send over network
+        fmt.Printf("   ➡️  Sending to %s\n", peer.Address)
+        if err := peer.send(&message{Code: msgBlock, Block: block}); err != nil {
+            fmt.Printf("   ❌ Sending to %s failed: %v\n", peer.Address, err)
         }
     }
     
@@ -261,6 +352,92 @@ func (n *Network) BroadcastBlock(block *types.Block) {
     }
 }
 
+// LocalStatus returns the status this node announces at handshake
+func (n *Network) LocalStatus() Status {
+    genesis := n.chain.GetGenesisBlock()
+    return Status{
+        ChainID: n.config.ChainID,
+        Genesis: n.chain.CalculateHash(genesis.Header),
+        ForkID:  n.chain.ForkID(),
+        Head:    n.chain.GetCurrentBlock().Header.Number.Uint64(),
+    }
+}
+
+// HandleStatus checks the status a peer announced at handshake and drops the
+// peer if it runs another chain or an incompatible upgrade schedule
+func (n *Network) HandleStatus(peerAddr string, status Status) error {
+    local := n.LocalStatus()
+    var err error
+    switch {
+    case status.ChainID != local.ChainID:
+        err = fmt.Errorf("%w: %d, local %d", ErrChainIDMismatch, status.ChainID, local.ChainID)
+    case status.Genesis != local.Genesis:
+        err = fmt.Errorf("%w: %x, local %x", ErrGenesisMismatch, status.Genesis[:4], local.Genesis[:4])
+    default:
+        err = n.chain.CheckForkID(status.ForkID)
+    }
+    if err != nil {
+        fmt.Printf("🚫 [P2P] Handshake with %s failed: %v\n", peerAddr, err)
+        n.RemovePeer(peerAddr)
+        return err
+    }
+    
+    n.UpdatePeerHealth(peerAddr)
+    fmt.Printf("🤝 [P2P] Handshake with %s (fork ID %s, head #%d)\n", peerAddr, status.ForkID, status.Head)
+    return nil
+}
+
+// HandleBlock processes a block delivered by a peer. Blocks arriving before
+// their parent or slightly early are buffered by the chain.
+func (n *Network) HandleBlock(peerAddr string, block *types.Block) {
+    n.UpdatePeerHealth(peerAddr)
+    
+    if err := n.chain.InsertBlock(block, "peer-"+peerAddr); err != nil {
+        fmt.Printf("❌ [P2P] Block #%s from %s rejected: %v\n", block.Header.Number, peerAddr, err)
+    }
+}
+
+// SetConsensusHandler hands the agreement messages peers send to handler,
+// e.g. hybrid.LocalNetwork.Deliver. Without a handler they are ignored.
+func (n *Network) SetConsensusHandler(handler func(msg *hybrid.ConsensusMessage)) {
+    n.mu.Lock()
+    n.consensusHandler = handler
+    n.mu.Unlock()
+}
+
+// HandleConsensus processes an agreement message delivered by a peer. The
+// validators check its signature themselves.
+func (n *Network) HandleConsensus(peerAddr string, msg *hybrid.ConsensusMessage) {
+    n.UpdatePeerHealth(peerAddr)
+
+    n.mu.RLock()
+    handler := n.consensusHandler
+    n.mu.RUnlock()
+    if handler != nil {
+        handler(msg)
+    }
+}
+
+// BroadcastConsensus sends an agreement message to all active peers without
+// waiting for them. Peers do not forward it: validators on different nodes
+// must be connected to each other directly.
+func (n *Network) BroadcastConsensus(msg *hybrid.ConsensusMessage) {
+    for _, peer := range n.GetActivePeers() {
+        go func(peer *PeerInfo) {
+            if err := peer.send(&message{Code: msgConsensus, Consensus: msg}); err != nil {
+                fmt.Printf("   ❌ Sending consensus message to %s failed: %v\n", peer.Address, err)
+            }
+        }(peer)
+    }
+}
+
+// broadcastNewHeads forwards chain head events to peers until unsubscribed
+func (n *Network) broadcastNewHeads(sub *blockchain.Subscription[blockchain.NewHeadEvent]) {
+    for event := range sub.Chan() {
+        n.BroadcastBlock(event.Block)
+    }
+}
+
 // BroadcastTransaction broadcasts a transaction to all peers
 func (n *Network) BroadcastTransaction(tx *types.Transaction) {
     activePeers := n.GetActivePeers()
@@ -294,15 +471,23 @@ func (n *Network) cleanupDeadPeers() {
     ticker := time.NewTicker(60 * time.Second) // Check every minute
     defer ticker.Stop()
 
-    for n.running {
-        <-ticker.C
+    for {
+        select {
+        case <-ticker.C:
+        case <-n.quit:
+            return
+        }
         
         n.mu.Lock()
         removedCount := 0
         for peerID, peer := range n.peers {
             // Remove peers not seen for more than 3 minutes
             if time.Since(peer.LastSeen) > 3*time.Minute {
+                if peer.conn != nil {
+                    peer.conn.Close()
+                }
                 delete(n.peers, peerID)
+                n.chain.DropPeerBlocks(peerID)
                 removedCount++
                 fmt.Printf("🧹 [P2P] Cleaned up dead peer: %s (last seen: %v ago)\n", 
                     peer.Address, time.Since(peer.LastSeen))
@@ -321,11 +506,17 @@ func (n *Network) monitorNetwork() {
     ticker := time.NewTicker(30 * time.Second)
     defer ticker.Stop()
 
-    for n.running {
-        <-ticker.C
+    for {
+        select {
+        case <-ticker.C:
+        case <-n.quit:
+            return
+        }
         
         activePeers := n.GetActivePeers()
+        n.mu.RLock()
         totalPeers := len(n.peers)
+        n.mu.RUnlock()
         
         fmt.Printf("📊 [P2P] Network stats: %d active peers (%d total in list)\n", 
             len(activePeers), totalPeers)
@@ -0,0 +1,181 @@
+package network
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "math/big"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/dev"
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/forks"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// newTestNode starts a network on a free loopback port for an in-memory dev
+// chain created from genesis
+func newTestNode(t *testing.T, genesis *blockchain.Genesis) (*Network, *blockchain.Blockchain, *dev.Engine) {
+    t.Helper()
+    engine := dev.NewEngine(nil)
+    chain, err := blockchain.NewBlockchain(&blockchain.Config{Genesis: genesis}, engine)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(chain.Close)
+
+    network, err := NewNetwork(&Config{ListenAddr: "/ip4/127.0.0.1/tcp/0", ChainID: genesis.ChainID}, chain)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := network.Start(); err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(network.Stop)
+    return network, chain, engine
+}
+
+// waitForPeers waits until network has count active peers
+func waitForPeers(t *testing.T, network *Network, count int) {
+    t.Helper()
+    for deadline := time.Now().Add(5 * time.Second); len(network.GetActivePeers()) != count; {
+        if time.Now().After(deadline) {
+            t.Fatalf("%d active peers, want %d", len(network.GetActivePeers()), count)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+func TestHandshakeAndBlockExchange(t *testing.T) {
+    local, localChain, engine := newTestNode(t, blockchain.DefaultGenesis())
+    remote, remoteChain, _ := newTestNode(t, blockchain.DefaultGenesis())
+
+    if err := remote.AddPeer(local.GetListenAddr()); err != nil {
+        t.Fatal(err)
+    }
+    waitForPeers(t, local, 1)
+    waitForPeers(t, remote, 1)
+
+    // A block sealed locally reaches the remote chain through HandleBlock
+    heads := remoteChain.SubscribeNewHead(1)
+    defer heads.Unsubscribe()
+    block, err := dev.NewSealer(engine, localChain, types.Address{0xc}).Commit(nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case event := <-heads.Chan():
+        if event.Hash != localChain.CalculateHash(block.Header) {
+            t.Fatalf("remote head %x, want %x", event.Hash, localChain.CalculateHash(block.Header))
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("block not delivered to the peer")
+    }
+}
+
+func TestHandshakeRejectsOtherConfig(t *testing.T) {
+    local, _, _ := newTestNode(t, blockchain.DefaultGenesis())
+
+    // Same genesis block, different consensus config
+    genesis := blockchain.DefaultGenesis()
+    genesis.Config.BlockTime *= 2
+    remote, _, _ := newTestNode(t, genesis)
+
+    if err := remote.AddPeer(local.GetListenAddr()); !errors.Is(err, forks.ErrLocalIncompatibleOrStale) {
+        t.Fatalf("got %v, want %v", err, forks.ErrLocalIncompatibleOrStale)
+    }
+    if len(remote.GetActivePeers()) != 0 {
+        t.Fatal("incompatible peer added")
+    }
+    time.Sleep(100 * time.Millisecond)
+    if len(local.GetActivePeers()) != 0 {
+        t.Fatal("incompatible peer accepted on the listening side")
+    }
+}
+
+// A peer sending a block or agreement message with null fields is dropped
+// before the message reaches the chain or the validators
+func TestMalformedBlockDropsPeer(t *testing.T) {
+    local, chain, _ := newTestNode(t, blockchain.DefaultGenesis())
+    genesisHash := chain.CalculateHash(chain.GetGenesisBlock().Header)
+
+    for _, test := range []struct {
+        name string
+        msg  *message
+    }{
+        {"null number", &message{Code: msgBlock, Block: &types.Block{Header: &types.Header{ParentHash: genesisHash, Difficulty: big.NewInt(1)}}}},
+        {"null transaction", &message{Code: msgBlock, Block: &types.Block{
+            Header:       &types.Header{ParentHash: genesisHash, Number: big.NewInt(1), Difficulty: big.NewInt(1)},
+            Transactions: []*types.Transaction{nil},
+        }}},
+        {"null vote", &message{Code: msgBlock, Block: &types.Block{
+            Header:     &types.Header{ParentHash: genesisHash, Number: big.NewInt(1), Difficulty: big.NewInt(1)},
+            LastCommit: []*types.Vote{nil},
+        }}},
+        {"empty consensus message", &message{Code: msgConsensus, Consensus: &hybrid.ConsensusMessage{}}},
+        {"proposal without header", &message{Code: msgConsensus, Consensus: &hybrid.ConsensusMessage{
+            Proposal: &types.Proposal{Number: 1, Block: &types.Block{}},
+        }}},
+    } {
+        host, port, err := local.parseAddress(local.GetListenAddr())
+        if err != nil {
+            t.Fatal(err)
+        }
+        conn, err := net.Dial("tcp", net.JoinHostPort(host, port))
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer conn.Close()
+
+        // Handshake as a peer of the same chain
+        status := local.LocalStatus()
+        encoder, decoder := json.NewEncoder(conn), json.NewDecoder(conn)
+        if err := encoder.Encode(&message{Code: msgStatus, Status: &status}); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := readStatus(decoder); err != nil {
+            t.Fatal(err)
+        }
+        waitForPeers(t, local, 1)
+
+        if err := encoder.Encode(test.msg); err != nil {
+            t.Fatal(err)
+        }
+        conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+        if err := decoder.Decode(new(message)); !errors.Is(err, io.EOF) {
+            t.Fatalf("%s: connection not closed: %v", test.name, err)
+        }
+        waitForPeers(t, local, 0)
+    }
+}
+
+// Agreement messages of a validator reach the validators of its peers
+func TestConsensusMessageExchange(t *testing.T) {
+    local, _, _ := newTestNode(t, blockchain.DefaultGenesis())
+    remote, _, _ := newTestNode(t, blockchain.DefaultGenesis())
+
+    // One validator on each node, each node's network bridged to its peers
+    localAgreement, remoteAgreement := hybrid.NewLocalNetwork(), hybrid.NewLocalNetwork()
+    sender, receiver := localAgreement.Join(), remoteAgreement.Join()
+    localAgreement.SetRelay(local.BroadcastConsensus)
+    remote.SetConsensusHandler(remoteAgreement.Deliver)
+
+    if err := remote.AddPeer(local.GetListenAddr()); err != nil {
+        t.Fatal(err)
+    }
+    waitForPeers(t, local, 1)
+
+    vote := &types.Vote{Validator: types.Address{0xa}, Number: 1, Type: types.VotePrevote, Signature: []byte{1}}
+    sender.Broadcast(&hybrid.ConsensusMessage{Vote: vote})
+    select {
+    case msg := <-receiver.Messages():
+        if msg.Vote == nil || msg.Vote.Validator != vote.Validator || msg.Vote.Number != vote.Number {
+            t.Fatalf("received %+v, want vote %+v", msg, vote)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("vote not delivered to the peer's validator")
+    }
+}
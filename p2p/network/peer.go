@@ -0,0 +1,199 @@
+package network
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/consensus/hybrid"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// Peers talk JSON messages over TCP. Every connection opens with a status
+// exchange, the dialing side first; blocks and agreement messages only flow
+// once HandleStatus accepted the other side.
+const (
+    msgStatus    = "status"
+    msgBlock     = "block"
+    msgConsensus = "consensus"
+
+    dialTimeout      = 2 * time.Second
+    handshakeTimeout = 5 * time.Second
+    writeTimeout     = 5 * time.Second
+)
+
+var (
+    ErrHandshake        = errors.New("peer handshake failed")
+    ErrMalformedBlock   = errors.New("malformed block")
+    ErrMalformedMessage = errors.New("malformed consensus message")
+)
+
+// message is the envelope of everything sent between peers
+type message struct {
+    Code      string                   `json:"code"`
+    Status    *Status                  `json:"status,omitempty"`
+    Block     *types.Block             `json:"block,omitempty"`
+    Consensus *hybrid.ConsensusMessage `json:"consensus,omitempty"`
+}
+
+// send writes msg to the peer's connection
+func (p *PeerInfo) send(msg *message) error {
+    p.writeMu.Lock()
+    defer p.writeMu.Unlock()
+
+    p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+    return json.NewEncoder(p.conn).Encode(msg)
+}
+
+// readStatus reads the status a peer opens its side of the handshake with
+func readStatus(decoder *json.Decoder) (Status, error) {
+    var msg message
+    if err := decoder.Decode(&msg); err != nil {
+        return Status{}, fmt.Errorf("%w: %v", ErrHandshake, err)
+    }
+    if msg.Code != msgStatus || msg.Status == nil {
+        return Status{}, fmt.Errorf("%w: expected status, got %q", ErrHandshake, msg.Code)
+    }
+    return *msg.Status, nil
+}
+
+// writeStatus sends the local status on conn
+func (n *Network) writeStatus(conn net.Conn) error {
+    status := n.LocalStatus()
+    conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+    if err := json.NewEncoder(conn).Encode(&message{Code: msgStatus, Status: &status}); err != nil {
+        return fmt.Errorf("%w: %v", ErrHandshake, err)
+    }
+    return nil
+}
+
+// handshake exchanges statuses over a new connection and checks the peer's
+// with HandleStatus. The dialing side speaks first.
+func (n *Network) handshake(address string, conn net.Conn, decoder *json.Decoder, dialed bool) error {
+    conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+    defer conn.SetReadDeadline(time.Time{})
+
+    var (
+        status Status
+        err    error
+    )
+    if dialed {
+        if err = n.writeStatus(conn); err == nil {
+            status, err = readStatus(decoder)
+        }
+    } else {
+        if status, err = readStatus(decoder); err == nil {
+            err = n.writeStatus(conn)
+        }
+    }
+    if err != nil {
+        return err
+    }
+    return n.HandleStatus(address, status)
+}
+
+// listen accepts connections from peers until the listener is closed
+func (n *Network) listen(listener net.Listener) {
+    for {
+        conn, err := listener.Accept()
+        if err != nil {
+            return
+        }
+        go n.handleInbound(conn)
+    }
+}
+
+// handleInbound runs the handshake with a peer that dialed in and adds it
+func (n *Network) handleInbound(conn net.Conn) {
+    address := conn.RemoteAddr().String()
+    if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+        address = fmt.Sprintf("/ip4/%s/tcp/%d", tcpAddr.IP, tcpAddr.Port)
+    }
+    decoder := json.NewDecoder(conn)
+    if err := n.handshake(address, conn, decoder, false); err != nil {
+        conn.Close()
+        return
+    }
+    n.addConnectedPeer(address, conn, decoder)
+}
+
+// readLoop hands the blocks a peer sends to HandleBlock and its agreement
+// messages to HandleConsensus until the connection fails, then removes the
+// peer
+func (n *Network) readLoop(address string, conn net.Conn, decoder *json.Decoder) {
+    for {
+        var msg message
+        if err := decoder.Decode(&msg); err != nil {
+            fmt.Printf("🔌 [P2P] Connection to %s closed: %v\n", address, err)
+            n.removePeerConn(address, conn)
+            return
+        }
+        switch msg.Code {
+        case msgBlock:
+            if err := validateBlockShape(msg.Block); err != nil {
+                fmt.Printf("🚫 [P2P] Dropping %s: %v\n", address, err)
+                n.removePeerConn(address, conn)
+                return
+            }
+            n.HandleBlock(address, msg.Block)
+        case msgConsensus:
+            if err := validateConsensusShape(msg.Consensus); err != nil {
+                fmt.Printf("🚫 [P2P] Dropping %s: %v\n", address, err)
+                n.removePeerConn(address, conn)
+                return
+            }
+            n.HandleConsensus(address, msg.Consensus)
+        default:
+            fmt.Printf("⚠️  [P2P] Unexpected %q message from %s\n", msg.Code, address)
+        }
+    }
+}
+
+// validateBlockShape rejects blocks missing the fields every later check
+// dereferences. Decoding JSON leaves null fields and list entries nil, and
+// such a block says nothing about the chain: the peer sending it is broken
+// or hostile.
+func validateBlockShape(block *types.Block) error {
+    switch {
+    case block == nil:
+        return fmt.Errorf("%w: no block", ErrMalformedBlock)
+    case block.Header == nil:
+        return fmt.Errorf("%w: no header", ErrMalformedBlock)
+    case block.Header.Number == nil:
+        return fmt.Errorf("%w: no number", ErrMalformedBlock)
+    case block.Header.Difficulty == nil:
+        return fmt.Errorf("%w: no difficulty", ErrMalformedBlock)
+    }
+    for i, tx := range block.Transactions {
+        if tx == nil {
+            return fmt.Errorf("%w: transaction %d is null", ErrMalformedBlock, i)
+        }
+    }
+    for i, vote := range block.Votes {
+        if vote == nil {
+            return fmt.Errorf("%w: vote %d is null", ErrMalformedBlock, i)
+        }
+    }
+    for i, vote := range block.LastCommit {
+        if vote == nil {
+            return fmt.Errorf("%w: last commit vote %d is null", ErrMalformedBlock, i)
+        }
+    }
+    return nil
+}
+
+// validateConsensusShape rejects agreement messages that are neither a vote
+// nor a proposal of a well-formed block
+func validateConsensusShape(msg *hybrid.ConsensusMessage) error {
+    switch {
+    case msg == nil:
+        return fmt.Errorf("%w: no message", ErrMalformedMessage)
+    case (msg.Proposal == nil) == (msg.Vote == nil):
+        return fmt.Errorf("%w: want exactly one of proposal and vote", ErrMalformedMessage)
+    case msg.Proposal != nil:
+        return validateBlockShape(msg.Proposal.Block)
+    }
+    return nil
+}
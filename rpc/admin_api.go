@@ -0,0 +1,34 @@
+package rpc
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/blockchain"
+)
+
+// AdminAPI serves node maintenance methods under the admin_ namespace. It
+// modifies the chain, so it is only registered when explicitly enabled.
+type AdminAPI struct {
+    chain *blockchain.Blockchain
+}
+
+// RegisterAdminAPI installs the admin_ methods on server
+func RegisterAdminAPI(server *Server, chain *blockchain.Blockchain) {
+    api := &AdminAPI{chain: chain}
+    server.Register("admin_setHead", api.SetHead)
+}
+
+// SetHead rewinds the canonical chain to the given block number
+func (api *AdminAPI) SetHead(params json.RawMessage) (interface{}, error) {
+    var number *uint64
+    if err := parseParams(params, &number); err != nil {
+        return nil, err
+    }
+    if number == nil {
+        return nil, fmt.Errorf("%w: block number required", ErrInvalidParams)
+    }
+    if err := api.chain.SetHead(*number); err != nil {
+        return nil, err
+    }
+    return true, nil
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// RPCBlock is a block together with its hash
+type RPCBlock struct {
+    Hash types.Hash `json:"hash"`
+    *types.Block
+}
+
+// ChainAPI serves read-only chain data under the chain_ namespace
+type ChainAPI struct {
+    chain *blockchain.Blockchain
+}
+
+// RegisterChainAPI installs the chain_ methods on server
+func RegisterChainAPI(server *Server, chain *blockchain.Blockchain) {
+    api := &ChainAPI{chain: chain}
+    server.Register("chain_blockNumber", api.BlockNumber)
+    server.Register("chain_getBlockByNumber", api.GetBlockByNumber)
+    server.Register("chain_getBlockByHash", api.GetBlockByHash)
+    server.Register("chain_getFinalizedBlock", api.GetFinalizedBlock)
+    server.Register("chain_getSafeBlock", api.GetSafeBlock)
+    server.Register("chain_getTransactionByHash", api.GetTransactionByHash)
+    server.Register("chain_getBlockRewards", api.GetBlockRewards)
+}
+
+// RPCTransaction is a canonical transaction with its position in the chain
+type RPCTransaction struct {
+    Hash        types.Hash `json:"hash"`
+    BlockHash   types.Hash `json:"blockHash"`
+    BlockNumber uint64     `json:"blockNumber"`
+    Index       int        `json:"transactionIndex"`
+    *types.Transaction
+}
+
+// BlockNumber returns the number of the canonical head
+func (api *ChainAPI) BlockNumber(params json.RawMessage) (interface{}, error) {
+    return api.chain.GetCurrentBlock().Header.Number.Uint64(), nil
+}
+
+// GetBlockByNumber accepts a number or one of "latest", "safe", "finalized"
+func (api *ChainAPI) GetBlockByNumber(params json.RawMessage) (interface{}, error) {
+    var tag json.RawMessage
+    if err := parseParams(params, &tag); err != nil {
+        return nil, err
+    }
+    block, err := api.resolveBlock(tag)
+    if err != nil {
+        return nil, err
+    }
+    return api.toRPCBlock(block), nil
+}
+
+// GetBlockByHash returns any stored block, canonical or not
+func (api *ChainAPI) GetBlockByHash(params json.RawMessage) (interface{}, error) {
+    var blockHash types.Hash
+    if err := parseParams(params, &blockHash); err != nil {
+        return nil, err
+    }
+    return api.toRPCBlock(api.chain.GetBlockByHash(blockHash)), nil
+}
+
+// GetFinalizedBlock returns the latest finalized block
+func (api *ChainAPI) GetFinalizedBlock(params json.RawMessage) (interface{}, error) {
+    return api.toRPCBlock(api.chain.GetFinalizedBlock()), nil
+}
+
+// GetSafeBlock returns the latest safe block
+func (api *ChainAPI) GetSafeBlock(params json.RawMessage) (interface{}, error) {
+    return api.toRPCBlock(api.chain.GetSafeBlock()), nil
+}
+
+// GetTransactionByHash returns a transaction included in the canonical chain
+func (api *ChainAPI) GetTransactionByHash(params json.RawMessage) (interface{}, error) {
+    var txHash types.Hash
+    if err := parseParams(params, &txHash); err != nil {
+        return nil, err
+    }
+    tx, blockHash, number, index, ok := api.chain.GetTransaction(txHash)
+    if !ok {
+        return nil, nil
+    }
+    return &RPCTransaction{
+        Hash:        txHash,
+        BlockHash:   blockHash,
+        BlockNumber: number,
+        Index:       index,
+        Transaction: tx,
+    }, nil
+}
+
+// GetBlockRewards returns how the reward of a block was paid out. It accepts
+// the same block numbers and tags as GetBlockByNumber.
+func (api *ChainAPI) GetBlockRewards(params json.RawMessage) (interface{}, error) {
+    var tag json.RawMessage
+    if err := parseParams(params, &tag); err != nil {
+        return nil, err
+    }
+    block, err := api.resolveBlock(tag)
+    if err != nil || block == nil {
+        return nil, err
+    }
+    return api.chain.GetBlockRewards(api.chain.CalculateHash(block.Header)), nil
+}
+
+func (api *ChainAPI) resolveBlock(tag json.RawMessage) (*types.Block, error) {
+    var name string
+    if err := json.Unmarshal(tag, &name); err == nil {
+        switch name {
+        case "", "latest":
+            return api.chain.GetCurrentBlock(), nil
+        case "safe":
+            return api.chain.GetSafeBlock(), nil
+        case "finalized":
+            return api.chain.GetFinalizedBlock(), nil
+        }
+        number, err := strconv.ParseUint(name, 0, 64)
+        if err != nil {
+            return nil, fmt.Errorf("%w: unknown block tag %q", ErrInvalidParams, name)
+        }
+        return api.chain.GetBlockByNumber(number), nil
+    }
+
+    var number uint64
+    if err := json.Unmarshal(tag, &number); err != nil {
+        return nil, fmt.Errorf("%w: block number or tag expected", ErrInvalidParams)
+    }
+    return api.chain.GetBlockByNumber(number), nil
+}
+
+func (api *ChainAPI) toRPCBlock(block *types.Block) *RPCBlock {
+    if block == nil {
+        return nil
+    }
+    return &RPCBlock{Hash: api.chain.CalculateHash(block.Header), Block: block}
+}
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+    "encoding/json"
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/types"
+)
+
+// DebugAPI serves diagnostic data under the debug_ namespace
+type DebugAPI struct {
+    chain *blockchain.Blockchain
+}
+
+// RegisterDebugAPI installs the debug_ methods on server
+func RegisterDebugAPI(server *Server, chain *blockchain.Blockchain) {
+    api := &DebugAPI{chain: chain}
+    server.Register("debug_getBadBlocks", api.GetBadBlocks)
+    server.Register("debug_getBadBlock", api.GetBadBlock)
+}
+
+// GetBadBlocks returns the recently rejected blocks, newest first
+func (api *DebugAPI) GetBadBlocks(params json.RawMessage) (interface{}, error) {
+    return api.chain.BadBlocks(), nil
+}
+
+// GetBadBlock returns one rejected block by hash
+func (api *DebugAPI) GetBadBlock(params json.RawMessage) (interface{}, error) {
+    var blockHash types.Hash
+    if err := parseParams(params, &blockHash); err != nil {
+        return nil, err
+    }
+    return api.chain.GetBadBlock(blockHash), nil
+}
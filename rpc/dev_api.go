@@ -0,0 +1,77 @@
+package rpc
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/dev"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// DevAPI serves the dev_ methods of a node run with the dev engine, which
+// seals blocks as soon as transactions arrive or a block is asked for
+type DevAPI struct {
+    chain  *blockchain.Blockchain
+    sealer *dev.Sealer
+}
+
+// RegisterDevAPI installs the dev_ methods on server. Transactions sent with
+// dev_sendTransaction are only sealed while sealer runs (Sealer.Start).
+func RegisterDevAPI(server *Server, chain *blockchain.Blockchain, sealer *dev.Sealer) {
+    api := &DevAPI{chain: chain, sealer: sealer}
+    server.Register("dev_sendTransaction", api.SendTransaction)
+    server.Register("dev_seal", api.Seal)
+}
+
+// SendTransaction announces a signed transaction as pending and returns its
+// hash. The sealer puts it in a block of its own; if the block fails, the
+// sealer logs why and the transaction is dropped.
+func (api *DevAPI) SendTransaction(params json.RawMessage) (interface{}, error) {
+    var tx *types.Transaction
+    if err := parseParams(params, &tx); err != nil {
+        return nil, err
+    }
+    if tx == nil {
+        return nil, fmt.Errorf("%w: transaction required", ErrInvalidParams)
+    }
+    if err := api.checkTransaction(tx); err != nil {
+        return nil, err
+    }
+    api.chain.PostPendingTransactions([]*types.Transaction{tx})
+    return hash.CalculateTransactionHash(tx), nil
+}
+
+// Seal seals a block with the given signed transactions on top of the head,
+// an empty one without params, and returns it
+func (api *DevAPI) Seal(params json.RawMessage) (interface{}, error) {
+    var txs []*types.Transaction
+    if err := parseParams(params, &txs); err != nil {
+        return nil, err
+    }
+    for i, tx := range txs {
+        if tx == nil {
+            return nil, fmt.Errorf("%w: transaction %d is null", ErrInvalidParams, i)
+        }
+        if err := api.checkTransaction(tx); err != nil {
+            return nil, fmt.Errorf("transaction %d: %w", i, err)
+        }
+    }
+    block, err := api.sealer.Commit(txs)
+    if err != nil {
+        return nil, err
+    }
+    return &RPCBlock{Hash: api.chain.CalculateHash(block.Header), Block: block}, nil
+}
+
+// checkTransaction rejects transactions that are not signed for this chain
+func (api *DevAPI) checkTransaction(tx *types.Transaction) error {
+    if tx.ChainID != api.chain.ChainID() {
+        return fmt.Errorf("%w: transaction for chain %d on chain %d", ErrInvalidParams, tx.ChainID, api.chain.ChainID())
+    }
+    if _, err := keys.TransactionSender(tx); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidParams, err)
+    }
+    return nil
+}
@@ -0,0 +1,152 @@
+package rpc
+
+import (
+    "bytes"
+    "encoding/json"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/selsichain/selsichain-core/core/blockchain"
+    "github.com/selsichain/selsichain-core/core/consensus/dev"
+    "github.com/selsichain/selsichain-core/core/types"
+    "github.com/selsichain/selsichain-core/crypto/hash"
+    "github.com/selsichain/selsichain-core/crypto/keys"
+)
+
+// newDevServer serves the dev_ methods of an in-memory dev chain on which
+// the returned key is funded
+func newDevServer(t *testing.T) (*Server, *blockchain.Blockchain, *dev.Sealer, *keys.KeyPair) {
+    t.Helper()
+    key, err := new(keys.KeyManager).GenerateKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    genesis := blockchain.DefaultGenesis()
+    genesis.Alloc[key.Address] = blockchain.GenesisAccount{Balance: big.NewInt(1e18)}
+    engine := dev.NewEngine(nil)
+    chain, err := blockchain.NewBlockchain(&blockchain.Config{Genesis: genesis}, engine)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(chain.Close)
+
+    sealer := dev.NewSealer(engine, chain, types.Address{0xc})
+    server := NewServer()
+    RegisterDevAPI(server, chain, sealer)
+    return server, chain, sealer, key
+}
+
+// call posts a request for method with params to server and returns the
+// result, or fails unless the error code is wantCode
+func call(t *testing.T, server *Server, wantCode int, method string, params ...interface{}) json.RawMessage {
+    t.Helper()
+    if params == nil {
+        params = []interface{}{}
+    }
+    body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+    if err != nil {
+        t.Fatal(err)
+    }
+    recorder := httptest.NewRecorder()
+    server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+
+    var resp struct {
+        Result json.RawMessage `json:"result"`
+        Error  *rpcError       `json:"error"`
+    }
+    if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+        t.Fatal(err)
+    }
+    switch {
+    case resp.Error == nil && wantCode != 0:
+        t.Fatalf("%s: succeeded, want error %d", method, wantCode)
+    case resp.Error != nil && resp.Error.Code != wantCode:
+        t.Fatalf("%s: error %d %s", method, resp.Error.Code, resp.Error.Message)
+    }
+    return resp.Result
+}
+
+// newTransfer returns key's transfer of 1 wei at nonce
+func newTransfer(t *testing.T, key *keys.KeyPair, chainID uint64, nonce uint64) *types.Transaction {
+    t.Helper()
+    to := types.Address{0xb}
+    tx := &types.Transaction{
+        Nonce:    nonce,
+        GasPrice: big.NewInt(1000000000),
+        Gas:      21000,
+        To:       &to,
+        Value:    big.NewInt(1),
+        Type:     types.TxRegular,
+        ChainID:  chainID,
+    }
+    if err := key.SignTransaction(tx); err != nil {
+        t.Fatal(err)
+    }
+    return tx
+}
+
+func TestDevSendTransaction(t *testing.T) {
+    server, chain, sealer, key := newDevServer(t)
+    heads := chain.SubscribeNewHead(1)
+    defer heads.Unsubscribe()
+    sealer.Start()
+    defer sealer.Stop()
+
+    tx := newTransfer(t, key, chain.ChainID(), 0)
+    var txHash types.Hash
+    if err := json.Unmarshal(call(t, server, 0, "dev_sendTransaction", tx), &txHash); err != nil {
+        t.Fatal(err)
+    }
+    if txHash != hash.CalculateTransactionHash(tx) {
+        t.Fatalf("returned hash %x, want %x", txHash, hash.CalculateTransactionHash(tx))
+    }
+
+    // The sealer puts it in a block at once
+    select {
+    case <-heads.Chan():
+    case <-time.After(10 * time.Second):
+        t.Fatal("no block sealed")
+    }
+    if _, _, number, _, ok := chain.GetTransaction(txHash); !ok || number != 1 {
+        t.Fatalf("transaction not in block #1")
+    }
+
+    // Transactions for another chain or unsigned are refused
+    call(t, server, codeInvalidParams, "dev_sendTransaction", newTransfer(t, key, chain.ChainID()+1, 1))
+    unsigned := newTransfer(t, key, chain.ChainID(), 1)
+    unsigned.V, unsigned.R, unsigned.S = nil, nil, nil
+    call(t, server, codeInvalidParams, "dev_sendTransaction", unsigned)
+    call(t, server, codeInvalidParams, "dev_sendTransaction")
+}
+
+func TestDevSeal(t *testing.T) {
+    server, chain, _, key := newDevServer(t)
+
+    var block RPCBlock
+    if err := json.Unmarshal(call(t, server, 0, "dev_seal"), &block); err != nil {
+        t.Fatal(err)
+    }
+    if block.Header.Number.Uint64() != 1 || len(block.Transactions) != 0 {
+        t.Fatalf("sealed block #%s with %d transactions, want empty block #1", block.Header.Number, len(block.Transactions))
+    }
+
+    txs := []*types.Transaction{newTransfer(t, key, chain.ChainID(), 0), newTransfer(t, key, chain.ChainID(), 1)}
+    if err := json.Unmarshal(call(t, server, 0, "dev_seal", txs), &block); err != nil {
+        t.Fatal(err)
+    }
+    if block.Header.Number.Uint64() != 2 || len(block.Transactions) != 2 {
+        t.Fatalf("sealed block #%s with %d transactions, want block #2 with 2", block.Header.Number, len(block.Transactions))
+    }
+    if head := chain.GetCurrentBlock(); chain.CalculateHash(head.Header) != block.Hash {
+        t.Fatal("sealed block is not the head")
+    }
+
+    // A transaction the chain cannot execute fails the block
+    call(t, server, codeServerError, "dev_seal", []*types.Transaction{newTransfer(t, key, chain.ChainID(), 0)})
+    if head := chain.GetCurrentBlock(); head.Header.Number.Uint64() != 2 {
+        t.Fatalf("head moved to #%s", head.Header.Number)
+    }
+}
@@ -0,0 +1,158 @@
+// Package rpc exposes node APIs over JSON-RPC 2.0 on HTTP
+package rpc
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+)
+
+const maxRequestSize = 1024 * 1024
+
+// JSON-RPC 2.0 error codes
+const (
+    codeParseError     = -32700
+    codeInvalidRequest = -32600
+    codeMethodNotFound = -32601
+    codeInvalidParams  = -32602
+    codeServerError    = -32000
+)
+
+var ErrInvalidParams = errors.New("invalid params")
+
+// Method handles one RPC method; params is the raw positional parameter array
+type Method func(params json.RawMessage) (interface{}, error)
+
+// Server dispatches JSON-RPC requests to registered methods
+type Server struct {
+    methods  map[string]Method
+    mu       sync.RWMutex
+    http     *http.Server
+    listener net.Listener
+}
+
+type request struct {
+    Version string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id"`
+    Method  string          `json:"method"`
+    Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+    Version string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id"`
+    Result  interface{}     `json:"result,omitempty"`
+    Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+    Code    int    `json:"code"`
+    Message string `json:"message"`
+}
+
+// NewServer creates a server without any methods
+func NewServer() *Server {
+    return &Server{
+        methods: make(map[string]Method),
+    }
+}
+
+// Register installs a method under name, e.g. "chain_blockNumber"
+func (s *Server) Register(name string, method Method) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.methods[name] = method
+}
+
+// Start listens on addr and serves requests in the background
+func (s *Server) Start(addr string) error {
+    listener, err := net.Listen("tcp", addr)
+    if err != nil {
+        return fmt.Errorf("failed to listen on %s: %w", addr, err)
+    }
+    s.listener = listener
+    s.http = &http.Server{
+        Handler:     s,
+        ReadTimeout: 30 * time.Second,
+    }
+    go s.http.Serve(listener)
+
+    fmt.Printf("🔌 RPC server listening on http://%s\n", listener.Addr())
+    return nil
+}
+
+// Stop shuts the HTTP listener down
+func (s *Server) Stop() {
+    if s.http != nil {
+        s.http.Close()
+        fmt.Println("🛑 RPC server stopped")
+    }
+}
+
+// ServeHTTP implements http.Handler for POSTed JSON-RPC requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    var req request
+    resp := response{Version: "2.0"}
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+        resp.Error = &rpcError{Code: codeParseError, Message: err.Error()}
+    } else {
+        resp.ID = req.ID
+        resp.Result, resp.Error = s.call(&req)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) call(req *request) (interface{}, *rpcError) {
+    if req.Version != "2.0" || req.Method == "" {
+        return nil, &rpcError{Code: codeInvalidRequest, Message: "invalid request"}
+    }
+
+    s.mu.RLock()
+    method, exists := s.methods[req.Method]
+    s.mu.RUnlock()
+    if !exists {
+        return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %s not found", req.Method)}
+    }
+
+    result, err := method(req.Params)
+    if err != nil {
+        if errors.Is(err, ErrInvalidParams) {
+            return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+        }
+        return nil, &rpcError{Code: codeServerError, Message: err.Error()}
+    }
+    return result, nil
+}
+
+// parseParams decodes the positional params into targets; missing trailing
+// params leave their targets untouched
+func parseParams(params json.RawMessage, targets ...interface{}) error {
+    if len(params) == 0 || string(params) == "null" {
+        return nil
+    }
+    var values []json.RawMessage
+    if err := json.Unmarshal(params, &values); err != nil {
+        return fmt.Errorf("%w: params must be an array", ErrInvalidParams)
+    }
+    if len(values) > len(targets) {
+        return fmt.Errorf("%w: too many params (want at most %d)", ErrInvalidParams, len(targets))
+    }
+    for i, value := range values {
+        if err := json.Unmarshal(value, targets[i]); err != nil {
+            return fmt.Errorf("%w: param %d: %v", ErrInvalidParams, i, err)
+        }
+    }
+    return nil
+}